@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/server"
@@ -13,16 +14,43 @@ import (
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/webhook"
 )
 
 func main() {
 	port := flag.String("port", "8080", "default port to listen on")
 	authz := flag.Bool("rbac", false, "enable role-based access control for request commands.")
+	maxRooms := flag.Int("max-rooms", 0, "maximum number of rooms the server will create. A value of 0 means no limit.")
+	maxStreams := flag.Int("max-streams", 0, "maximum number of streams the server will register. A value of 0 means no limit.")
+	maxRoomQueue := flag.Int("max-room-queue", 0, "maximum number of streams that may be queued in a single room, across all of its users. A value of 0 means no limit.")
+	maxRoomsPerIp := flag.Int("max-rooms-per-ip", 0, "maximum number of rooms a single ip address may create within --room-creation-window. A value of 0 means no limit.")
+	roomCreationWindow := flag.Duration("room-creation-window", socket.DEFAULT_ROOM_CREATION_WINDOW, "period of time over which room creations are counted for --max-rooms-per-ip.")
+	chatLimit := flag.Int("chat-limit", socket.DEFAULT_CHAT_MESSAGE_LIMIT, "maximum number of chat messages a single client may send within --chat-limit-window. Commands are limited separately, to twice as many. A value of 0 means no limit.")
+	chatLimitWindow := flag.Duration("chat-limit-window", socket.DEFAULT_CHAT_MESSAGE_WINDOW, "period of time over which chat messages are counted for --chat-limit.")
+	pingInterval := flag.Duration("ping-interval", connection.DEFAULT_PING_INTERVAL, "amount of time between pings sent to each websocket connection. Connections that do not respond to two consecutive pings are disconnected. A value of 0 disables pings.")
+	disableCompression := flag.Bool("disable-compression", false, "disable per-message compression of large websocket messages.")
+	incrementalQueueSync := flag.Bool("incremental-queue-sync", false, "broadcast queue changes as incremental events rather than full queue syncs.")
+	reorderWindow := flag.Duration("reorder-batch-window", playback.DEFAULT_REORDER_BROADCAST_WINDOW, "period of time after a queue re-order over which further re-orders from the same user are coalesced into a single re-order and broadcast. A value of 0 applies and broadcasts every re-order.")
+	streamLoadAcks := flag.Bool("stream-load-acks", false, "expect clients to acknowledge \"streamload\" events, re-sending the event once to clients that do not.")
+	streamLoadAckTimeout := flag.Duration("stream-load-ack-timeout", playback.DEFAULT_STREAM_LOAD_ACK_TIMEOUT, "amount of time to wait for a \"streamload\" acknowledgement before re-sending the event.")
+	promoteCreator := flag.Bool("promote-returning-creator", false, "bind a room's creator to the admin role again when they rejoin the room with the creator token issued to them. Requires --rbac.")
+	creatorReturnPolicy := flag.String("creator-return-policy", playback.CreatorReturnKeep, "what to do with admins elected while a room's creator was away once the creator returns (\""+playback.CreatorReturnKeep+"\" or \""+playback.CreatorReturnDemote+"\").")
+	emptyRoomGrace := flag.Duration("empty-room-grace", playback.DEFAULT_EMPTY_ROOM_GRACE_PERIOD, "amount of time to keep a room after its last client leaves before reaping it.")
+	suffixUsernames := flag.Bool("suffix-usernames", false, "give clients requesting a taken username the same username followed by the smallest available number, rather than rejecting it.")
+	usernameReclaimGrace := flag.Duration("username-reclaim-grace", client.DEFAULT_USERNAME_RECLAIM_GRACE_PERIOD, "amount of time a disconnected client's username remains reserved for a client reconnecting from the same host. A value of 0 releases usernames immediately.")
+	requireUsername := flag.Bool("require-username", false, "require clients to choose a username before they can chat or queue streams.")
+	roomStreamDirs := flag.String("room-stream-dirs", "", "comma-separated list of room=directory pairs scoping each room's local streams to a directory in the stream data root (e.g. \"movies=films,shows=tv\").")
+	stateDir := flag.String("state-dir", "", "directory to periodically save room queues and playback state to, and restore them from on boot.")
+	webhookUrl := flag.String("webhook-url", "", "url to POST room lifecycle events (room created, first stream played, room reaped) to.")
+	webhookSecret := flag.String("webhook-secret", "", "secret used to sign webhook payloads with an HMAC-SHA256 \""+webhook.SIGNATURE_HEADER+"\" header.")
 	flag.Parse()
 
 	nsHandler := connection.NewNamespaceHandler()
 	connHandler := connection.NewHandler(nsHandler)
 	cmdHandler := cmd.NewHandler()
+	clientHandler := client.NewHandler()
+	clientHandler.SetSuffixTakenUsernames(*suffixUsernames)
+	clientHandler.SetUsernameReclaimGracePeriod(*usernameReclaimGrace)
 
 	if *authz {
 		log.Printf("INF AUTHZ rbac authorization enabled.\n")
@@ -33,16 +61,66 @@ func main() {
 		connHandler = connection.NewHandlerWithRBAC(authorizer, nsHandler)
 		cmdHandler = cmd.NewHandlerWithRBAC(authorizer)
 
+		// revoke time-bound role bindings once they expire
+		rbac.NewBindingSweeper(cmd.NotifyExpiredBinding(clientHandler)).Init(authorizer)
+
+	}
+
+	connHandler.SetPingInterval(*pingInterval)
+
+	playbackHandler := playback.NewGarbageCollectedHandler(nsHandler)
+	playbackHandler.SetMaxPlaybacks(*maxRooms)
+	playbackHandler.SetIncrementalQueueSync(*incrementalQueueSync)
+	playbackHandler.SetEmptyRoomGracePeriod(*emptyRoomGrace)
+	playbackHandler.SetStreamLoadAcks(*streamLoadAcks, *streamLoadAckTimeout)
+	playbackHandler.SetReorderBroadcastWindow(*reorderWindow)
+	playbackHandler.SetMaxRoomQueueItems(*maxRoomQueue)
+
+	if len(*webhookUrl) > 0 {
+		log.Printf("INF WEBHOOK room lifecycle events will be sent to %q\n", *webhookUrl)
+		playbackHandler.SetLifecycleNotifier(webhook.NewHTTPNotifier(*webhookUrl, *webhookSecret))
 	}
 
+	if len(*roomStreamDirs) > 0 {
+		for _, pair := range strings.Split(*roomStreamDirs, ",") {
+			segs := strings.SplitN(pair, "=", 2)
+			if len(segs) != 2 || len(segs[0]) == 0 {
+				log.Fatalf("ERR invalid --room-stream-dirs entry %q: expected room=directory\n", pair)
+			}
+			if err := playbackHandler.SetRoomStreamRoot(segs[0], segs[1]); err != nil {
+				log.Fatalf("ERR unable to scope room %q to stream directory %q: %v\n", segs[0], segs[1], err)
+			}
+			log.Printf("INF PLAYBACK room %q will be scoped to stream directory %q\n", segs[0], segs[1])
+		}
+	}
+
+	if len(*stateDir) > 0 {
+		if err := playbackHandler.LoadSnapshots(*stateDir); err != nil {
+			log.Fatalf("ERR unable to restore room snapshots from %q: %v\n", *stateDir, err)
+		}
+		log.Printf("INF PLAYBACK room state will be saved to %q every %v\n", *stateDir, playback.SnapshotInterval)
+		playback.PersistSnapshots(playbackHandler, *stateDir)
+	}
+
+	streamHandler := stream.NewGarbageCollectedHandler()
+	streamHandler.SetMaxStreams(*maxStreams)
+
 	socketHandler := socket.NewHandler(
 		nsHandler,
 		connHandler,
 		cmdHandler,
-		client.NewHandler(),
-		playback.NewGarbageCollectedHandler(nsHandler),
-		stream.NewGarbageCollectedHandler(),
+		clientHandler,
+		playbackHandler,
+		streamHandler,
 	)
+	socketHandler.SetCompression(!*disableCompression)
+	socketHandler.SetRoomCreationLimit(*maxRoomsPerIp, *roomCreationWindow)
+	socketHandler.SetChatMessageLimit(*chatLimit, *chatLimitWindow)
+	socketHandler.SetRequireUsername(*requireUsername)
+	socketHandler.SetPromoteReturningCreator(*promoteCreator)
+	if err := socketHandler.SetCreatorReturnPolicy(*creatorReturnPolicy); err != nil {
+		log.Fatalf("ERR %v\n", err)
+	}
 
 	requestHandler := server.NewRequestHandler(socketHandler, connHandler)
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/juanvallejo/streaming-server/pkg/api/discovery"
 	"github.com/juanvallejo/streaming-server/pkg/api/endpoint"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 )
 
@@ -33,6 +34,7 @@ type Handler interface {
 type ApiHandler struct {
 	endpoints   map[string]endpoint.ApiEndpoint
 	connections connection.ConnectionHandler
+	playbacks   playback.PlaybackHandler
 }
 
 func (h *ApiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -91,20 +93,26 @@ func (h *ApiHandler) RegisterEndpoint(e endpoint.ApiEndpoint) {
 
 }
 
-func NewHandler(connHandler connection.ConnectionHandler) Handler {
+func NewHandler(connHandler connection.ConnectionHandler, playbackHandler playback.PlaybackHandler) Handler {
 	handler := &ApiHandler{
 		endpoints:   make(map[string]endpoint.ApiEndpoint),
 		connections: connHandler,
+		playbacks:   playbackHandler,
 	}
 	handler.registerDefaultEndpoints()
 	return handler
 }
 
 func (h *ApiHandler) registerDefaultEndpoints() {
-	h.RegisterEndpoint(endpoint.NewStreamEndpoint())
+	h.RegisterEndpoint(endpoint.NewStreamEndpoint(h.playbacks))
 	h.RegisterEndpoint(endpoint.NewYoutubeEndpoint())
 	// TODO: reenable once new twitch API changes are implemented
 	//h.RegisterEndpoint(endpoint.NewTwitchEndpoint())
 	h.RegisterEndpoint(endpoint.NewAuthEndpoint())
 	h.RegisterEndpoint(endpoint.NewSoundCloudEndpoint())
+	h.RegisterEndpoint(endpoint.NewEventsEndpoint())
+	h.RegisterEndpoint(endpoint.NewProvidersEndpoint())
+	h.RegisterEndpoint(endpoint.NewStreamInfoEndpoint())
+	h.RegisterEndpoint(endpoint.NewDebugEndpoint(h.playbacks))
+	h.RegisterEndpoint(endpoint.NewRoomEndpoint(h.playbacks))
 }
@@ -0,0 +1,73 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/api/types"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+const DEBUG_ENDPOINT_PREFIX = "/debug"
+
+// DebugEndpoint implements ApiEndpoint
+type DebugEndpoint struct {
+	*ApiEndpointSchema
+
+	playbackHandler playback.PlaybackHandler
+}
+
+// ReapedRoomList composes a slice of recently reaped rooms
+type ReapedRoomList struct {
+	Kind  string                 `json:"kind"`
+	Items []*playback.ReapedRoom `json:"items"`
+}
+
+func (l *ReapedRoomList) Serialize() ([]byte, error) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return b, nil
+}
+
+// Handle serves operator diagnostics. "/debug/reaped" returns the rooms
+// most recently removed by the reaper, from the most recently reaped,
+// along with why they were reaped and how long they existed for.
+func (e *DebugEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	if len(segments) != 2 || segments[1] != "reaped" {
+		HandleEndpointNotFound(w)
+		return
+	}
+
+	if err := authorizeAdminRequest(connHandler, r); err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	list := &ReapedRoomList{
+		Kind:  types.API_TYPE_REAPED_ROOM_LIST,
+		Items: e.playbackHandler.ReapAudit().Rooms(),
+	}
+
+	b, err := list.Serialize()
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func NewDebugEndpoint(playbackHandler playback.PlaybackHandler) ApiEndpoint {
+	return &DebugEndpoint{
+		ApiEndpointSchema: &ApiEndpointSchema{
+			path: DEBUG_ENDPOINT_PREFIX,
+		},
+
+		playbackHandler: playbackHandler,
+	}
+}
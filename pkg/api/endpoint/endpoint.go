@@ -6,6 +6,8 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/juanvallejo/streaming-server/pkg/api/endpoint/query"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 )
 
@@ -95,3 +97,35 @@ func HandleEndpointNotFound(w http.ResponseWriter) {
 
 	w.Write(b)
 }
+
+// authorizeAdminRequest returns an error unless the request's "id" query
+// parameter identifies a connection bound to the admin role. Every request
+// is authorized when rbac is not enabled.
+func authorizeAdminRequest(connHandler connection.ConnectionHandler, r *http.Request) error {
+	authorizer := connHandler.Authorizer()
+	if authorizer == nil {
+		return nil
+	}
+
+	connId := r.URL.Query().Get(query.CONN_ID_KEY)
+	if len(connId) == 0 {
+		return fmt.Errorf("missing required parameter: id")
+	}
+
+	conn, exists := connHandler.Connection(connId)
+	if !exists {
+		return fmt.Errorf("unable to find connection by id %v", connId)
+	}
+
+	for _, b := range authorizer.Bindings() {
+		if b.Role().Name() != rbac.ADMIN_ROLE {
+			continue
+		}
+		for _, s := range b.Subjects() {
+			if s.UUID() == conn.UUID() {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("the connection specified is not authorized to use this endpoint")
+}
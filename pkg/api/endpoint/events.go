@@ -0,0 +1,135 @@
+package endpoint
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/juanvallejo/streaming-server/pkg/api/endpoint/query"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+const (
+	EVENTS_ENDPOINT_PREFIX = "/events"
+)
+
+// eventStreamEvents are the namespace events relayed to
+// read-only subscribers of the events endpoint
+var eventStreamEvents = map[string]bool{
+	"streamload":        true,
+	"streamsync":        true,
+	"queuesync":         true,
+	"queueitemadded":    true,
+	"queueitemremoved":  true,
+	"queuereordered":    true,
+	"info_queueruntime": true,
+	"chatmessage":       true,
+}
+
+var ErrEventStreamClosed = errors.New("event stream has been closed")
+
+// EventStreamBuffer is the amount of events queued for a subscriber that
+// is not keeping up. Subscribers falling further behind are disconnected,
+// rather than holding up the room's broadcasts.
+const EventStreamBuffer = 64
+
+// streamEvent is a namespace event awaiting delivery to a subscriber
+type streamEvent struct {
+	name string
+	data []byte
+}
+
+// EventsEndpoint implements ApiEndpoint
+type EventsEndpoint struct {
+	*ApiEndpointSchema
+}
+
+// Handle serves a Server-Sent Events stream of a room's broadcast
+// events until the requesting client disconnects. As with a room's
+// status, no connection to the room is required to follow its events.
+func (e *EventsEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	room := r.URL.Query().Get(query.ROOM_KEY)
+	if len(room) == 0 {
+		HandleEndpointError(fmt.Errorf("missing required parameter: room"), w)
+		return
+	}
+
+	if _, exists := connHandler.NamespaceByName(room); !exists {
+		HandleEndpointError(fmt.Errorf("unable to find room with name %q", room), w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		HandleEndpointError(fmt.Errorf("streaming responses are not supported"), w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// events are queued by broadcasters and written to the client
+	// here, so that a slow client never blocks a broadcast. done is
+	// closed once the client falls too far behind.
+	events := make(chan streamEvent, EventStreamBuffer)
+	done := make(chan struct{})
+	closed := false
+	mux := sync.Mutex{}
+
+	observer := connection.NewObserver(func(eventName string, data []byte) error {
+		if !eventStreamEvents[eventName] {
+			return nil
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		if closed {
+			return ErrEventStreamClosed
+		}
+
+		select {
+		case events <- streamEvent{name: eventName, data: data}:
+			return nil
+		default:
+			closed = true
+			close(done)
+			return fmt.Errorf("subscriber fell more than %v events behind", EventStreamBuffer)
+		}
+	})
+
+	connHandler.AddObserver(room, observer)
+	defer connHandler.RemoveObserver(room, observer)
+
+	log.Printf("INF API EVENTS subscriber (%s) added to room %q\n", observer.UUID(), room)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("INF API EVENTS subscriber (%s) removed from room %q\n", observer.UUID(), room)
+			return
+		case <-done:
+			log.Printf("INF API EVENTS subscriber (%s) removed from room %q: it was not keeping up with the room's events\n", observer.UUID(), room)
+			return
+		case evt := <-events:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.name, evt.data); err != nil {
+				log.Printf("INF API EVENTS subscriber (%s) removed from room %q: %v\n", observer.UUID(), room, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func NewEventsEndpoint() ApiEndpoint {
+	return &EventsEndpoint{
+		&ApiEndpointSchema{
+			path: EVENTS_ENDPOINT_PREFIX,
+		},
+	}
+}
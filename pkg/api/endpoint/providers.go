@@ -0,0 +1,63 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/api/types"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const PROVIDERS_ENDPOINT_PREFIX = "/providers"
+
+// ProvidersEndpoint implements ApiEndpoint
+type ProvidersEndpoint struct {
+	*ApiEndpointSchema
+}
+
+// ProviderList composes a slice of stream providers
+type ProviderList struct {
+	Kind  string             `json:"kind"`
+	Items []*stream.Provider `json:"items"`
+}
+
+func (p *ProviderList) Serialize() ([]byte, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return b, nil
+}
+
+// Handle returns the list of supported stream providers, the urls they
+// handle, and whether metadata and durations are available for their streams.
+func (e *ProvidersEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	if len(segments) > 1 {
+		HandleEndpointNotFound(w)
+		return
+	}
+
+	pList := &ProviderList{
+		Kind:  types.API_TYPE_PROVIDER_LIST,
+		Items: stream.Providers,
+	}
+
+	b, err := pList.Serialize()
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func NewProvidersEndpoint() ApiEndpoint {
+	return &ProvidersEndpoint{
+		&ApiEndpointSchema{
+			path: PROVIDERS_ENDPOINT_PREFIX,
+		},
+	}
+}
@@ -2,4 +2,7 @@ package query
 
 const (
 	CONN_ID_KEY = "id"
+	ROOM_KEY    = "room"
+	// OBSERVER_KEY marks a connection as an observer when set to "true"
+	OBSERVER_KEY = "observer"
 )
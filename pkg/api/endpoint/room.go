@@ -0,0 +1,84 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+const ROOM_ENDPOINT_PREFIX = "/room"
+
+// RoomEndpoint implements ApiEndpoint
+type RoomEndpoint struct {
+	*ApiEndpointSchema
+
+	playbackHandler playback.PlaybackHandler
+}
+
+// Handle serves read-only room state. "/room/<name>/status" returns the
+// room's current playback status, as sent to its clients in "streamsync"
+// events. Unknown rooms are answered with a 404.
+func (e *RoomEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	if len(segments) != 3 || segments[2] != "status" {
+		HandleEndpointNotFound(w)
+		return
+	}
+
+	ns, exists := connHandler.NamespaceByName(segments[1])
+	if !exists {
+		handleRoomNotFound(segments[1], w)
+		return
+	}
+
+	sPlayback, exists := e.playbackHandler.PlaybackByNamespace(ns)
+	if !exists {
+		handleRoomNotFound(segments[1], w)
+		return
+	}
+
+	// the room may be reaped while its status is read
+	status := sPlayback.GetStatus()
+	if current, exists := e.playbackHandler.PlaybackByNamespace(ns); !exists || current != sPlayback {
+		handleRoomNotFound(segments[1], w)
+		return
+	}
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func handleRoomNotFound(room string, w http.ResponseWriter) {
+	res := &ApiResponse{
+		Error:    fmt.Sprintf("unable to find room with name %q", room),
+		HTTPCode: http.StatusNotFound,
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		log.Panic("unable to marshal api error response")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write(b)
+}
+
+func NewRoomEndpoint(playbackHandler playback.PlaybackHandler) ApiEndpoint {
+	return &RoomEndpoint{
+		ApiEndpointSchema: &ApiEndpointSchema{
+			path: ROOM_ENDPOINT_PREFIX,
+		},
+
+		playbackHandler: playbackHandler,
+	}
+}
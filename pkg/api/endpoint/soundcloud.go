@@ -10,6 +10,7 @@ import (
 
 	"github.com/juanvallejo/streaming-server/pkg/api/config"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
 
 const (
@@ -93,6 +94,11 @@ func handleSoundCloudApiSearch(query string, w http.ResponseWriter) {
 }
 
 func handleSoundCloudApiStream(rawPermalink string, w http.ResponseWriter) {
+	if len(config.SC_API_KEY) == 0 {
+		HandleEndpointError(stream.ErrSoundCloudNoClientId, w)
+		return
+	}
+
 	permalink := url.QueryEscape(rawPermalink)
 
 	// resolve permalink into track id
@@ -104,6 +110,11 @@ func handleSoundCloudApiStream(rawPermalink string, w http.ResponseWriter) {
 	}
 
 	defer res.Body.Close()
+	if err := stream.SoundCloudResolveError(res.StatusCode); err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
 	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		HandleEndpointError(err, w)
@@ -148,11 +159,19 @@ func encodeApiResponse(data []byte) ([]byte, error) {
 		}
 
 		for _, track := range playlist.Tracks {
+			// tracks that are private or unavailable have no permalink
+			if track == nil || len(track.Permalink) == 0 {
+				continue
+			}
+
 			track.Kind = SoundCloudPlaylistItem
 			track.Thumb = track.Artwork
 			track.Url = track.Permalink
 			resp.Items = append(resp.Items, track)
 		}
+		if len(resp.Items) == 0 {
+			return nil, fmt.Errorf("error: this soundcloud playlist has no playable tracks, link a single track instead")
+		}
 
 		respBytes, err := json.Marshal(resp)
 		if err != nil {
@@ -6,11 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"strings"
 
+	"github.com/juanvallejo/streaming-server/pkg/api/endpoint/query"
 	"github.com/juanvallejo/streaming-server/pkg/api/types"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
 	paths "github.com/juanvallejo/streaming-server/pkg/server/path"
-	pathutil "github.com/juanvallejo/streaming-server/pkg/server/path"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
@@ -20,6 +22,8 @@ const STREAM_ENDPOINT_PREFIX = "/stream"
 // StreamEndpoint implements ApiEndpoint
 type StreamEndpoint struct {
 	*ApiEndpointSchema
+
+	playbackHandler playback.PlaybackHandler
 }
 
 // StreamList composes a slice of Stream
@@ -38,8 +42,28 @@ func (s *StreamList) Serialize() ([]byte, error) {
 }
 
 // Handle returns a "discovery" of all local streams in the server data root.
+// If a "room" query parameter is given, and that room's local streams are
+// scoped to a directory of the data root, only streams in that directory
+// are listed, and stream locations are relative to that directory.
 func (e *StreamEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
-	dir, err := ioutil.ReadDir(paths.StreamDataRootPath)
+	root := ""
+	if room := r.URL.Query().Get(query.ROOM_KEY); len(room) > 0 {
+		ns, exists := connHandler.NamespaceByName(room)
+		if !exists {
+			handleRoomNotFound(room, w)
+			return
+		}
+
+		sPlayback, exists := e.playbackHandler.PlaybackByNamespace(ns)
+		if !exists {
+			handleRoomNotFound(room, w)
+			return
+		}
+
+		root, _ = sPlayback.StreamRoot()
+	}
+
+	dir, err := ioutil.ReadDir(paths.StreamDataFilePathFromFilename(root))
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
@@ -47,7 +71,7 @@ func (e *StreamEndpoint) Handle(connHandler connection.ConnectionHandler, segmen
 
 	if len(segments) > 1 {
 		if len(segments) == 2 {
-			handleStreamMetadata(segments[1], w, r)
+			handleStreamMetadata(root, segments[1], w, r)
 			return
 		}
 
@@ -85,8 +109,8 @@ func (e *StreamEndpoint) Handle(connHandler connection.ConnectionHandler, segmen
 	w.Write(b)
 }
 
-func handleStreamMetadata(streamUrl string, w http.ResponseWriter, r *http.Request) {
-	fpath := paths.StreamDataFilePathFromFilename(streamUrl)
+func handleStreamMetadata(root, streamUrl string, w http.ResponseWriter, r *http.Request) {
+	fpath := paths.StreamDataFilePathFromFilename(path.Join(root, paths.StreamDataRelativePath(streamUrl)))
 	_, err := os.Stat(fpath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -99,13 +123,12 @@ func handleStreamMetadata(streamUrl string, w http.ResponseWriter, r *http.Reque
 	}
 
 	s := stream.NewLocalVideoStream(streamUrl)
-	localStream, ok := s.(*stream.LocalVideoStream)
-	if !ok {
+	if _, ok := s.(*stream.LocalVideoStream); !ok {
 		HandleEndpointError(fmt.Errorf("invalid local stream object"), w)
 		return
 	}
 
-	data, err := stream.FetchVideoMetadata(pathutil.StreamDataFilePathFromUrl(localStream.Url))
+	data, err := stream.FetchVideoMetadata(fpath)
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
@@ -127,10 +150,12 @@ func handleStreamMetadata(streamUrl string, w http.ResponseWriter, r *http.Reque
 	w.Write(b)
 }
 
-func NewStreamEndpoint() ApiEndpoint {
+func NewStreamEndpoint(playbackHandler playback.PlaybackHandler) ApiEndpoint {
 	return &StreamEndpoint{
-		&ApiEndpointSchema{
+		ApiEndpointSchema: &ApiEndpointSchema{
 			path: STREAM_ENDPOINT_PREFIX,
 		},
+
+		playbackHandler: playbackHandler,
 	}
 }
@@ -0,0 +1,61 @@
+package endpoint
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const STREAM_INFO_ENDPOINT_PREFIX = "/streaminfo"
+
+// StreamInfoEndpoint implements ApiEndpoint
+type StreamInfoEndpoint struct {
+	*ApiEndpointSchema
+}
+
+// Handle resolves the stream given by the "url" query parameter and returns
+// it along with its fetched metadata, or the error encountered fetching it.
+// The stream is not registered with the server. When rbac is enabled, an "id"
+// query parameter must identify a connection bound to the admin role.
+func (e *StreamInfoEndpoint) Handle(connHandler connection.ConnectionHandler, segments []string, w http.ResponseWriter, r *http.Request) {
+	if len(segments) > 1 {
+		HandleEndpointNotFound(w)
+		return
+	}
+
+	if err := authorizeAdminRequest(connHandler, r); err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	streamUrl := r.URL.Query().Get("url")
+	if len(streamUrl) == 0 {
+		HandleEndpointError(fmt.Errorf("missing required parameter: url"), w)
+		return
+	}
+
+	inspection, err := stream.Inspect(streamUrl, stream.InspectTimeout)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	b, err := inspection.Serialize()
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func NewStreamInfoEndpoint() ApiEndpoint {
+	return &StreamInfoEndpoint{
+		&ApiEndpointSchema{
+			path: STREAM_INFO_ENDPOINT_PREFIX,
+		},
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/api/config"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
@@ -24,8 +26,16 @@ var (
 	youtubeMaxPlaylistResults   = 15
 	youtubeEndpointTemplate     = "https://www.googleapis.com/youtube/v3/search?part=snippet&q=%v&type=video&maxResults=%v&key=%v"
 	youtubeEndpointListTemplate = "https://www.googleapis.com/youtube/v3/playlistItems?part=snippet&playlistId=%v&maxResults=%v&key=%v"
+
+	// youtubeClient is used for every youtube api request, so that a slow
+	// response never blocks its caller for longer than youtubeRequestTimeout
+	youtubeClient = &http.Client{
+		Timeout: youtubeRequestTimeout,
+	}
 )
 
+const youtubeRequestTimeout = 10 * time.Second
+
 // YoutubeEndpoint implements ApiEndpoint
 type YoutubeEndpoint struct {
 	*ApiEndpointSchema
@@ -122,23 +132,61 @@ func handleApiSearch(searchQuery string, w http.ResponseWriter) {
 }
 
 func handleApiList(listId string, w http.ResponseWriter) {
-	reqUrl := fmt.Sprintf(youtubeEndpointListTemplate, listId, youtubeMaxPlaylistResults, config.YT_API_KEY)
-	handleApiRequest(YoutubePlaylistItem, reqUrl, w)
+	// the list id is received url-encoded, and is
+	// escaped once more when building the request url
+	unescaped, err := url.QueryUnescape(listId)
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	resp, err := FetchYoutubePlaylist(unescaped)
+	writeYoutubeResponse(resp, err, w)
+}
+
+// FetchYoutubePlaylist receives a youtube playlist id and
+// returns up to youtubeMaxPlaylistResults of its videos
+func FetchYoutubePlaylist(listId string) (*YoutubeEndpointResponse, error) {
+	reqUrl := fmt.Sprintf(youtubeEndpointListTemplate, url.QueryEscape(listId), youtubeMaxPlaylistResults, config.YT_API_KEY)
+	return fetchYoutubeItems(YoutubePlaylistItem, reqUrl)
 }
 
 func handleApiRequest(kind string, url string, w http.ResponseWriter) {
-	res, err := http.Get(url)
+	resp, err := fetchYoutubeItems(kind, url)
+	writeYoutubeResponse(resp, err, w)
+}
+
+// writeYoutubeResponse writes the given youtube api response,
+// or handles the given error if the request failed
+func writeYoutubeResponse(resp *YoutubeEndpointResponse, err error, w http.ResponseWriter) {
+	if err != nil {
+		HandleEndpointError(err, w)
+		return
+	}
+
+	respBytes, err := resp.Encode()
 	if err != nil {
 		HandleEndpointError(err, w)
 		return
 	}
 
+	w.Write(respBytes)
+}
+
+// fetchYoutubeItems requests the given youtube api url and returns its
+// items of the given kind, conforming to the standard api response item
+// for this server.
+func fetchYoutubeItems(kind string, url string) (*YoutubeEndpointResponse, error) {
+	res, err := youtubeClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
 	defer res.Body.Close()
 
 	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		HandleEndpointError(err, w)
-		return
+		return nil, err
 	}
 
 	// modify standard youube api search result items
@@ -152,8 +200,7 @@ func handleApiRequest(kind string, url string, w http.ResponseWriter) {
 	resp := &YoutubeEndpointResponse{}
 	err = json.Unmarshal(data, resp)
 	if err != nil {
-		HandleEndpointError(err, w)
-		return
+		return nil, err
 	}
 
 	// default required spec fields for an api response item
@@ -172,13 +219,7 @@ func handleApiRequest(kind string, url string, w http.ResponseWriter) {
 		respItem.Title = respItem.Snippet.Title
 	}
 
-	respBytes, err := resp.Encode()
-	if err != nil {
-		HandleEndpointError(err, w)
-		return
-	}
-
-	w.Write(respBytes)
+	return resp, nil
 }
 
 func NewYoutubeEndpoint() ApiEndpoint {
@@ -0,0 +1,113 @@
+package endpoint
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHandleApiList(t *testing.T) {
+	defer func(template string, c *http.Client) {
+		youtubeEndpointListTemplate = template
+		youtubeClient = c
+	}(youtubeEndpointListTemplate, youtubeClient)
+
+	tests := []struct {
+		name   string
+		listId string
+		// delay is how long the youtube api takes to respond
+		delay           time.Duration
+		videoIds        []string
+		expectRequested string
+		expectUrls      []string
+		expectErr       bool
+	}{
+		{
+			name:            "playlist",
+			listId:          "PL123",
+			videoIds:        []string{"a", "b"},
+			expectRequested: "PL123",
+			expectUrls:      []string{"https://www.youtube.com/watch?v=a", "https://www.youtube.com/watch?v=b"},
+		},
+		{
+			name:            "url-encoded list id",
+			listId:          "PL%2D123",
+			videoIds:        []string{"a"},
+			expectRequested: "PL-123",
+			expectUrls:      []string{"https://www.youtube.com/watch?v=a"},
+		},
+		{
+			name:            "empty playlist",
+			listId:          "PL123",
+			expectRequested: "PL123",
+			expectUrls:      []string{},
+		},
+		{
+			name:      "slow response",
+			listId:    "PL123",
+			delay:     100 * time.Millisecond,
+			videoIds:  []string{"a"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requested := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = r.URL.Query().Get("playlistId")
+				time.Sleep(tc.delay)
+
+				items := []map[string]interface{}{}
+				for _, id := range tc.videoIds {
+					items = append(items, map[string]interface{}{
+						"kind": YoutubePlaylistItem,
+						"snippet": map[string]interface{}{
+							"title":      "video " + id,
+							"resourceId": map[string]interface{}{"videoId": id},
+						},
+					})
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+			}))
+			defer server.Close()
+
+			youtubeEndpointListTemplate = server.URL + "/playlistItems?playlistId=%v&maxResults=%v&key=%v"
+			youtubeClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+			w := httptest.NewRecorder()
+			handleApiList(tc.listId, w)
+
+			apiErr := &ApiResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), apiErr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectErr != (len(apiErr.Error) > 0) {
+				t.Fatalf("expected an error response: %v, got %q", tc.expectErr, w.Body.String())
+			}
+			if tc.expectErr {
+				return
+			}
+
+			if requested != tc.expectRequested {
+				t.Fatalf("expected playlist %q to be requested, got %q", tc.expectRequested, requested)
+			}
+
+			resp := &YoutubeEndpointResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			urls := []string{}
+			for _, item := range resp.Items {
+				urls = append(urls, item.Url)
+			}
+			if !reflect.DeepEqual(urls, tc.expectUrls) {
+				t.Fatalf("expected videos %v, got %v", tc.expectUrls, urls)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package types
 
 const (
-	API_TYPE_STREAM_LIST = "streamList"
+	API_TYPE_STREAM_LIST   = "streamList"
+	API_TYPE_PROVIDER_LIST = "providerList"
+
+	API_TYPE_REAPED_ROOM_LIST = "reapedRoomList"
 )
 
 // ApiCodec provides methods of serializing and de-serializing
@@ -0,0 +1,99 @@
+package playback
+
+import (
+	"log"
+	"time"
+)
+
+// DEFAULT_STREAM_LOAD_ACK_TIMEOUT is the amount of time to wait for a
+// "streamloaded" acknowledgement before re-sending a "streamload" event
+// to a client, unless set otherwise through Handler.SetStreamLoadAcks.
+const DEFAULT_STREAM_LOAD_ACK_TIMEOUT = 5 * time.Second
+
+// StreamLoadAcks returns true if clients sent a "streamload" event in the
+// room are expected to send a "streamloaded" acknowledgement. Clients that
+// do not ack within the room's ack timeout are sent the event once more.
+func (p *Playback) StreamLoadAcks() bool {
+	return p.streamLoadAcks
+}
+
+// ExpectStreamLoadedAck receives a connection id and the url of a stream
+// the connection has been sent a "streamload" event for. If the connection
+// does not acknowledge the stream within the room's ack timeout, and the
+// stream is still loaded, the given resend func is called once. Acks are
+// best-effort and never hold up the playback.
+func (p *Playback) ExpectStreamLoadedAck(connId, url string, resend func()) {
+	if !p.streamLoadAcks {
+		return
+	}
+
+	p.ackMux.Lock()
+	defer p.ackMux.Unlock()
+
+	if p.pendingAcks == nil {
+		p.pendingAcks = make(map[string]map[string]*time.Timer)
+	}
+	if _, exists := p.pendingAcks[connId]; !exists {
+		p.pendingAcks[connId] = make(map[string]*time.Timer)
+	}
+	if timer, exists := p.pendingAcks[connId][url]; exists {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(p.streamLoadAckTimeout, func() {
+		p.ackMux.Lock()
+		pending, exists := p.pendingAcks[connId][url]
+		if !exists || pending != timer {
+			p.ackMux.Unlock()
+			return
+		}
+		p.removeAck(connId, url)
+		p.ackMux.Unlock()
+
+		if s, exists := p.GetStream(); !exists || s.GetStreamURL() != url {
+			return
+		}
+
+		log.Printf("INF PLAYBACK connection with id %q did not acknowledge stream %q within %v. Re-sending streamload...", connId, url, p.streamLoadAckTimeout)
+		resend()
+	})
+	p.pendingAcks[connId][url] = timer
+}
+
+// AckStreamLoaded receives a connection id and the url of a stream
+// the connection has acknowledged loading. Returns a boolean (false)
+// if no acknowledgement was expected for the given stream.
+func (p *Playback) AckStreamLoaded(connId, url string) bool {
+	p.ackMux.Lock()
+	defer p.ackMux.Unlock()
+
+	timer, exists := p.pendingAcks[connId][url]
+	if !exists {
+		return false
+	}
+
+	timer.Stop()
+	p.removeAck(connId, url)
+	return true
+}
+
+// ClearStreamLoadedAcks stops waiting on any
+// acknowledgements from the given connection id.
+func (p *Playback) ClearStreamLoadedAcks(connId string) {
+	p.ackMux.Lock()
+	defer p.ackMux.Unlock()
+
+	for _, timer := range p.pendingAcks[connId] {
+		timer.Stop()
+	}
+	delete(p.pendingAcks, connId)
+}
+
+// removeAck deletes a pending ack. Caller must hold the ackMux lock.
+func (p *Playback) removeAck(connId, url string) {
+	delete(p.pendingAcks[connId], url)
+	if len(p.pendingAcks[connId]) == 0 {
+		delete(p.pendingAcks, connId)
+	}
+}
@@ -112,29 +112,15 @@ func pickAdmin(picker AdminPicker, authorizer rbac.Authorizer, ns connection.Nam
 			continue
 		}
 
-		candidate, exists := picker.Pick(ns.Connections())
+		// observers are not eligible to become admins
+		candidate, exists := picker.Pick(connection.Participants(ns.Connections()))
 		if !exists {
 			continue
 		}
 
 		// determine if at least one admin in namespace
-		if len(adminBindings) > 0 {
-			hasAdmin := false
-			for _, admins := range adminBindings {
-				for _, admin := range admins.Subjects() {
-					if findAdmin(ns.Connections(), admin) {
-						hasAdmin = true
-						break
-					}
-				}
-				if hasAdmin {
-					break
-				}
-			}
-
-			if hasAdmin {
-				continue
-			}
+		if hasAdmin(adminBindings, ns.Connections()) {
+			continue
 		}
 
 		after := time.Now().Sub(p.LastAdminDepartureTime())
@@ -168,6 +154,37 @@ func pickAdmin(picker AdminPicker, authorizer rbac.Authorizer, ns connection.Nam
 	}
 }
 
+// HasAdmin returns true if at least one of the given
+// namespace's connections is bound to the admin role.
+func HasAdmin(authorizer rbac.Authorizer, ns connection.Namespace) bool {
+	if authorizer == nil {
+		return false
+	}
+
+	adminBindings := []rbac.RoleBinding{}
+	for _, b := range authorizer.Bindings() {
+		if b.Role().Name() == rbac.ADMIN_ROLE {
+			adminBindings = append(adminBindings, b)
+		}
+	}
+
+	return hasAdmin(adminBindings, ns.Connections())
+}
+
+// hasAdmin determines if a subject of any of the given
+// admin role-bindings exists within a given set of connections
+func hasAdmin(adminBindings []rbac.RoleBinding, conns []connection.Connection) bool {
+	for _, admins := range adminBindings {
+		for _, admin := range admins.Subjects() {
+			if findAdmin(conns, admin) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // findAdmin determines if a given subject exists within a given set of connections
 func findAdmin(subjects []connection.Connection, subject rbac.Subject) bool {
 	for _, c := range subjects {
@@ -0,0 +1,22 @@
+package playback
+
+import (
+	"sync/atomic"
+)
+
+// RoomChurn counts the rooms created and reaped since the server started
+type RoomChurn struct {
+	Created int64
+	Reaped  int64
+}
+
+var roomChurn RoomChurn
+
+// Churn returns the amount of rooms created
+// and reaped since the server started
+func Churn() RoomChurn {
+	return RoomChurn{
+		Created: atomic.LoadInt64(&roomChurn.Created),
+		Reaped:  atomic.LoadInt64(&roomChurn.Reaped),
+	}
+}
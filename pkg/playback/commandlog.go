@@ -0,0 +1,72 @@
+package playback
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	// MaxCommandLogEntries is the maximum amount of executed
+	// commands retained in a Playback's command log
+	MaxCommandLogEntries = 100
+	// MaxCommandLogLength is the maximum length of a command,
+	// or its error, retained in a command log entry
+	MaxCommandLogLength = 200
+)
+
+// CommandLogEntry is a record of a command executed in a room
+type CommandLogEntry struct {
+	User    string
+	Command string
+	Error   string
+	RanAt   time.Time
+}
+
+// commandLog holds the commands most recently executed in a room
+type commandLog struct {
+	mux     sync.Mutex
+	entries []*CommandLogEntry
+}
+
+// LogCommand records a command executed in the room by the given user,
+// along with the error it returned, if any. The oldest entries are
+// dropped once MaxCommandLogEntries is exceeded.
+func (p *Playback) LogCommand(user, command string, err error) {
+	entry := &CommandLogEntry{
+		User:    user,
+		Command: truncateLogText(command),
+		RanAt:   time.Now(),
+	}
+	if err != nil {
+		entry.Error = truncateLogText(err.Error())
+	}
+
+	p.commands.mux.Lock()
+	defer p.commands.mux.Unlock()
+
+	p.commands.entries = append(p.commands.entries, entry)
+	if len(p.commands.entries) > MaxCommandLogEntries {
+		p.commands.entries = p.commands.entries[len(p.commands.entries)-MaxCommandLogEntries:]
+	}
+}
+
+// CommandLog returns the commands executed in the room,
+// ordered from the most recently executed command.
+func (p *Playback) CommandLog() []*CommandLogEntry {
+	p.commands.mux.Lock()
+	defer p.commands.mux.Unlock()
+
+	entries := make([]*CommandLogEntry, 0, len(p.commands.entries))
+	for i := len(p.commands.entries) - 1; i >= 0; i-- {
+		entries = append(entries, p.commands.entries[i])
+	}
+	return entries
+}
+
+// truncateLogText shortens the given text to MaxCommandLogLength
+func truncateLogText(text string) string {
+	if len(text) <= MaxCommandLogLength {
+		return text
+	}
+	return text[:MaxCommandLogLength] + "..."
+}
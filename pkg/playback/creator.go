@@ -0,0 +1,49 @@
+package playback
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	connutil "github.com/juanvallejo/streaming-server/pkg/socket/connection/util"
+)
+
+const (
+	// CreatorReturnKeep keeps an interim admin bound to the
+	// admin role once the room's creator is promoted on rejoin
+	CreatorReturnKeep = "keep"
+	// CreatorReturnDemote unbinds every other admin in the room
+	// once the room's creator is promoted on rejoin
+	CreatorReturnDemote = "demote"
+)
+
+// ValidCreatorReturnPolicy returns an error if the
+// given policy is not a known creator return policy
+func ValidCreatorReturnPolicy(policy string) error {
+	switch policy {
+	case CreatorReturnKeep, CreatorReturnDemote:
+		return nil
+	}
+	return fmt.Errorf("unknown creator return policy %q: expected one of %q or %q", policy, CreatorReturnKeep, CreatorReturnDemote)
+}
+
+// IssueCreatorToken generates and stores the token identifying
+// the room's creator across reconnections, replacing any token
+// previously issued for the room.
+func (p *Playback) IssueCreatorToken() (string, error) {
+	token, err := connutil.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	p.creatorToken = token
+	return token, nil
+}
+
+// IsCreatorToken returns true if the given token
+// was issued to the creator of the room
+func (p *Playback) IsCreatorToken(token string) bool {
+	if len(p.creatorToken) == 0 || len(token) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(p.creatorToken), []byte(token)) == 1
+}
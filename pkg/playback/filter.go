@@ -0,0 +1,115 @@
+package playback
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// StreamFilter restricts the streams that may be played in a room
+// by domain or by video id. Entries in the blocklist are always
+// rejected. If the allowlist contains any entries, streams that do
+// not match at least one of them are rejected as well.
+type StreamFilter struct {
+	blocked map[string]bool
+	allowed map[string]bool
+}
+
+// Block adds an entry to the blocklist
+func (f *StreamFilter) Block(entry string) {
+	f.blocked[entry] = true
+}
+
+// Unblock removes an entry from the blocklist. Returns
+// false if the entry was not in the blocklist.
+func (f *StreamFilter) Unblock(entry string) bool {
+	if _, exists := f.blocked[entry]; !exists {
+		return false
+	}
+
+	delete(f.blocked, entry)
+	return true
+}
+
+// Allow adds an entry to the allowlist
+func (f *StreamFilter) Allow(entry string) {
+	f.allowed[entry] = true
+}
+
+// Unallow removes an entry from the allowlist. Returns
+// false if the entry was not in the allowlist.
+func (f *StreamFilter) Unallow(entry string) bool {
+	if _, exists := f.allowed[entry]; !exists {
+		return false
+	}
+
+	delete(f.allowed, entry)
+	return true
+}
+
+// Blocked returns the sorted entries in the blocklist
+func (f *StreamFilter) Blocked() []string {
+	return sortedEntries(f.blocked)
+}
+
+// Allowed returns the sorted entries in the allowlist
+func (f *StreamFilter) Allowed() []string {
+	return sortedEntries(f.allowed)
+}
+
+// Verify receives a stream resource location and returns
+// an error if the filter does not permit it to be played.
+func (f *StreamFilter) Verify(streamUrl string) error {
+	host, id := stream.ResourceIdentifiers(streamUrl)
+
+	for entry := range f.blocked {
+		if filterEntryMatches(entry, host, id) {
+			return fmt.Errorf("error: %q has been blocked in this room (matched %q)", streamUrl, entry)
+		}
+	}
+
+	if len(f.allowed) == 0 {
+		return nil
+	}
+
+	for entry := range f.allowed {
+		if filterEntryMatches(entry, host, id) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("error: %q does not match any of the domains or videos allowed in this room", streamUrl)
+}
+
+// filterEntryMatches returns true if the given entry is the
+// video id, or the domain (or a parent domain) of a stream
+func filterEntryMatches(entry, host, id string) bool {
+	if len(id) > 0 && entry == id {
+		return true
+	}
+	if len(host) == 0 {
+		return false
+	}
+
+	domain := strings.TrimPrefix(strings.ToLower(entry), "www.")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func sortedEntries(m map[string]bool) []string {
+	entries := make([]string, 0, len(m))
+	for entry := range m {
+		entries = append(entries, entry)
+	}
+
+	sort.Strings(entries)
+	return entries
+}
+
+func NewStreamFilter() *StreamFilter {
+	return &StreamFilter{
+		blocked: make(map[string]bool),
+		allowed: make(map[string]bool),
+	}
+}
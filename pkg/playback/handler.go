@@ -1,18 +1,32 @@
 package playback
 
 import (
+	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/webhook"
+)
+
+var (
+	ErrMaxPlaybacksExceeded = errors.New("the server has reached its maximum number of rooms")
 )
 
 type PlaybackHandler interface {
+	// AtCapacity returns a boolean (true) if the handler has reached
+	// its maximum amount of Playback objects and will refuse to
+	// create any more.
+	AtCapacity() bool
 	// NewPlayback receives a playback id and instantiates a new Playback
 	// object used to keep track of individual user-created stream sessions.
 	// A playback id should be a fully-qualified room name.
-	NewPlayback(connection.Namespace, rbac.Authorizer, client.SocketClientHandler) *Playback
+	// Returns an error if the handler is at capacity.
+	NewPlayback(connection.Namespace, rbac.Authorizer, client.SocketClientHandler) (*Playback, error)
 	// PlaybackByNamespace receives a connection.Namespace and retrieves a Playback object
 	// corresponding to that room. Returns a boolean (false) if a Playback object
 	// does not exist by the given roomName.
@@ -24,42 +38,164 @@ type PlaybackHandler interface {
 	// IsReapable receives a Playback and determines if it is reapable
 	// based on whether or not its corresponding Namespace has any items left
 	IsReapable(*Playback) bool
+	// SetMaxPlaybacks receives the maximum amount of Playback objects
+	// the handler is allowed to compose. A value <= 0 removes the limit.
+	SetMaxPlaybacks(int)
+	// SetIncrementalQueueSync receives a boolean determining whether queue
+	// changes in rooms created by the handler are broadcast as incremental
+	// events, rather than as a "queuesync" event containing the entire queue.
+	// Clients may still send a "request_queuesync" event to reconcile their state.
+	SetIncrementalQueueSync(bool)
+	// SetEmptyRoomGracePeriod receives the amount of time to keep a room
+	// created by the handler after its last client leaves before reaping it.
+	SetEmptyRoomGracePeriod(time.Duration)
+	// SetLifecycleNotifier receives a webhook.Notifier to notify when rooms
+	// created by the handler are created, play their first stream, and are
+	// reaped. A nil notifier disables lifecycle notifications.
+	SetLifecycleNotifier(webhook.Notifier)
+	// SetStreamLoadAcks receives a boolean determining whether clients sent
+	// a "streamload" event in rooms created by the handler are expected to
+	// acknowledge it, and the amount of time to wait for an acknowledgement
+	// before re-sending the event once.
+	SetStreamLoadAcks(bool, time.Duration)
+	// SetReorderBroadcastWindow receives the period of time after a queue
+	// re-order in rooms created by the handler over which further re-orders
+	// requested by the same user are coalesced into a single broadcast.
+	// A value <= 0 broadcasts every re-order as it happens.
+	SetReorderBroadcastWindow(time.Duration)
+	// SetMaxRoomQueueItems receives the maximum amount of streams that may
+	// be queued across every user's queue in a room created by the handler.
+	// A value <= 0 removes the limit.
+	SetMaxRoomQueueItems(int)
+	// SetRoomStreamRoot receives a room name and a directory, relative to the
+	// server's stream data root, that the room's local streams are scoped to
+	// once it is created (see Playback.SetStreamRoot).
+	// Returns an error if the directory does not exist.
+	SetRoomStreamRoot(string, string) error
+	// ReapAudit returns the record of the rooms most
+	// recently removed by the handler's garbage collector
+	ReapAudit() *ReapAudit
+	// SaveQueueSlot receives a room name and a QueueSlot and keeps the slot
+	// for the room until it is reaped, replacing any slot saved under the
+	// same name. Returns an error if the room has reached its maximum amount
+	// of saved slots.
+	SaveQueueSlot(string, *QueueSlot) error
+	// QueueSlot receives a room name and a slot name and returns the slot
+	// saved under that name for the room, or a boolean (false) if none exists.
+	QueueSlot(string, string) (*QueueSlot, bool)
+	// QueueSlotNames receives a room name and returns the
+	// sorted names of every slot saved for the room
+	QueueSlotNames(string) []string
+	// RestoreFromSnapshot receives a serialized PlaybackSnapshot and keeps
+	// it until its room is created again. Returns an error if the snapshot
+	// cannot be parsed.
+	RestoreFromSnapshot([]byte) error
+	// TakeSnapshot receives a room name and returns, and discards, a
+	// snapshot restored for that room, or a boolean (false) if none exists.
+	TakeSnapshot(string) (*PlaybackSnapshot, bool)
+	// LoadSnapshots restores every room snapshot in the given directory
+	LoadSnapshots(string) error
+	// SaveSnapshots writes a snapshot of every room to the given directory
+	SaveSnapshots(string) error
 }
 
 // Handler implements StreamPlaybackHandler
 type Handler struct {
 	isGarbageCollected bool
 	garbageCollector   *PlaybackReaper
+	audit              *ReapAudit
+	maxPlaybacks       int
+	// options copied to each Playback object the handler creates
+	incrementalQueueSync bool
+	emptyGracePeriod     time.Duration
+	lifecycleNotifier    webhook.Notifier
+	streamLoadAcks       bool
+	streamLoadAckTimeout time.Duration
+	reorderWindow        time.Duration
+	maxQueueItems        int
+	// map of room names to the directories their local streams are scoped to
+	streamRoots map[string]string
 	// map of stream ids to Playback objects
-	streamplaybacks  map[string]*Playback
+	streamplaybacks map[string]*Playback
+	// mux guards streamplaybacks and streamRoots
+	mux              sync.RWMutex
 	namespaceHandler connection.NamespaceHandler
+	// snapshots restored from disk awaiting their room
+	pending pendingSnapshots
+	// queue slots saved for each room
+	slots *queueSlotStore
+}
+
+func (h *Handler) AtCapacity() bool {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
+	return h.atCapacity()
 }
 
-func (h *Handler) NewPlayback(ns connection.Namespace, authorizer rbac.Authorizer, clientHandler client.SocketClientHandler) *Playback {
+func (h *Handler) atCapacity() bool {
+	return h.maxPlaybacks > 0 && len(h.streamplaybacks) >= h.maxPlaybacks
+}
+
+func (h *Handler) NewPlayback(ns connection.Namespace, authorizer rbac.Authorizer, clientHandler client.SocketClientHandler) (*Playback, error) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.atCapacity() {
+		log.Printf("WRN PLAYBACK refusing to create room %q: %v rooms exist (max %v)\n", ns.Name(), len(h.streamplaybacks), h.maxPlaybacks)
+		return nil, ErrMaxPlaybacksExceeded
+	}
+
 	var s *Playback
 	if authorizer == nil {
 		s = NewPlayback(ns)
 	} else {
 		s = NewPlaybackWithAdminPicker(ns, authorizer, clientHandler, h)
 	}
+	s.streamRoot = h.streamRoots[ns.Name()]
+	s.incrementalQueueSync = h.incrementalQueueSync
+	s.emptyGracePeriod = h.emptyGracePeriod
+	s.lifecycleNotifier = h.lifecycleNotifier
+	s.streamLoadAcks = h.streamLoadAcks
+	s.streamLoadAckTimeout = h.streamLoadAckTimeout
+	s.reorderWindow = h.reorderWindow
+	s.maxQueueItems = h.maxQueueItems
 
 	h.streamplaybacks[ns.Name()] = s
-	return s
+	atomic.AddInt64(&roomChurn.Created, 1)
+	s.notifyLifecycle(webhook.EVENT_ROOM_CREATED, nil)
+	return s, nil
 }
 
 func (h *Handler) ReapPlayback(p *Playback) bool {
-	if sp, exists := h.streamplaybacks[p.name]; exists {
-		sp.Cleanup()
+	h.mux.Lock()
+	sp, exists := h.streamplaybacks[p.name]
+	if exists {
 		delete(h.streamplaybacks, sp.name)
+	}
+	h.mux.Unlock()
+
+	if exists {
+		// read the current stream before Cleanup unsets it
+		currentStream, _ := sp.GetStream()
+		sp.Cleanup()
+		h.slots.Delete(sp.UUID())
 
 		// clean up composed namespace with name
 		// corresponding to the playback object's id
 		h.namespaceHandler.DeleteNamespaceByName(sp.UUID())
+		atomic.AddInt64(&roomChurn.Reaped, 1)
+
+		sp.notifyLifecycle(webhook.EVENT_ROOM_REAPED, currentStream)
 		return exists
 	}
 	return false
 }
 
+// IsReapable returns true if the Playback has no connected participants.
+// Rooms whose last client has left (see Playback.EmptySince) are reaped
+// after a shorter grace period than rooms that are merely idle.
+// The Playback is not modified, so callers may use this to report a room's status.
 func (h *Handler) IsReapable(p *Playback) bool {
 	ns, exists := h.namespaceHandler.NamespaceByName(p.UUID())
 	if !exists {
@@ -68,10 +204,14 @@ func (h *Handler) IsReapable(p *Playback) bool {
 		return true
 	}
 
-	return len(ns.Connections()) == 0
+	// observers alone do not keep a room from being reaped
+	return len(connection.Participants(ns.Connections())) == 0
 }
 
 func (h *Handler) PlaybackByNamespace(ns connection.Namespace) (*Playback, bool) {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
 	if sPlayback, exists := h.streamplaybacks[ns.Name()]; exists {
 		return sPlayback, true
 	}
@@ -80,6 +220,9 @@ func (h *Handler) PlaybackByNamespace(ns connection.Namespace) (*Playback, bool)
 }
 
 func (h *Handler) Playbacks() []*Playback {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+
 	playbacks := []*Playback{}
 	for _, p := range h.streamplaybacks {
 		playbacks = append(playbacks, p)
@@ -87,6 +230,52 @@ func (h *Handler) Playbacks() []*Playback {
 	return playbacks
 }
 
+func (h *Handler) ReapAudit() *ReapAudit {
+	return h.audit
+}
+
+func (h *Handler) SetMaxPlaybacks(max int) {
+	h.maxPlaybacks = max
+}
+
+func (h *Handler) SetIncrementalQueueSync(incremental bool) {
+	h.incrementalQueueSync = incremental
+}
+
+func (h *Handler) SetEmptyRoomGracePeriod(grace time.Duration) {
+	h.emptyGracePeriod = grace
+}
+
+func (h *Handler) SetLifecycleNotifier(notifier webhook.Notifier) {
+	h.lifecycleNotifier = notifier
+}
+
+func (h *Handler) SetStreamLoadAcks(enabled bool, timeout time.Duration) {
+	h.streamLoadAcks = enabled
+	h.streamLoadAckTimeout = timeout
+}
+
+func (h *Handler) SetReorderBroadcastWindow(window time.Duration) {
+	h.reorderWindow = window
+}
+
+func (h *Handler) SetMaxRoomQueueItems(max int) {
+	h.maxQueueItems = max
+}
+
+func (h *Handler) SetRoomStreamRoot(room, dir string) error {
+	root, err := resolveStreamRoot(dir)
+	if err != nil {
+		return err
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.streamRoots[room] = root
+	return nil
+}
+
 func (h *Handler) initGarbageCollector() {
 	// if handler is already being garbage collected, perform a no-op
 	if h.isGarbageCollected {
@@ -105,16 +294,35 @@ func (h *Handler) initGarbageCollector() {
 
 func NewHandler(nsHandler connection.NamespaceHandler) PlaybackHandler {
 	return &Handler{
-		namespaceHandler: nsHandler,
-		streamplaybacks:  make(map[string]*Playback),
+		namespaceHandler:     nsHandler,
+		audit:                &ReapAudit{},
+		emptyGracePeriod:     DEFAULT_EMPTY_ROOM_GRACE_PERIOD,
+		streamLoadAckTimeout: DEFAULT_STREAM_LOAD_ACK_TIMEOUT,
+		reorderWindow:        DEFAULT_REORDER_BROADCAST_WINDOW,
+		streamplaybacks:      make(map[string]*Playback),
+		streamRoots:          make(map[string]string),
+		pending: pendingSnapshots{
+			snapshots: make(map[string]*PlaybackSnapshot),
+		},
+		slots: newQueueSlotStore(MaxQueueSlotRooms),
 	}
 }
 
 func NewGarbageCollectedHandler(nsHandler connection.NamespaceHandler) PlaybackHandler {
+	audit := &ReapAudit{}
 	h := &Handler{
-		namespaceHandler: nsHandler,
-		garbageCollector: NewPlaybackReaper(),
-		streamplaybacks:  make(map[string]*Playback),
+		namespaceHandler:     nsHandler,
+		audit:                audit,
+		garbageCollector:     NewPlaybackReaper(audit),
+		emptyGracePeriod:     DEFAULT_EMPTY_ROOM_GRACE_PERIOD,
+		streamLoadAckTimeout: DEFAULT_STREAM_LOAD_ACK_TIMEOUT,
+		reorderWindow:        DEFAULT_REORDER_BROADCAST_WINDOW,
+		streamplaybacks:      make(map[string]*Playback),
+		streamRoots:          make(map[string]string),
+		pending: pendingSnapshots{
+			snapshots: make(map[string]*PlaybackSnapshot),
+		},
+		slots: newQueueSlotStore(MaxQueueSlotRooms),
 	}
 	h.initGarbageCollector()
 	return h
@@ -0,0 +1,120 @@
+package playback
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// MaxHistoryItems is the maximum amount of played
+// streams retained in a Playback's history
+var MaxHistoryItems = 50
+
+// HistoryEntry is a record of a stream that has been played in a room.
+// It retains enough information to recreate the stream once reaped.
+type HistoryEntry struct {
+	Url       string    `json:"url"`
+	Kind      string    `json:"kind"`
+	StartedBy string    `json:"startedBy"`
+	PlayedAt  time.Time `json:"playedAt"`
+
+	stream stream.Stream
+}
+
+// MarshalJSON serializes the entry along with
+// the name of its stream, given by Name
+func (e *HistoryEntry) MarshalJSON() ([]byte, error) {
+	type historyEntry HistoryEntry
+	return json.Marshal(struct {
+		*historyEntry
+		Name string `json:"name"`
+	}{
+		historyEntry: (*historyEntry)(e),
+		Name:         e.Name(),
+	})
+}
+
+// Name returns the name of the played stream, or
+// its url if the stream's metadata had no name
+func (e *HistoryEntry) Name() string {
+	if e.stream != nil && len(e.stream.GetName()) > 0 {
+		return e.stream.GetName()
+	}
+	return e.Url
+}
+
+// History returns the streams played in the room,
+// ordered from the most recently played stream.
+func (p *Playback) History() []*HistoryEntry {
+	p.historyMux.Lock()
+	defer p.historyMux.Unlock()
+
+	history := make([]*HistoryEntry, 0, len(p.history))
+	for i := len(p.history) - 1; i >= 0; i-- {
+		history = append(history, p.history[i])
+	}
+	return history
+}
+
+// HistoryEntry receives a history position, with the most recently
+// played stream at position 0, and returns the corresponding
+// HistoryEntry, or a boolean (false) if the position is out of range.
+func (p *Playback) HistoryEntry(idx int) (*HistoryEntry, bool) {
+	p.historyMux.Lock()
+	defer p.historyMux.Unlock()
+
+	if idx < 0 || idx >= len(p.history) {
+		return nil, false
+	}
+	return p.history[len(p.history)-1-idx], true
+}
+
+// RewindHistory is called once a stream from the room's history has
+// been played again. It receives the number of entries that were played
+// after the replayed stream, and removes them along with the stream's
+// previous entry from the room's history, so that the history steps back
+// to the replayed stream.
+func (p *Playback) RewindHistory(skipped int) {
+	p.historyMux.Lock()
+	defer p.historyMux.Unlock()
+
+	if skipped < 0 || len(p.history) < skipped+2 {
+		return
+	}
+
+	last := len(p.history) - 1
+	p.history = append(p.history[:last-1-skipped], p.history[last])
+}
+
+// recordHistory appends a newly played stream to the room's history,
+// dropping the oldest entries once MaxHistoryItems is exceeded.
+func (p *Playback) recordHistory(s stream.Stream) {
+	startedBy := p.StartedBy()
+
+	p.historyMux.Lock()
+	defer p.historyMux.Unlock()
+
+	p.history = append(p.history, &HistoryEntry{
+		Url:       s.GetStreamURL(),
+		Kind:      s.GetKind(),
+		StartedBy: startedBy,
+		PlayedAt:  time.Now(),
+
+		stream: s,
+	})
+
+	if len(p.history) > MaxHistoryItems {
+		p.history = p.history[len(p.history)-MaxHistoryItems:]
+	}
+}
+
+// ClearHistory removes every entry from the room's history.
+// Clearing the queue leaves the history intact, so that
+// streams can still be re-queued from it.
+func (p *Playback) ClearHistory() {
+	p.historyMux.Lock()
+	defer p.historyMux.Unlock()
+
+	p.history = nil
+}
@@ -0,0 +1,31 @@
+package playback
+
+import (
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/webhook"
+)
+
+// notifyLifecycle delivers a room lifecycle event to the room's lifecycle
+// notifier, if one is configured. The stream is optional and may be nil.
+func (p *Playback) notifyLifecycle(event string, s stream.Stream) {
+	if p.lifecycleNotifier == nil {
+		return
+	}
+
+	e := &webhook.Event{
+		Room:      p.name,
+		Event:     event,
+		Timestamp: time.Now(),
+	}
+	if s != nil {
+		e.Stream = &webhook.StreamEvent{
+			Url:  s.GetStreamURL(),
+			Name: s.GetName(),
+			Kind: s.GetKind(),
+		}
+	}
+
+	p.lifecycleNotifier.Notify(e)
+}
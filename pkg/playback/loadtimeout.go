@@ -0,0 +1,108 @@
+package playback
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// LoadTimeoutCallback is called with a stream no
+// client reported progress on within the room's load timeout
+type LoadTimeoutCallback func(stream.Stream)
+
+// loadTimeoutState holds a room's load timeout, and the
+// pending timer for the currently loaded stream, if any
+type loadTimeoutState struct {
+	mux      sync.Mutex
+	timeout  time.Duration
+	pending  *time.Timer
+	callback LoadTimeoutCallback
+}
+
+// SetLoadTimeout receives the amount of time clients have to report
+// progress on a newly loaded stream before it is skipped. A value
+// <= 0 disables the timeout. Only streams set after the timeout
+// is changed are affected.
+func (p *Playback) SetLoadTimeout(timeout time.Duration) {
+	p.loadTimeout.mux.Lock()
+	defer p.loadTimeout.mux.Unlock()
+
+	if timeout < 0 {
+		timeout = 0
+	}
+	p.loadTimeout.timeout = timeout
+}
+
+// LoadTimeout returns the amount of time clients have to report
+// progress on a newly loaded stream before it is skipped, or a
+// boolean (false) if the room has no load timeout.
+func (p *Playback) LoadTimeout() (time.Duration, bool) {
+	p.loadTimeout.mux.Lock()
+	defer p.loadTimeout.mux.Unlock()
+
+	return p.loadTimeout.timeout, p.loadTimeout.timeout > 0
+}
+
+// OnLoadTimeout sets the callback function called once a stream
+// has failed to start within the room's load timeout
+func (p *Playback) OnLoadTimeout(callback LoadTimeoutCallback) {
+	p.loadTimeout.mux.Lock()
+	defer p.loadTimeout.mux.Unlock()
+
+	p.loadTimeout.callback = callback
+}
+
+// ReportStreamProgress is called once a client has reported progress
+// on the current stream. It cancels the pending load timeout, if any.
+func (p *Playback) ReportStreamProgress() {
+	p.loadTimeout.mux.Lock()
+	defer p.loadTimeout.mux.Unlock()
+
+	p.stopLoadTimeout()
+}
+
+// startLoadTimeout cancels the load timeout pending for the previous
+// stream, if any, and starts one for the given stream if the room
+// has a load timeout.
+func (p *Playback) startLoadTimeout(s stream.Stream) {
+	p.loadTimeout.mux.Lock()
+	defer p.loadTimeout.mux.Unlock()
+
+	p.stopLoadTimeout()
+	if p.loadTimeout.timeout <= 0 || p.loadTimeout.callback == nil {
+		return
+	}
+
+	timeout := p.loadTimeout.timeout
+	callback := p.loadTimeout.callback
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		p.loadTimeout.mux.Lock()
+		if p.loadTimeout.pending != timer {
+			p.loadTimeout.mux.Unlock()
+			return
+		}
+		p.loadTimeout.pending = nil
+		p.loadTimeout.mux.Unlock()
+
+		if current, exists := p.GetStream(); !exists || current != s {
+			return
+		}
+
+		log.Printf("INF PLAYBACK no client reported progress on stream %q in room %q within %v\n", s.GetStreamURL(), p.UUID(), timeout)
+		callback(s)
+	})
+	p.loadTimeout.pending = timer
+}
+
+// stopLoadTimeout cancels the pending load timeout, if
+// any. Caller must hold the loadTimeout lock.
+func (p *Playback) stopLoadTimeout() {
+	if p.loadTimeout.pending != nil {
+		p.loadTimeout.pending.Stop()
+		p.loadTimeout.pending = nil
+	}
+}
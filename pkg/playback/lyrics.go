@@ -0,0 +1,101 @@
+package playback
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	lrcTimestampPattern = regexp.MustCompile(`^\[(\d+):(\d+(?:\.\d+)?)\]`)
+	lrcOffsetPattern    = regexp.MustCompile(`^\[offset:\s*([+-]?\d+)\]`)
+)
+
+// LyricsLine is a single line of time-synced lyrics
+type LyricsLine struct {
+	// Time is the playback time, in seconds, at which the line is sung
+	Time float64 `json:"time"`
+	Text string  `json:"text"`
+}
+
+// ParseLRC receives a reader for a timed-lyrics file in LRC format and
+// returns its lines ordered by time. Lines without a timestamp, including
+// metadata tags, are ignored. An [offset:] tag is applied to every line.
+func ParseLRC(r io.Reader) ([]LyricsLine, error) {
+	lines := []LyricsLine{}
+	offset := 0.0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := lrcOffsetPattern.FindStringSubmatch(line); m != nil {
+			ms, err := strconv.Atoi(m[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid lyrics offset %q: %v", m[1], err)
+			}
+			// a positive offset shifts lyrics to appear sooner
+			offset = -float64(ms) / 1000
+			continue
+		}
+
+		// a line may be prefixed by several timestamps
+		times := []float64{}
+		for {
+			m := lrcTimestampPattern.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+
+			min, _ := strconv.Atoi(m[1])
+			sec, _ := strconv.ParseFloat(m[2], 64)
+			times = append(times, float64(min*60)+sec)
+			line = line[len(m[0]):]
+		}
+
+		for _, t := range times {
+			lines = append(lines, LyricsLine{
+				Time: t,
+				Text: strings.TrimSpace(line),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		lines[i].Time += offset
+		if lines[i].Time < 0 {
+			lines[i].Time = 0
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time < lines[j].Time
+	})
+	return lines, nil
+}
+
+// SetLyrics receives the client-relative path of a timed-lyrics
+// file and its parsed lines, and stores them as the room's lyrics.
+func (p *Playback) SetLyrics(path string, lines []LyricsLine) {
+	p.lyricsPath = path
+	p.lyrics = lines
+}
+
+// ClearLyrics removes the room's currently loaded lyrics
+func (p *Playback) ClearLyrics() {
+	p.lyricsPath = ""
+	p.lyrics = nil
+}
+
+// Lyrics returns the client-relative path and parsed lines of the room's
+// currently loaded lyrics, or a boolean (false) if lyrics are off.
+func (p *Playback) Lyrics() (string, []LyricsLine, bool) {
+	return p.lyricsPath, p.lyrics, len(p.lyricsPath) > 0
+}
@@ -0,0 +1,157 @@
+package playback
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MirrorCallback is called for a mirroring room every time the
+// stream or timer of the room it mirrors changes. It receives
+// the room being mirrored.
+type MirrorCallback func(source *Playback)
+
+// mirrorState holds the room a room mirrors, if any,
+// and the rooms mirroring it, keyed by room name
+type mirrorState struct {
+	mux sync.Mutex
+
+	allowed   bool
+	source    *Playback
+	followers map[string]*mirrorFollower
+}
+
+// mirrorFollower is a room mirroring another room
+type mirrorFollower struct {
+	playback *Playback
+	callback MirrorCallback
+}
+
+// SetMirroringAllowed determines whether other rooms may mirror the room.
+// Rooms already mirroring the room stop doing so once it is disallowed.
+func (p *Playback) SetMirroringAllowed(allowed bool) {
+	p.mirror.mux.Lock()
+	p.mirror.allowed = allowed
+	followers := p.mirror.followers
+	if !allowed {
+		p.mirror.followers = nil
+	}
+	p.mirror.mux.Unlock()
+
+	if allowed {
+		return
+	}
+	for _, follower := range followers {
+		follower.playback.detachFrom(p)
+	}
+}
+
+// MirroringAllowed returns true if other rooms may mirror the room
+func (p *Playback) MirroringAllowed() bool {
+	p.mirror.mux.Lock()
+	defer p.mirror.mux.Unlock()
+
+	return p.mirror.allowed
+}
+
+// Mirror has the room follow the given source room: the callback is called
+// every time the source room's stream or timer changes. A room may only
+// mirror one room at a time, and the source room must allow mirroring.
+func (p *Playback) Mirror(source *Playback, callback MirrorCallback) error {
+	if source == p {
+		return fmt.Errorf("a room cannot mirror itself")
+	}
+	if !source.MirroringAllowed() {
+		return fmt.Errorf("room %q does not allow other rooms to mirror it", source.UUID())
+	}
+
+	// prevent cycles, which would have rooms mirror each other endlessly
+	for curr, exists := source.Mirroring(); exists; curr, exists = curr.Mirroring() {
+		if curr == p {
+			return fmt.Errorf("room %q is already mirroring this room", source.UUID())
+		}
+	}
+
+	p.StopMirroring()
+
+	source.mirror.mux.Lock()
+	if source.mirror.followers == nil {
+		source.mirror.followers = make(map[string]*mirrorFollower)
+	}
+	source.mirror.followers[p.UUID()] = &mirrorFollower{
+		playback: p,
+		callback: callback,
+	}
+	source.mirror.mux.Unlock()
+
+	p.mirror.mux.Lock()
+	p.mirror.source = source
+	p.mirror.mux.Unlock()
+	return nil
+}
+
+// StopMirroring stops the room from following the room it mirrors.
+// Returns the name of the room that was being mirrored, or a boolean
+// (false) if the room was not mirroring another room.
+func (p *Playback) StopMirroring() (string, bool) {
+	p.mirror.mux.Lock()
+	source := p.mirror.source
+	p.mirror.source = nil
+	p.mirror.mux.Unlock()
+
+	if source == nil {
+		return "", false
+	}
+
+	source.mirror.mux.Lock()
+	delete(source.mirror.followers, p.UUID())
+	source.mirror.mux.Unlock()
+	return source.UUID(), true
+}
+
+// Mirroring returns the room this room mirrors, or
+// a boolean (false) if it is not mirroring any room.
+func (p *Playback) Mirroring() (*Playback, bool) {
+	p.mirror.mux.Lock()
+	defer p.mirror.mux.Unlock()
+
+	return p.mirror.source, p.mirror.source != nil
+}
+
+// detachFrom clears the room's source if it is still the given room
+func (p *Playback) detachFrom(source *Playback) {
+	p.mirror.mux.Lock()
+	defer p.mirror.mux.Unlock()
+
+	if p.mirror.source == source {
+		p.mirror.source = nil
+	}
+}
+
+// notifyMirrors calls the callback of every room mirroring this room
+func (p *Playback) notifyMirrors() {
+	p.mirror.mux.Lock()
+	callbacks := make([]MirrorCallback, 0, len(p.mirror.followers))
+	for _, follower := range p.mirror.followers {
+		callbacks = append(callbacks, follower.callback)
+	}
+	p.mirror.mux.Unlock()
+
+	for _, callback := range callbacks {
+		callback(p)
+	}
+}
+
+// cleanupMirrors detaches the room from the room it mirrors,
+// and every room mirroring it
+func (p *Playback) cleanupMirrors() {
+	p.StopMirroring()
+
+	p.mirror.mux.Lock()
+	followers := p.mirror.followers
+	p.mirror.followers = nil
+	p.mirror.mux.Unlock()
+
+	for _, follower := range followers {
+		follower.playback.detachFrom(p)
+	}
+}
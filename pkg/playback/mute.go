@@ -0,0 +1,48 @@
+package playback
+
+import "sync"
+
+// mutedClients holds the connection ids of
+// clients whose chat messages are dropped
+type mutedClients struct {
+	mux sync.Mutex
+	ids map[string]bool
+}
+
+// Mute drops chat messages sent to the room by the given connection.
+// Returns false if the connection was already muted.
+func (p *Playback) Mute(connId string) bool {
+	p.muted.mux.Lock()
+	defer p.muted.mux.Unlock()
+
+	if p.muted.ids == nil {
+		p.muted.ids = make(map[string]bool)
+	}
+	if p.muted.ids[connId] {
+		return false
+	}
+	p.muted.ids[connId] = true
+	return true
+}
+
+// Unmute allows the given connection to chat in the room again.
+// Returns false if the connection was not muted.
+func (p *Playback) Unmute(connId string) bool {
+	p.muted.mux.Lock()
+	defer p.muted.mux.Unlock()
+
+	if !p.muted.ids[connId] {
+		return false
+	}
+	delete(p.muted.ids, connId)
+	return true
+}
+
+// IsMuted returns true if chat messages
+// from the given connection are dropped
+func (p *Playback) IsMuted(connId string) bool {
+	p.muted.mux.Lock()
+	defer p.muted.mux.Unlock()
+
+	return p.muted.ids[connId]
+}
@@ -0,0 +1,102 @@
+package playback
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/playback/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+const (
+	// ON_EMPTY_STOP stops the room's playback once its queue runs out
+	ON_EMPTY_STOP = "stop"
+	// ON_EMPTY_LOOP queues the streams most recently
+	// played in the room again once its queue runs out
+	ON_EMPTY_LOOP = "loop"
+	// ON_EMPTY_HOME stops the room's playback once its queue runs
+	// out, and has clients show an idle screen until a stream is loaded
+	ON_EMPTY_HOME = "home"
+)
+
+// SetOnEmpty receives ON_EMPTY_STOP, ON_EMPTY_LOOP, or ON_EMPTY_HOME and sets
+// it as what the room does once its last queued stream has finished playing.
+func (p *Playback) SetOnEmpty(behavior string) error {
+	switch behavior {
+	case ON_EMPTY_STOP, ON_EMPTY_LOOP, ON_EMPTY_HOME:
+		p.statusMux.Lock()
+		p.onEmpty = behavior
+		p.statusMux.Unlock()
+		return nil
+	}
+	return fmt.Errorf("unsupported end-of-queue behavior %q, expecting %q, %q, or %q", behavior, ON_EMPTY_STOP, ON_EMPTY_LOOP, ON_EMPTY_HOME)
+}
+
+// OnEmpty returns what the room does once its
+// last queued stream has finished playing
+func (p *Playback) OnEmpty() string {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	if len(p.onEmpty) == 0 {
+		return ON_EMPTY_STOP
+	}
+	return p.onEmpty
+}
+
+// LoopHistory queues the streams most recently played in the room again,
+// in the order they were played, under the given user's queue. Streams
+// played more than once are queued a single time, and no more streams
+// are queued than a user queue can hold.
+// Returns the amount of streams queued.
+func (p *Playback) LoopHistory(user *client.Client, streamHandler stream.StreamHandler) (int, error) {
+	seen := make(map[string]bool)
+	urls := []string{}
+	for i := len(p.history) - 1; i >= 0 && len(urls) < queue.MaxAggregatableQueueItems; i-- {
+		url := p.history[i].Url
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	if len(urls) == 0 {
+		return 0, nil
+	}
+
+	userQueue, exists, err := util.GetUserQueue(user, p.GetQueue())
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		if err := p.GetQueue().Push(userQueue); err != nil {
+			return 0, err
+		}
+	}
+
+	looped := 0
+	for i := len(urls) - 1; i >= 0; i-- {
+		if p.UserQueueFull(userQueue) {
+			break
+		}
+
+		s, err := p.GetOrCreateStreamFromUrl(urls[i], user, streamHandler, func(data []byte, created bool, err error) {})
+		if err != nil {
+			log.Printf("INF PLAYBACK skipping stream %q while looping room %q: %v", urls[i], p.name, err)
+			continue
+		}
+		if _, err := p.PushToQueue(userQueue, s); err != nil {
+			log.Printf("INF PLAYBACK skipping stream %q while looping room %q: %v", urls[i], p.name, err)
+			continue
+		}
+		looped++
+	}
+
+	if userQueue.Size() == 0 {
+		p.GetQueue().DeleteItem(userQueue)
+	}
+	return looped, nil
+}
@@ -2,8 +2,10 @@ package playback
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	api "github.com/juanvallejo/streaming-server/pkg/api/types"
@@ -13,6 +15,7 @@ import (
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/webhook"
 )
 
 const (
@@ -34,12 +37,49 @@ const (
 	PLAYBACK_STATE_ENDED
 )
 
+const (
+	// A playback mode of "embed" indicates that clients should
+	// play streams through a provider's embedded player.
+	PLAYBACK_MODE_EMBED = "embed"
+
+	// A playback mode of "direct" indicates that clients should
+	// play streams through a resolved, directly playable url.
+	PLAYBACK_MODE_DIRECT = "direct"
+)
+
 // PlaybackStreamMetadataCallback is a callback function called once metadata for a stream has been fetched
 type PlaybackStreamMetadataCallback func(data []byte, created bool, err error)
 
 // PlaybackState represents the current state of the room's playback
 type PlaybackState int
 
+// StateChangeCallback is a callback function called with the previous
+// and current state of a room's playback whenever the state changes
+type StateChangeCallback func(from, to PlaybackState)
+
+// StreamLoadCallback is a callback function called with a room's newly
+// loaded stream before any other change to the room's current stream can
+// happen, so that announcements of consecutive streams are sent in the
+// order the streams were loaded.
+type StreamLoadCallback func(stream.Stream)
+
+var (
+	ErrStreamChanged = errors.New("the current stream has already changed")
+	// ErrRoomQueueFull is returned when pushing to a user's queue
+	// while the room's queue holds its maximum amount of streams
+	ErrRoomQueueFull = errors.New("this room's queue is full.")
+)
+
+// DEFAULT_REORDER_BROADCAST_WINDOW is the period of time after a queue
+// re-order over which further re-orders requested by the same user are
+// coalesced, unless set otherwise through Handler.SetReorderBroadcastWindow.
+const DEFAULT_REORDER_BROADCAST_WINDOW = 250 * time.Millisecond
+
+// SeekAheadTolerance is the amount of seconds a client may report being
+// ahead of the room's timer, to account for buffering and latency, before
+// it is snapped back in rooms that do not allow seeking ahead.
+const SeekAheadTolerance = 3
+
 // Playback represents playback status for a given
 // stream - there are one or more StreamPlayback instances
 // for every one stream
@@ -50,46 +90,166 @@ type Playback struct {
 	stream             stream.Stream
 	startedBy          string
 	timer              *Timer
+	createdAt          time.Time
 	lastUpdated        time.Time
 	lastAdminDeparture time.Time
+	emptiedAt          time.Time
+	hasPlayed          bool
+	advanceNeedsAdmin  bool
+	autoloadDisabled   bool
+	privateQueues      bool
+	noSeekAhead        bool
+	onEmpty            string
+	maxPlayTime        int
+	userQueueLimit     int
+	streamRoot         string
+	subtitlesPath      string
+	lyricsPath         string
+	lyrics             []LyricsLine
+	mode               string
+	directUrl          string
+	directErr          error
+	defaultVolume      int
+	currentVolume      int
+	welcomeMessage     string
+	lenientQueue       bool
+	filter             *StreamFilter
+	history            []*HistoryEntry
+	historyMux         sync.Mutex
+	preparedStream     stream.Stream
+	creatorToken       string
+	stats              roomStats
+	queueRuntime       queueRuntimeState
+	mirror             mirrorState
+	muted              mutedClients
+	commands           commandLog
+	loadTimeout        loadTimeoutState
+	restored           restoredQueues
+
+	// options set by the Handler that created the Playback,
+	// which do not change once the Playback is created
+	incrementalQueueSync bool
+	emptyGracePeriod     time.Duration
+	lifecycleNotifier    webhook.Notifier
+	streamLoadAcks       bool
+	streamLoadAckTimeout time.Duration
+	reorderWindow        time.Duration
+	maxQueueItems        int
+
+	// streamMux serializes changes to the current stream
+	streamMux sync.Mutex
+	// reaped is set once the room has been cleaned up; guarded by streamMux
+	reaped bool
+
+	// statusMux guards the fields that are changed concurrently:
+	// the current stream, the user that started it, its direct
+	// url, the playback state, the room settings set through
+	// commands, the state change callbacks, and the times the
+	// room was last updated and emptied
+	statusMux sync.RWMutex
+
+	// pendingAcks holds, for every connection id, a timer
+	// for each stream url awaiting a "streamloaded" ack
+	pendingAcks map[string]map[string]*time.Timer
+	ackMux      sync.Mutex
+
+	// queueRequests holds streams awaiting admin
+	// approval while the room is moderated
+	moderated         bool
+	queueRequests     []*QueueRequest
+	queueRequestCount int
+	requestMux        sync.Mutex
 
 	// State indicates the current state of the
 	// room's Playback
-	state PlaybackState
+	state          PlaybackState
+	stateCallbacks []StateChangeCallback
 }
 
 // Cleanup handles resource cleanup for room resources
 func (p *Playback) Cleanup() {
 	// remove room ref from the current stream
-	if p.stream != nil {
-		p.stream.Metadata().RemoveParentRef(p)
-		p.stream.Metadata().RemoveLabelledRef(p.UUID())
+	if s, exists := p.GetStream(); exists {
+		s.Metadata().RemoveParentRef(p)
+		s.Metadata().RemoveLabelledRef(p.UUID())
 	}
 
+	p.ClearPreparedStream()
+	p.cleanupMirrors()
+
+	p.loadTimeout.mux.Lock()
+	p.stopLoadTimeout()
+	p.loadTimeout.callback = nil
+	p.loadTimeout.mux.Unlock()
+
 	if p.adminPicker != nil {
 		p.adminPicker.Stop()
 	}
 
 	p.timer.Stop()
-	p.timer.callbacks = []TimerCallback{}
-	p.timer = nil
+	p.timer.clearCallbacks()
+
+	p.statusMux.Lock()
+	p.stateCallbacks = nil
+	p.statusMux.Unlock()
+
+	p.ackMux.Lock()
+	for _, timers := range p.pendingAcks {
+		for _, timer := range timers {
+			timer.Stop()
+		}
+	}
+	p.pendingAcks = nil
+	p.ackMux.Unlock()
+
+	// wait for any snapshot of the room in progress. The stopped timer
+	// is kept, so that status reads racing the reap can still use it.
+	p.streamMux.Lock()
+	p.reaped = true
+	p.streamMux.Unlock()
 	p.ClearQueue()
+
+	p.statusMux.Lock()
 	p.stream = nil
+	p.statusMux.Unlock()
 }
 
 func (p *Playback) UUID() string {
 	return p.name
 }
 
+// SetState sets the current stream-playback state, calling
+// every StateChangeCallback if the state has changed
 func (p *Playback) SetState(s PlaybackState) {
+	p.statusMux.Lock()
+	prev := p.state
 	p.state = s
+	callbacks := p.stateCallbacks
+	p.statusMux.Unlock()
+
+	if prev == s {
+		return
+	}
+
+	for _, callback := range callbacks {
+		callback(prev, s)
+	}
 }
 
 // State returns the current stream-playback state
 func (p *Playback) State() PlaybackState {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
 	return p.state
 }
 
+// TimerState returns the state of the Playback's timer
+// (TIMER_PLAY, TIMER_PAUSE, or TIMER_STOP)
+func (p *Playback) TimerState() int {
+	return p.timer.State()
+}
+
 // HandleAdminDeparture receives a departing connection and determines if at least
 // one other connection in its namespace is bound to the admin role. If no other
 // admins are found, the adminHandler is notified.
@@ -134,9 +294,41 @@ func (p *Playback) HandleDisconnection(conn connection.Connection, authorizer rb
 // UpdateStartedBy receives a client and updates the
 // startedBy field with the client's current username
 func (p *Playback) UpdateStartedBy(name string) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
 	p.startedBy = name
 }
 
+// StartedBy returns the name of the user that started
+// the current stream, or an empty string if unknown
+func (p *Playback) StartedBy() string {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.startedBy
+}
+
+// ReassignCurrentStream receives the id of a user whose queue has been
+// handed to the given client. If the current stream was queued by that
+// user, its labelled reference and startedBy field are updated to point
+// to the new owner. Returns a bool (true) if the current stream was updated.
+func (p *Playback) ReassignCurrentStream(fromId string, owner *client.Client) bool {
+	s, exists := p.GetStream()
+	if !exists {
+		return false
+	}
+
+	ref, exists := s.Metadata().GetLabelledRef(p.UUID())
+	if !exists || ref.UUID() != fromId {
+		return false
+	}
+
+	s.Metadata().SetLabelledRef(p.UUID(), owner)
+	p.UpdateStartedBy(owner.GetUsernameOrId())
+	return true
+}
+
 // RefreshInfoFromClient receives a client and updates altered
 // client details used as part of playback info.
 // Returns a bool (true) if the client received contains
@@ -148,6 +340,9 @@ func (p *Playback) RefreshInfoFromClient(c *client.Client) bool {
 		return false
 	}
 
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
 	if len(p.startedBy) > 0 && p.startedBy == cOldUser {
 		cUser, hasUser := c.GetUsername()
 		if !hasUser {
@@ -164,21 +359,43 @@ func (p *Playback) RefreshInfoFromClient(c *client.Client) bool {
 
 func (p *Playback) Pause() error {
 	p.SetLastUpdated(time.Now())
+	defer p.notifyMirrors()
 	return p.timer.Pause()
 }
 
 func (p *Playback) Play() error {
 	p.SetState(PLAYBACK_STATE_STARTED)
 	p.SetLastUpdated(time.Now())
+	defer p.notifyMirrors()
 	return p.timer.Play()
 }
 
 func (p *Playback) Stop() error {
 	p.SetState(PLAYBACK_STATE_ENDED)
 	p.SetLastUpdated(time.Now())
+	defer p.notifyMirrors()
 	return p.timer.Stop()
 }
 
+// Hold stops the room's timer from advancing, suppressing
+// its tick callbacks, without changing the playback state
+// reported to clients.
+func (p *Playback) Hold() {
+	p.SetLastUpdated(time.Now())
+	p.timer.Hold()
+}
+
+// Resume allows a held timer to continue advancing
+func (p *Playback) Resume() {
+	p.SetLastUpdated(time.Now())
+	p.timer.Release()
+}
+
+// IsHeld returns true if the room's timer is being held
+func (p *Playback) IsHeld() bool {
+	return p.timer.IsHeld()
+}
+
 func (p *Playback) Reset() error {
 	p.SetLastUpdated(time.Now())
 	return p.timer.Set(0)
@@ -186,8 +403,8 @@ func (p *Playback) Reset() error {
 
 func (p *Playback) SetTime(newTime int) error {
 	p.SetLastUpdated(time.Now())
-	p.timer.Set(newTime)
-	return nil
+	defer p.notifyMirrors()
+	return p.timer.Set(newTime)
 }
 
 func (p *Playback) GetTime() int {
@@ -198,14 +415,246 @@ func (p *Playback) LastAdminDepartureTime() time.Time {
 	return p.lastAdminDeparture
 }
 
+// MarkEmpty records the time at which the room's last client left.
+// Empty rooms are reaped after a shorter grace period than idle ones.
+func (p *Playback) MarkEmpty(t time.Time) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.emptiedAt = t
+}
+
+// MarkPopulated clears the room's empty state once a client joins it
+func (p *Playback) MarkPopulated() {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.emptiedAt = time.Time{}
+}
+
+// EmptySince returns the time at which the room's last client left,
+// or a boolean (false) if the room has not been emptied by a departure.
+func (p *Playback) EmptySince() (time.Time, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.emptiedAt, !p.emptiedAt.IsZero()
+}
+
+// CreatedAt returns the time the room was created
+func (p *Playback) CreatedAt() time.Time {
+	return p.createdAt
+}
+
 func (p *Playback) GetLastUpdated() time.Time {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
 	return p.lastUpdated
 }
 
 func (p *Playback) SetLastUpdated(t time.Time) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
 	p.lastUpdated = t
 }
 
+// SetMode receives a playback mode and sets it as the room's preferred
+// mode of playback. If the "direct" mode is requested, a direct url is
+// resolved for the current stream (if any).
+// Returns an error if the mode is not supported, or if a direct url could
+// not be resolved for the current stream - in which case the room's stream
+// falls back to the "embed" mode until a new stream is loaded.
+func (p *Playback) SetMode(mode string) error {
+	if mode != PLAYBACK_MODE_EMBED && mode != PLAYBACK_MODE_DIRECT {
+		return fmt.Errorf("error: unsupported playback mode %q", mode)
+	}
+
+	p.statusMux.Lock()
+	p.mode = mode
+	p.statusMux.Unlock()
+
+	return p.resolveDirectUrl()
+}
+
+// Mode returns the room's preferred mode of playback
+func (p *Playback) Mode() string {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.mode
+}
+
+// resolveDirectUrl calculates a directly playable url for the current
+// stream if the room's preferred mode of playback is "direct".
+func (p *Playback) resolveDirectUrl() error {
+	p.setDirectUrl("", nil)
+
+	current, exists := p.GetStream()
+	if p.Mode() != PLAYBACK_MODE_DIRECT || !exists {
+		return nil
+	}
+
+	s, ok := current.(stream.DirectStream)
+	if !ok {
+		err := fmt.Errorf("direct playback is not supported for %s streams", current.GetKind())
+		p.setDirectUrl("", err)
+		return err
+	}
+
+	directUrl, err := s.DirectURL()
+	p.setDirectUrl(directUrl, err)
+	return err
+}
+
+// setDirectUrl sets the directly playable url of the current
+// stream, along with the error encountered resolving it, if any
+func (p *Playback) setDirectUrl(directUrl string, err error) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.directUrl = directUrl
+	p.directErr = err
+}
+
+// DirectUrlError returns the reason the current stream could not be
+// played directly in a room preferring "direct" playback, or nil if
+// it is being played directly, or the room prefers "embed" playback.
+func (p *Playback) DirectUrlError() error {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.directErr
+}
+
+// SetSubtitles receives a client-relative subtitles path
+// and stores it as the room's currently loaded subtitles track.
+func (p *Playback) SetSubtitles(path string) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.subtitlesPath = path
+}
+
+// ClearSubtitles removes the room's currently loaded subtitles track
+func (p *Playback) ClearSubtitles() {
+	p.SetSubtitles("")
+}
+
+// Subtitles returns the client-relative path of the room's currently
+// loaded subtitles track, or a boolean (false) if subtitles are off.
+func (p *Playback) Subtitles() (string, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.subtitlesPath, len(p.subtitlesPath) > 0
+}
+
+// SetDefaultVolume receives a volume value that clients
+// joining the room adopt. A negative value clears the default.
+func (p *Playback) SetDefaultVolume(vol int) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.defaultVolume = vol
+}
+
+// DefaultVolume returns the room's default volume, or a
+// boolean (false) if no default volume has been set.
+func (p *Playback) DefaultVolume() (int, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.defaultVolume, p.defaultVolume >= 0
+}
+
+// SetVolume records the volume most recently set for the room's
+// player. Values are clamped between 0 and 100.
+func (p *Playback) SetVolume(vol int) {
+	if vol < 0 {
+		vol = 0
+	}
+	if vol > 100 {
+		vol = 100
+	}
+
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.currentVolume = vol
+}
+
+// Volume returns the volume most recently set for the room's player
+func (p *Playback) Volume() int {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.currentVolume
+}
+
+// SetWelcomeMessage receives a message sent privately to each
+// client joining the room. An empty message clears it.
+func (p *Playback) SetWelcomeMessage(msg string) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.welcomeMessage = msg
+}
+
+// WelcomeMessage returns the room's welcome message, or a
+// boolean (false) if no welcome message has been set.
+func (p *Playback) WelcomeMessage() (string, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.welcomeMessage, len(p.welcomeMessage) > 0
+}
+
+// SetStrictQueue receives a boolean determining whether adding a
+// stream that a user has already queued is treated as an error.
+func (p *Playback) SetStrictQueue(strict bool) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.lenientQueue = !strict
+}
+
+// StrictQueue returns true if adding a stream that a user has
+// already queued is treated as an error. Rooms are strict by default.
+func (p *Playback) StrictQueue() bool {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return !p.lenientQueue
+}
+
+// SetQueueMode receives queue.ROUND_ROBIN_MODE or queue.SHARED_MODE and
+// sets it as the order in which the room's queued streams are played.
+func (p *Playback) SetQueueMode(mode string) error {
+	return p.GetQueue().SetMode(mode)
+}
+
+// QueueMode returns the order in which the room's queued streams are played
+func (p *Playback) QueueMode() string {
+	return p.GetQueue().Mode()
+}
+
+// Filter returns the StreamFilter restricting
+// the streams that may be played in the room
+func (p *Playback) Filter() *StreamFilter {
+	return p.filter
+}
+
+// OnStateChange adds a callback function called
+// every time the room's playback state changes
+func (p *Playback) OnStateChange(callback StateChangeCallback) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.stateCallbacks = append(p.stateCallbacks, callback)
+}
+
 // OnTick calls the playback object's timer object and sets its
 // "tick" callback function; called every tick increment interval.
 func (p *Playback) OnTick(callback TimerCallback) {
@@ -230,12 +679,13 @@ func (p *Playback) ClearQueue() error {
 
 	p.queueHandler.Clear()
 
-	var errMsg string
-	if len(errs) > 0 {
-		errMsg = "INF SOCKET CLIENT the following errors occurred while attempting to clear the queue:"
-		for _, e := range errs {
-			errMsg += "\n    " + e.Error()
-		}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	errMsg := "the following errors occurred while attempting to clear the queue:"
+	for _, e := range errs {
+		errMsg += "\n    " + e.Error()
 	}
 	return fmt.Errorf("%v", errMsg)
 }
@@ -252,16 +702,47 @@ func (p *Playback) GetQueue() queue.RoundRobinQueue {
 	return p.queueHandler.Queue().(queue.RoundRobinQueue)
 }
 
-// PushUserQueue pushes a stream to the queue belonging to the given user
-// and adds the Playback object as the parentRef to the pushed stream.
-func (p *Playback) PushToQueue(userQueue queue.AggregatableQueue, s stream.Stream) error {
-	if err := p.queueHandler.PushToQueue(userQueue, s); err == nil {
-		// mark stream as unreapable while it is aggregated in the queue
-		if !s.Metadata().AddParentRef(p) {
-			log.Printf("INF SOCKET CLIENT duplicate attempt to set parent ref %q to stream %q\n", p.UUID(), s.UUID())
+// RoomQueueSize returns the amount of streams
+// queued in the room across every user's queue
+func (p *Playback) RoomQueueSize() int {
+	size := 0
+	for _, item := range p.GetQueue().List() {
+		if userQueue, ok := item.(queue.AggregatableQueue); ok {
+			size += userQueue.Size()
 		}
 	}
-	return nil
+	return size
+}
+
+// MaxQueueItems returns the maximum amount of streams that may be queued
+// in the room across every user's queue. A value <= 0 means no limit.
+func (p *Playback) MaxQueueItems() int {
+	return p.maxQueueItems
+}
+
+// PushToQueue pushes a stream to the queue belonging to the given user
+// and adds the Playback object as the parentRef to the pushed stream.
+// The stream is pushed as a QueuedStream, which is returned.
+func (p *Playback) PushToQueue(userQueue queue.AggregatableQueue, s stream.Stream) (*QueuedStream, error) {
+	if p.maxQueueItems > 0 && p.RoomQueueSize() >= p.maxQueueItems {
+		return nil, ErrRoomQueueFull
+	}
+
+	queued, err := NewQueuedStream(unwrapStream(s))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.queueHandler.PushToQueue(userQueue, queued); err != nil {
+		return nil, err
+	}
+	p.countContribution(s)
+
+	// mark stream as unreapable while it is aggregated in the queue
+	if !s.Metadata().AddParentRef(p) {
+		log.Printf("INF SOCKET CLIENT duplicate attempt to set parent ref %q to stream %q\n", p.UUID(), s.UUID())
+	}
+	return queued, nil
 }
 
 // PopUserQueue pops a stream from the queue belonging to the given user
@@ -285,42 +766,321 @@ func (p *Playback) ClearQueueItem(userQueue queue.AggregatableQueue, qi queue.Qu
 	return nil
 }
 
+// SetAdvanceNeedsAdmin receives a boolean indicating whether the room
+// should only auto-advance its queue while at least one admin is present.
+func (p *Playback) SetAdvanceNeedsAdmin(needsAdmin bool) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.advanceNeedsAdmin = needsAdmin
+}
+
+// AdvanceNeedsAdmin returns true if the room suspends auto-advancing
+// its queue at the end of a stream while no admin is present.
+func (p *Playback) AdvanceNeedsAdmin() bool {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.advanceNeedsAdmin
+}
+
+// SetAutoload receives a boolean indicating whether clients
+// joining the room are sent the currently loaded stream.
+func (p *Playback) SetAutoload(autoload bool) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.autoloadDisabled = !autoload
+}
+
+// Autoload returns true if clients joining the room are sent the
+// currently loaded stream. Rooms autoload their stream by default.
+func (p *Playback) Autoload() bool {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return !p.autoloadDisabled
+}
+
+// SetPrivateQueues receives a boolean indicating whether a user's
+// queue may only be listed by that user and by the room's admins.
+func (p *Playback) SetPrivateQueues(private bool) {
+	p.privateQueues = private
+}
+
+// PrivateQueues returns true if a user's queue may only
+// be listed by that user and by the room's admins.
+func (p *Playback) PrivateQueues() bool {
+	return p.privateQueues
+}
+
+// SetMaxPlayTime receives an amount of seconds after which any stream
+// is skipped, regardless of its duration. A value <= 0 removes the cap.
+func (p *Playback) SetMaxPlayTime(seconds int) {
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.maxPlayTime = seconds
+}
+
+// MaxPlayTime returns the amount of seconds after which any stream
+// is skipped, or a boolean (false) if the room has no cap.
+func (p *Playback) MaxPlayTime() (int, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.maxPlayTime, p.maxPlayTime > 0
+}
+
+// SetNoSeekAhead receives a boolean determining whether clients
+// reporting a position ahead of the room's timer are snapped back
+func (p *Playback) SetNoSeekAhead(noSeekAhead bool) {
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.noSeekAhead = noSeekAhead
+}
+
+// IncrementalQueueSync returns true if queue changes in the room are broadcast
+// as incremental "queueitemadded", "queueitemremoved", and "queuereordered"
+// events, rather than as a "queuesync" event containing the entire queue.
+func (p *Playback) IncrementalQueueSync() bool {
+	return p.incrementalQueueSync
+}
+
+// ReorderBroadcastWindow returns the period of time after a queue re-order
+// in the room over which further re-orders requested by the same user are
+// coalesced into a single re-order and broadcast of the resulting order.
+// A value <= 0 applies and broadcasts every re-order as it happens.
+func (p *Playback) ReorderBroadcastWindow() time.Duration {
+	return p.reorderWindow
+}
+
+// NoSeekAhead returns true if clients reporting a position
+// ahead of the room's timer are snapped back to it
+func (p *Playback) NoSeekAhead() bool {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.noSeekAhead
+}
+
+// SeekedAhead receives a client's reported position in seconds and returns
+// true if the room does not allow seeking ahead, and the position is ahead
+// of the room's timer by more than SeekAheadTolerance.
+func (p *Playback) SeekedAhead(position float64) bool {
+	return p.NoSeekAhead() && position > float64(p.GetTime()+SeekAheadTolerance)
+}
+
+// SetUserQueueLimit receives the maximum amount of items any single user
+// may have in their queue. The limit may not exceed the storage limit of
+// a user queue. A value <= 0 removes the limit.
+func (p *Playback) SetUserQueueLimit(limit int) error {
+	if limit > queue.MaxAggregatableQueueItems {
+		return fmt.Errorf("error: the per-user queue limit cannot exceed the maximum of %v items", queue.MaxAggregatableQueueItems)
+	}
+	if limit < 0 {
+		limit = 0
+	}
+
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.userQueueLimit = limit
+	return nil
+}
+
+// UserQueueLimit returns the maximum amount of items any single user
+// may have in their queue, or a boolean (false) if no limit is set.
+func (p *Playback) UserQueueLimit() (int, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.userQueueLimit, p.userQueueLimit > 0
+}
+
+// UserQueueFull returns true if the given user queue has reached
+// the room's per-user limit, or its storage limit.
+func (p *Playback) UserQueueFull(userQueue queue.AggregatableQueue) bool {
+	if limit, exists := p.UserQueueLimit(); exists && userQueue.Size() >= limit {
+		return true
+	}
+	return userQueue.Size() >= queue.MaxAggregatableQueueItems
+}
+
 // GetStream returns a stream.Stream object containing current stream data
 // tied to the current Playback object, or a bool (false) if there
 // is no stream information currently loaded for the current Playback
 func (p *Playback) GetStream() (stream.Stream, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
 	return p.stream, p.stream != nil
 }
 
 // SetStream receives a stream.Stream and sets it as the currently-playing stream
 func (p *Playback) SetStream(s stream.Stream) {
-	if p.stream != nil {
+	if prev, exists := p.GetStream(); exists {
 		// remove Playback object from list of current stream's refs
-		p.stream.Metadata().RemoveParentRef(p)
-		p.stream.Metadata().RemoveLabelledRef(p.UUID())
+		prev.Metadata().RemoveParentRef(p)
+		prev.Metadata().RemoveLabelledRef(p.UUID())
 	}
 
+	startedBy := p.StartedBy()
 	startedByUser, exists := s.Metadata().GetLabelledRef(p.UUID())
 	if exists {
 		u, ok := startedByUser.(*client.Client)
 		if ok {
-			p.UpdateStartedBy(u.GetUsernameOrId())
+			startedBy = u.GetUsernameOrId()
 		}
 	} else {
 		log.Printf("INF PLAYBACK unable to find labelled client reference for room with id %v\n", p.UUID())
-		p.UpdateStartedBy("<unknown>")
+		startedBy = "<unknown>"
 	}
 
+	// the stream and the user that started it are
+	// swapped together, so that they are never torn
+	p.statusMux.Lock()
 	p.stream = s
-	p.stream.Metadata().SetLastUpdated(time.Now())
+	p.startedBy = startedBy
+	p.statusMux.Unlock()
+
+	s.Metadata().SetLastUpdated(time.Now())
+	p.SetLastUpdated(time.Now())
+	p.recordHistory(s)
+	p.startLoadTimeout(s)
+
+	if !p.hasPlayed {
+		p.hasPlayed = true
+		p.notifyLifecycle(webhook.EVENT_ROOM_FIRST_PLAYBACK, s)
+	}
+
+	if err := p.resolveDirectUrl(); err != nil {
+		log.Printf("WRN PLAYBACK unable to resolve direct url for stream %q in room %q; falling back to %q mode: %v\n", s.UUID(), p.UUID(), PLAYBACK_MODE_EMBED, err)
+	}
+
+	p.notifyMirrors()
+}
+
+// UnsetStream removes the currently-playing stream, if any,
+// leaving the room with no stream loaded. Calls are serialized
+// with any other calls that change the current stream.
+func (p *Playback) UnsetStream() {
+	p.streamMux.Lock()
+	defer p.streamMux.Unlock()
+
+	p.ClearPreparedStream()
+
+	p.loadTimeout.mux.Lock()
+	p.stopLoadTimeout()
+	p.loadTimeout.mux.Unlock()
+
+	p.statusMux.Lock()
+	prev := p.stream
+	p.stream = nil
+	p.startedBy = ""
+	p.directUrl = ""
+	p.directErr = nil
+	p.statusMux.Unlock()
+
+	if prev != nil {
+		// remove Playback object from list of current stream's refs
+		prev.Metadata().RemoveParentRef(p)
+		prev.Metadata().RemoveLabelledRef(p.UUID())
+	}
+
 	p.SetLastUpdated(time.Now())
 }
 
+// LoadStream receives a stream.Stream, sets it as the currently-playing
+// stream, and resets the playback timer. Calls are serialized with any
+// other calls that change the current stream. If onLoad is not nil, it
+// is called with the loaded stream before the next call is handled.
+func (p *Playback) LoadStream(s stream.Stream, onLoad StreamLoadCallback) {
+	p.streamMux.Lock()
+	defer p.streamMux.Unlock()
+
+	p.SetStream(s)
+	p.Reset()
+
+	if onLoad != nil {
+		onLoad(s)
+	}
+}
+
+// AdvanceQueue pops the next stream from the queue, sets it as the
+// currently-playing stream, and resets the playback timer. Concurrent
+// calls are serialized so that each call advances exactly one item.
+// If onLoad is not nil, it is called with the loaded stream before the
+// next call is handled.
+func (p *Playback) AdvanceQueue(onLoad StreamLoadCallback) (stream.Stream, error) {
+	p.streamMux.Lock()
+	defer p.streamMux.Unlock()
+
+	return p.advanceQueue(onLoad)
+}
+
+// AdvanceQueueFrom behaves like AdvanceQueue, but only advances the queue
+// if the given stream is still the currently-playing stream. Returns
+// ErrStreamChanged if the current stream has changed in the meantime.
+func (p *Playback) AdvanceQueueFrom(current stream.Stream, onLoad StreamLoadCallback) (stream.Stream, error) {
+	p.streamMux.Lock()
+	defer p.streamMux.Unlock()
+
+	if s, _ := p.GetStream(); s != current {
+		return nil, ErrStreamChanged
+	}
+
+	return p.advanceQueue(onLoad)
+}
+
+// advanceQueue pops the next stream off of the queue and sets it as the
+// current stream. Queue items that do not implement stream.Stream are
+// discarded, and the queue keeps advancing until a stream is found.
+func (p *Playback) advanceQueue(onLoad StreamLoadCallback) (stream.Stream, error) {
+	var nextStream stream.Stream
+	for nextStream == nil {
+		queueItem, err := p.GetQueue().Next()
+		if err != nil {
+			return nil, err
+		}
+
+		s, ok := queueItem.(stream.Stream)
+		if !ok {
+			log.Printf("WRN PLAYBACK discarding queue item %q in room %q: expected queue item to implement stream.Stream", queueItem.UUID(), p.name)
+			continue
+		}
+		nextStream = s
+	}
+
+	nextStream = unwrapStream(nextStream)
+	p.SetStream(nextStream)
+	p.Reset()
+
+	if onLoad != nil {
+		onLoad(nextStream)
+	}
+	return nextStream, nil
+}
+
 // GetOrCreateStreamFromUrl receives a stream location (path, url, or unique identifier)
+// already resolved against the room's stream directory (see ResolveStreamUrl)
 // and retrieves a corresponding stream.Stream, or creates a new one.
 // Calls callback once a cached stream is fetched, or metadata has been fetched for a
 // newly-created stream.
+// Returns an error if the room's StreamFilter does not permit the stream.
 func (p *Playback) GetOrCreateStreamFromUrl(url string, user *client.Client, streamHandler stream.StreamHandler, callback PlaybackStreamMetadataCallback) (stream.Stream, error) {
+	if err := p.verifyStreamRoot(url); err != nil {
+		return nil, err
+	}
+	if err := p.filter.Verify(url); err != nil {
+		return nil, err
+	}
+
 	if s, exists := streamHandler.GetStream(url); exists {
 		log.Printf("INF PLAYBACK found existing stream object with url %q, retrieving...", url)
 		callback([]byte{}, false, nil)
@@ -395,11 +1155,23 @@ func (p *Playback) GetOrCreateStreamFromUrl(url string, user *client.Client, str
 // about the current state of the Playback.
 // Implements api.ApiCodec.
 type PlaybackStatus struct {
-	QueueLength int          `json:"queueLength"`
-	StartedBy   string       `json:"startedBy"`
-	CreatedBy   string       `json:"createdBy"`
-	Stream      api.ApiCodec `json:"stream"`
-	TimerStatus api.ApiCodec `json:"playback"`
+	QueueLength    int          `json:"queueLength"`
+	StartedBy      string       `json:"startedBy"`
+	CreatedBy      string       `json:"createdBy"`
+	Stream         api.ApiCodec `json:"stream"`
+	TimerStatus    api.ApiCodec `json:"playback"`
+	Mode           string       `json:"mode"`
+	DirectUrl      string       `json:"directUrl,omitempty"`
+	DefaultVolume  *int         `json:"defaultVolume,omitempty"`
+	Volume         int          `json:"volume"`
+	StreamRoot     string       `json:"streamRoot,omitempty"`
+	UserQueueLimit int          `json:"userQueueLimit,omitempty"`
+	QueueMode      string       `json:"queueMode"`
+	NoSeekAhead    bool         `json:"noSeekAhead,omitempty"`
+	Mirroring      string       `json:"mirroring,omitempty"`
+	OnEmpty        string       `json:"onEmpty"`
+	SubtitlesPath  string       `json:"subtitlesPath,omitempty"`
+	SubtitlesOn    bool         `json:"subtitlesOn"`
 }
 
 func (s *PlaybackStatus) Serialize() ([]byte, error) {
@@ -417,18 +1189,58 @@ func (p *Playback) GetStatus() api.ApiCodec {
 	var streamCodec api.ApiCodec
 	var createdBy string
 
-	s, exists := p.GetStream()
-	if exists {
+	var mirroring string
+	if source, exists := p.Mirroring(); exists {
+		mirroring = source.UUID()
+	}
+
+	// read the current stream, the fields describing it, and the
+	// room's settings at once, so that they all refer to the same
+	// stream and are never read while a command changes them
+	p.statusMux.RLock()
+	s, startedBy, directUrl := p.stream, p.startedBy, p.directUrl
+	vol, defaultVol := p.currentVolume, p.defaultVolume
+	streamRoot, userQueueLimit, noSeekAhead := p.streamRoot, p.userQueueLimit, p.noSeekAhead
+	subtitlesPath := p.subtitlesPath
+	p.statusMux.RUnlock()
+
+	// the default volume is left out while it is unset
+	var defaultVolume *int
+	if defaultVol >= 0 {
+		defaultVolume = &defaultVol
+	}
+
+	// report the mode clients should actually use for the
+	// current stream - fall back to "embed" if no direct
+	// url could be resolved.
+	mode := PLAYBACK_MODE_EMBED
+	if len(directUrl) > 0 {
+		mode = PLAYBACK_MODE_DIRECT
+	}
+
+	if s != nil {
 		streamCodec = s.Codec()
 		createdBy = s.Metadata().GetCreationSource().GetSourceName()
 	}
 
 	return &PlaybackStatus{
-		QueueLength: p.GetQueue().Size(),
-		StartedBy:   p.startedBy,
-		CreatedBy:   createdBy,
-		TimerStatus: p.timer.Status(),
-		Stream:      streamCodec,
+		QueueLength:    p.GetQueue().Size(),
+		StartedBy:      startedBy,
+		CreatedBy:      createdBy,
+		TimerStatus:    p.timer.Status(),
+		Stream:         streamCodec,
+		Mode:           mode,
+		DirectUrl:      directUrl,
+		DefaultVolume:  defaultVolume,
+		Volume:         vol,
+		StreamRoot:     streamRoot,
+		UserQueueLimit: userQueueLimit,
+		QueueMode:      p.QueueMode(),
+		NoSeekAhead:    noSeekAhead,
+		Mirroring:      mirroring,
+		OnEmpty:        p.OnEmpty(),
+		SubtitlesPath:  subtitlesPath,
+		SubtitlesOn:    len(subtitlesPath) > 0,
 	}
 }
 
@@ -452,12 +1264,22 @@ func NewPlayback(ns connection.Namespace) *Playback {
 		panic("A namespace with a name is required to instantiate a new playback")
 	}
 
-	return &Playback{
-		name:               ns.Name(),
-		timer:              NewTimer(),
-		queueHandler:       queue.NewQueueHandler(queue.NewRoundRobinQueue()),
-		lastUpdated:        time.Now(),
-		lastAdminDeparture: time.Time{},
-		state:              PLAYBACK_STATE_NOT_STARTED,
+	p := &Playback{
+		name:                 ns.Name(),
+		timer:                NewTimer(),
+		queueHandler:         queue.NewQueueHandler(queue.NewRoundRobinQueue()),
+		createdAt:            time.Now(),
+		lastUpdated:          time.Now(),
+		lastAdminDeparture:   time.Time{},
+		mode:                 PLAYBACK_MODE_EMBED,
+		defaultVolume:        -1,
+		currentVolume:        100,
+		filter:               NewStreamFilter(),
+		state:                PLAYBACK_STATE_NOT_STARTED,
+		emptyGracePeriod:     DEFAULT_EMPTY_ROOM_GRACE_PERIOD,
+		streamLoadAckTimeout: DEFAULT_STREAM_LOAD_ACK_TIMEOUT,
+		reorderWindow:        DEFAULT_REORDER_BROADCAST_WINDOW,
 	}
+	p.timer.OnTick(p.countWatchTime)
+	return p
 }
@@ -0,0 +1,175 @@
+package playback
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+func TestAdvanceQueueNonStreamItems(t *testing.T) {
+	tests := []struct {
+		name string
+		// items are the ids of the items in the user's queue, in
+		// order. Ids prefixed with "http" are queued as streams.
+		items  []string
+		expect []string
+	}{
+		{
+			name:   "streams only",
+			items:  []string{"http://a", "http://b"},
+			expect: []string{"http://a", "http://b"},
+		},
+		{
+			name:   "leading non-stream item",
+			items:  []string{"bad", "http://a", "http://b"},
+			expect: []string{"http://a", "http://b"},
+		},
+		{
+			name:   "consecutive non-stream items",
+			items:  []string{"http://a", "bad1", "bad2", "http://b"},
+			expect: []string{"http://a", "http://b"},
+		},
+		{
+			name:   "trailing non-stream item",
+			items:  []string{"http://a", "bad"},
+			expect: []string{"http://a"},
+		},
+		{
+			name:   "non-stream items only",
+			items:  []string{"bad1", "bad2"},
+			expect: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPlayback(connection.NewNamespace("advance"))
+			userQueue := queue.NewAggregatableQueue("user")
+			if err := p.GetQueue().Push(userQueue); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, id := range tc.items {
+				var item queue.QueueItem = queue.NewQueueItem(id)
+				if strings.HasPrefix(id, "http") {
+					item = stream.NewRemoteVideoStream(id)
+				}
+				if err := userQueue.Push(item); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			played := []string{}
+			for {
+				s, err := p.AdvanceQueue(nil)
+				if err != nil {
+					break
+				}
+				if current, _ := p.GetStream(); current != s {
+					t.Fatalf("expected advanced stream %q to be the current stream", s.GetStreamURL())
+				}
+				played = append(played, s.GetStreamURL())
+			}
+
+			if !reflect.DeepEqual(played, tc.expect) {
+				t.Fatalf("expected streams %v to be played, got %v", tc.expect, played)
+			}
+			if p.GetQueue().Size() != 0 {
+				t.Fatalf("expected the queue to be empty once advanced, got %v queued", p.GetQueue().Size())
+			}
+		})
+	}
+}
+
+func TestGetStatusConcurrentSettings(t *testing.T) {
+	p := NewPlayback(connection.NewNamespace("status"))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			p.SetVolume(i)
+			p.SetDefaultVolume(i)
+			p.SetMode(PLAYBACK_MODE_EMBED)
+			p.SetNoSeekAhead(i%2 == 0)
+			p.SetUserQueueLimit(i % queue.MaxAggregatableQueueItems)
+			p.SetSubtitles("subs.vtt")
+			p.ClearSubtitles()
+			p.SetOnEmpty(ON_EMPTY_LOOP)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		status, ok := p.GetStatus().(*PlaybackStatus)
+		if !ok {
+			t.Fatalf("expected a *PlaybackStatus, got %T", p.GetStatus())
+		}
+		if status.Volume < 0 || status.Volume > 100 {
+			t.Fatalf("expected a volume between 0 and 100, got %v", status.Volume)
+		}
+	}
+	wg.Wait()
+}
+
+func TestGetStatusConcurrentCleanup(t *testing.T) {
+	p := NewPlayback(connection.NewNamespace("reaped"))
+	p.OnStateChange(func(from, to PlaybackState) {})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.Cleanup()
+	}()
+	for i := 0; i < 100; i++ {
+		p.GetStatus()
+		p.GetTime()
+		p.TimerState()
+		p.SetState(PlaybackState(i % 2))
+	}
+	wg.Wait()
+
+	if _, err := p.Snapshot(); err == nil {
+		t.Fatalf("expected an error taking a snapshot of a reaped room")
+	}
+}
+
+func TestStrictQueueDefault(t *testing.T) {
+	p := NewPlayback(connection.NewNamespace("strict"))
+	if !p.StrictQueue() {
+		t.Fatalf("expected rooms to queue strictly by default")
+	}
+
+	p.SetStrictQueue(false)
+	if p.StrictQueue() {
+		t.Fatalf("expected strict queueing to be turned off")
+	}
+}
+
+func TestClearQueue(t *testing.T) {
+	p := NewPlayback(connection.NewNamespace("clear"))
+	if err := p.ClearQueue(); err != nil {
+		t.Fatalf("unexpected error clearing an empty queue: %v", err)
+	}
+
+	userQueue := queue.NewAggregatableQueue("user")
+	if err := p.GetQueue().Push(userQueue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, url := range []string{"http://a", "http://b"} {
+		if _, err := p.PushToQueue(userQueue, stream.NewRemoteVideoStream(url)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := p.ClearQueue(); err != nil {
+		t.Fatalf("unexpected error clearing the queue: %v", err)
+	}
+	if size := p.RoomQueueSize(); size != 0 {
+		t.Fatalf("expected the queue to be empty once cleared, got %v queued", size)
+	}
+}
@@ -0,0 +1,50 @@
+package playback
+
+import (
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// SetPreparedStream receives a stream to be loaded at a later time,
+// once its metadata has been fetched. A previously prepared stream
+// is discarded. The prepared stream is kept from being reaped until
+// it is loaded, discarded, or replaced.
+func (p *Playback) SetPreparedStream(s stream.Stream) {
+	p.ClearPreparedStream()
+
+	s.Metadata().AddParentRef(p)
+	p.preparedStream = s
+}
+
+// PreparedStream returns the stream prepared for the room,
+// or a boolean (false) if no stream has been prepared.
+func (p *Playback) PreparedStream() (stream.Stream, bool) {
+	return p.preparedStream, p.preparedStream != nil
+}
+
+// ClearPreparedStream discards the stream prepared for the room, if any
+func (p *Playback) ClearPreparedStream() {
+	if p.preparedStream == nil {
+		return
+	}
+
+	if current, _ := p.GetStream(); p.preparedStream != current {
+		p.preparedStream.Metadata().RemoveParentRef(p)
+	}
+	p.preparedStream = nil
+}
+
+// LoadPreparedStream sets the stream prepared for the room as the
+// currently-playing stream, and resets the playback timer. Returns
+// a boolean (false) if no stream has been prepared. See LoadStream.
+func (p *Playback) LoadPreparedStream(onLoad StreamLoadCallback) (stream.Stream, bool) {
+	s, exists := p.PreparedStream()
+	if !exists {
+		return nil, false
+	}
+
+	p.LoadStream(s, onLoad)
+
+	// the loaded stream's parent ref is removed once it is replaced
+	p.preparedStream = nil
+	return s, true
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"time"
 
 	api "github.com/juanvallejo/streaming-server/pkg/api/types"
 )
@@ -14,6 +16,14 @@ const (
 	MaxAggregatableQueueItems = 20
 )
 
+const (
+	// ROUND_ROBIN_MODE plays the next item from each aggregated queue in turn
+	ROUND_ROBIN_MODE = "roundrobin"
+	// SHARED_MODE plays aggregated items in the order they were queued,
+	// regardless of which aggregated queue they belong to
+	SHARED_MODE = "shared"
+)
+
 var (
 	ErrNoItemsInQueue       = errors.New("there are no items in the queue")
 	ErrNoSuchQueueStr       = "no queue found with id %v"
@@ -141,6 +151,15 @@ type RoundRobinQueue interface {
 
 	// CurrentIndex returns the current round-robin index
 	CurrentIndex() int
+	// SetCurrentIndex receives an index into the aggregated Queues
+	// and sets it as the current round-robin index.
+	// Returns an error if the index is out of range.
+	SetCurrentIndex(int) error
+	// ReorderWithCurrentIndex is a concurrency-safe method that re-orders
+	// the aggregated Queues (see Reorder) and sets the current round-robin
+	// index in a single step, so that the queue cannot be advanced between
+	// the two. Returns an error if either the new order or the index is invalid.
+	ReorderWithCurrentIndex([]int, int) error
 	// DeleteFromQueue receives an aggregated queue within the round-robin
 	// queue and attempts to delete a QueueItem from it.
 	DeleteFromQueue(Queue, QueueItem) error
@@ -153,6 +172,13 @@ type RoundRobinQueue interface {
 	// PeekItems returns a slice containing the first item
 	// from each aggregated QueueItem in the queue.
 	PeekItems() []QueueItem
+	// Mode returns the order in which aggregated items are
+	// played: ROUND_ROBIN_MODE (the default) or SHARED_MODE.
+	Mode() string
+	// SetMode receives ROUND_ROBIN_MODE or SHARED_MODE and sets it
+	// as the order in which aggregated items are played.
+	// Returns an error if the mode is not supported.
+	SetMode(string) error
 }
 
 // AggregatableQueue is a queue that can be aggregated as a QueueItem
@@ -168,6 +194,42 @@ type QueueItem interface {
 	UUID() string
 }
 
+// TimedQueueItem is a QueueItem that records the time it was queued at
+type TimedQueueItem interface {
+	QueueItem
+
+	QueuedAt() time.Time
+}
+
+// QueuedAt returns the time the given QueueItem was queued at,
+// or a zero time if the item does not record it.
+func QueuedAt(item QueueItem) time.Time {
+	if timed, ok := item.(TimedQueueItem); ok {
+		return timed.QueuedAt()
+	}
+	return time.Time{}
+}
+
+// IdentifiableQueueItem is a QueueItem with an id that is unique
+// to its position in a queue, even if its UUID is not.
+type IdentifiableQueueItem interface {
+	QueueItem
+
+	ItemId() string
+}
+
+// QueueItemMatches returns true if the given id matches
+// either the QueueItem's UUID or its item id.
+func QueueItemMatches(item QueueItem, id string) bool {
+	if item.UUID() == id {
+		return true
+	}
+	if identifiable, ok := item.(IdentifiableQueueItem); ok {
+		return identifiable.ItemId() == id
+	}
+	return false
+}
+
 // QueueItemSchema implements Queue and QueueItem
 type QueueItemSchema struct {
 	id string
@@ -207,6 +269,16 @@ func (q *QueueSchema) DeleteItem(item QueueItem) error {
 		}
 	}
 
+	// prefer the exact queued item, if it can be identified
+	if identifiable, ok := item.(IdentifiableQueueItem); ok {
+		for i, v := range q.Items {
+			if QueueItemMatches(v, identifiable.ItemId()) {
+				idx = i
+				break
+			}
+		}
+	}
+
 	if idx >= 0 {
 		q.Items = append(q.Items[0:idx], q.Items[idx+1:len(q.Items)]...)
 		return nil
@@ -279,6 +351,11 @@ func (q *ReorderableQueueSchema) Reorder(newOrder []int) error {
 	q.Lock()
 	defer q.Unlock()
 
+	return reorder(q, newOrder)
+}
+
+// reorder implements Reorder for the given Queue without locking it
+func reorder(q Queue, newOrder []int) error {
 	items := q.List()
 	seen := make(map[int]bool)
 	newQueueItemList := make([]QueueItem, 0, q.Size())
@@ -366,11 +443,17 @@ type RoundRobinQueueSchema struct {
 	itemsById map[string]AggregatableQueue
 	mux       sync.Mutex
 
-	// count used to round-robin the queue for each QueueItem
+	// count used to round-robin the queue for each QueueItem.
+	// rrCount and mode are guarded by the ReorderableQueue lock.
 	rrCount int
+	// mode is the order in which aggregated items are played
+	mode string
 }
 
 func (q *RoundRobinQueueSchema) Clear() {
+	q.Lock()
+	defer q.Unlock()
+
 	for _, i := range q.itemsById {
 		agg, ok := i.(AggregatableQueue)
 		if !ok {
@@ -439,14 +522,89 @@ func (q *RoundRobinQueueSchema) Push(item QueueItem) error {
 	return nil
 }
 
+// Reorder re-orders the aggregated queues, adjusting the round-robin
+// count so that the queue due to play next is still played next.
+func (q *RoundRobinQueueSchema) Reorder(newOrder []int) error {
+	if len(newOrder) == 0 {
+		return nil
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	var next QueueItem
+	if items := q.List(); q.rrCount < len(items) {
+		next = items[q.rrCount]
+	}
+
+	if err := reorder(q.ReorderableQueue, newOrder); err != nil {
+		return err
+	}
+	if next == nil {
+		return nil
+	}
+
+	for idx, item := range q.List() {
+		if item.UUID() == next.UUID() {
+			q.rrCount = idx
+			break
+		}
+	}
+	return nil
+}
+
+func (q *RoundRobinQueueSchema) ReorderWithCurrentIndex(newOrder []int, idx int) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if err := q.validateIndex(idx); err != nil {
+		return err
+	}
+	if err := reorder(q.ReorderableQueue, newOrder); err != nil {
+		return err
+	}
+
+	q.rrCount = idx
+	return nil
+}
+
 func (q *RoundRobinQueueSchema) CurrentIndex() int {
+	q.Lock()
+	defer q.Unlock()
+
 	return q.rrCount
 }
 
+func (q *RoundRobinQueueSchema) SetCurrentIndex(idx int) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if err := q.validateIndex(idx); err != nil {
+		return err
+	}
+
+	q.rrCount = idx
+	return nil
+}
+
+// validateIndex returns an error if the given
+// index is not a valid round-robin index
+func (q *RoundRobinQueueSchema) validateIndex(idx int) error {
+	if idx < 0 || (idx > 0 && idx >= q.Size()) {
+		return fmt.Errorf("error: round-robin index out of range: %v", idx)
+	}
+	return nil
+}
+
 func (q *RoundRobinQueueSchema) DeleteItem(queue QueueItem) error {
 	q.Lock()
 	defer q.Unlock()
 
+	return q.deleteItem(queue)
+}
+
+// deleteItem implements DeleteItem without locking the queue
+func (q *RoundRobinQueueSchema) deleteItem(queue QueueItem) error {
 	if qItem, exists := q.itemsById[queue.UUID()]; exists {
 		idx := -1
 		for i, v := range q.List() {
@@ -462,6 +620,9 @@ func (q *RoundRobinQueueSchema) DeleteItem(queue QueueItem) error {
 		// rrCount to 0 (next item wraps back to first).
 		// If deleted QueueItem index is less than rrCount,
 		// decrease rrCount by one to "pull" items back.
+		// If the deleted QueueItem is the one at rrCount,
+		// rrCount is left as-is: the queue after it is
+		// shifted into its place and plays next.
 		if idx >= 0 {
 			q.ReorderableQueue.DeleteItem(queue)
 			if idx < q.rrCount {
@@ -501,26 +662,88 @@ func (q *RoundRobinQueueSchema) DeleteFromQueue(queue Queue, qItem QueueItem) er
 	return err
 }
 
+func (q *RoundRobinQueueSchema) Mode() string {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.mode
+}
+
+func (q *RoundRobinQueueSchema) SetMode(mode string) error {
+	q.Lock()
+	defer q.Unlock()
+
+	switch mode {
+	case ROUND_ROBIN_MODE, SHARED_MODE:
+		q.mode = mode
+		return nil
+	}
+	return fmt.Errorf("unsupported queue mode %q: expected one of %q or %q", mode, ROUND_ROBIN_MODE, SHARED_MODE)
+}
+
+// earliestIndex returns the index of the aggregated queue whose
+// first item was queued the earliest. Empty queues are skipped.
+func (q *RoundRobinQueueSchema) earliestIndex() int {
+	earliest := -1
+	var earliestAt time.Time
+	for idx, item := range q.List() {
+		aggQueue, ok := item.(AggregatableQueue)
+		if !ok || aggQueue.Size() == 0 {
+			continue
+		}
+
+		queuedAt := QueuedAt(aggQueue.List()[0])
+		if earliest < 0 || queuedAt.Before(earliestAt) {
+			earliest = idx
+			earliestAt = queuedAt
+		}
+	}
+
+	if earliest < 0 {
+		return q.rrCount
+	}
+	return earliest
+}
+
 func (q *RoundRobinQueueSchema) Next() (QueueItem, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	return q.next()
+}
+
+// next implements Next without locking the queue
+func (q *RoundRobinQueueSchema) next() (QueueItem, error) {
 	if q.Size() == 0 {
 		return nil, ErrNoItemsInQueue
 	}
 
+	// in shared mode, play the earliest queued item next
+	if q.mode == SHARED_MODE {
+		q.rrCount = q.earliestIndex()
+	}
+
+	// deleting aggregated queues keeps the round-robin count pointing at
+	// the queue due to play next (see DeleteItem); wrap around rather than
+	// index out of range if it was ever left past the end of the queue.
 	qItems := q.List()
+	if q.rrCount < 0 || q.rrCount >= len(qItems) {
+		q.rrCount = 0
+	}
 	qItem := qItems[q.rrCount]
 	aggQueue, ok := qItem.(AggregatableQueue)
 	if !ok {
-		return nil, fmt.Errorf("expected QueueItem at round-robin count %q to implement AggregatableQueue", q.rrCount)
+		return nil, fmt.Errorf("expected QueueItem at round-robin count %v to implement AggregatableQueue", q.rrCount)
 	}
 
 	// get next queue - if empty,
 	// skip and try again
 	if aggQueue.Size() == 0 {
-		err := q.DeleteItem(aggQueue)
+		err := q.deleteItem(aggQueue)
 		if err != nil {
 			return nil, err
 		}
-		return q.Next()
+		return q.next()
 	}
 
 	poppedItem, err := aggQueue.Pop()
@@ -560,11 +783,23 @@ func (q *RoundRobinQueueSchema) PeekItems() []QueueItem {
 }
 
 func (q *RoundRobinQueueSchema) Serialize() ([]byte, error) {
+	q.Lock()
+	defer q.Unlock()
+
 	items := q.PeekItems()
 
-	// sort items by round-robin index
+	if q.mode == SHARED_MODE {
+		// sort items by the time they were queued at
+		sort.SliceStable(items, func(i, j int) bool {
+			return QueuedAt(items[i]).Before(QueuedAt(items[j]))
+		})
+	} else {
+		// sort items by round-robin index
+		items = append(items[q.rrCount:], items[0:q.rrCount]...)
+	}
+
 	b, err := json.Marshal(&QueueSchema{
-		Items: append(items[q.rrCount:], items[0:q.rrCount]...),
+		Items: items,
 	})
 	if err != nil {
 		return []byte{}, err
@@ -578,5 +813,6 @@ func NewRoundRobinQueue() RoundRobinQueue {
 		ReorderableQueue: NewReorderableQueue(),
 
 		itemsById: make(map[string]AggregatableQueue),
+		mode:      ROUND_ROBIN_MODE,
 	}
 }
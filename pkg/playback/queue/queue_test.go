@@ -0,0 +1,301 @@
+package queue
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// newTestRoundRobinQueue returns a RoundRobinQueue aggregating a queue
+// for each of the given ids, in order. Each queue holds the given amount
+// of items, identified by the queue's id followed by the item's position
+// (A1, A2, ...).
+func newTestRoundRobinQueue(t *testing.T, items int, ids ...string) RoundRobinQueue {
+	q := NewRoundRobinQueue()
+	for _, id := range ids {
+		aggQueue := NewAggregatableQueue(id)
+		for i := 1; i <= items; i++ {
+			if err := aggQueue.Push(NewQueueItem(id + string(rune('0'+i)))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := q.Push(aggQueue); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return q
+}
+
+// playOrder pops every item in the queue and returns their ids
+func playOrder(t *testing.T, q RoundRobinQueue) []string {
+	order := []string{}
+	for q.Size() > 0 {
+		item, err := q.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		order = append(order, item.UUID())
+	}
+	return order
+}
+
+func TestReorderWithCurrentIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		advance   int
+		order     []int
+		idx       int
+		expectErr bool
+		expect    []string
+	}{
+		{
+			name:   "rotate to a fair start",
+			order:  []int{1, 2, 0},
+			idx:    0,
+			expect: []string{"B1", "C1", "A1", "B2", "C2", "A2"},
+		},
+		{
+			name:    "balance after advancing",
+			advance: 2,
+			order:   []int{2, 0, 1},
+			idx:     0,
+			expect:  []string{"C1", "A2", "B2", "C2"},
+		},
+		{
+			name:   "point at a moved queue",
+			order:  []int{0, 2, 1},
+			idx:    1,
+			expect: []string{"C1", "B1", "A1", "C2", "B2", "A2"},
+		},
+		{
+			name:      "index out of range",
+			order:     []int{2, 1, 0},
+			idx:       3,
+			expectErr: true,
+			expect:    []string{"A1", "B1", "C1", "A2", "B2", "C2"},
+		},
+		{
+			name:      "invalid order",
+			order:     []int{0, 0, 1},
+			idx:       0,
+			expectErr: true,
+			expect:    []string{"A1", "B1", "C1", "A2", "B2", "C2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newTestRoundRobinQueue(t, 2, "A", "B", "C")
+			for i := 0; i < tc.advance; i++ {
+				if _, err := q.Next(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			err := q.ReorderWithCurrentIndex(tc.order, tc.idx)
+			if tc.expectErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order := playOrder(t, q); !reflect.DeepEqual(order, tc.expect) {
+				t.Fatalf("expected play order %v, got %v", tc.expect, order)
+			}
+		})
+	}
+}
+
+func TestRoundRobinQueueConcurrentAccess(t *testing.T) {
+	q := newTestRoundRobinQueue(t, MaxAggregatableQueueItems, "A", "B", "C")
+	total := q.Size() * MaxAggregatableQueueItems
+
+	var wg sync.WaitGroup
+	popped := make(chan string, total)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, err := q.Next()
+				if err != nil {
+					return
+				}
+				popped <- item.UUID()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			q.Reorder([]int{1, 0})
+			q.ReorderWithCurrentIndex([]int{1, 0}, 0)
+			q.SetCurrentIndex(0)
+			q.SetMode(ROUND_ROBIN_MODE)
+			q.CurrentIndex()
+			q.Serialize()
+		}
+	}()
+	wg.Wait()
+	close(popped)
+
+	seen := make(map[string]bool)
+	for id := range popped {
+		if seen[id] {
+			t.Fatalf("expected every item to be popped once, %q was popped again", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != total {
+		t.Fatalf("expected %v items to be popped, got %v", total, len(seen))
+	}
+}
+
+func TestRoundRobinReorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance int
+		// deleted is the id of an aggregated queue
+		// deleted before re-ordering, if any
+		deleted string
+		order   []int
+		expect  []string
+	}{
+		{
+			name:   "next queue moved back",
+			order:  []int{1, 2, 0},
+			expect: []string{"A1", "B1", "C1", "A2", "B2", "C2"},
+		},
+		{
+			name:    "next queue moved forward",
+			advance: 1,
+			order:   []int{1, 0, 2},
+			expect:  []string{"B1", "A2", "C1", "B2", "C2"},
+		},
+		{
+			name:    "next queue moved to the end",
+			advance: 2,
+			order:   []int{0, 1},
+			expect:  []string{"C1", "A2", "B2", "C2"},
+		},
+		{
+			name:    "partial order appends remaining queues",
+			advance: 1,
+			order:   []int{2},
+			expect:  []string{"B1", "C1", "A2", "B2", "C2"},
+		},
+		{
+			name:    "after deleting the next queue",
+			advance: 1,
+			deleted: "B",
+			order:   []int{1, 0},
+			expect:  []string{"C1", "A2", "C2"},
+		},
+		{
+			name:    "after deleting a played queue",
+			advance: 2,
+			deleted: "A",
+			order:   []int{1, 0},
+			expect:  []string{"C1", "B2", "C2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newTestRoundRobinQueue(t, 2, "A", "B", "C")
+			for i := 0; i < tc.advance; i++ {
+				if _, err := q.Next(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if len(tc.deleted) > 0 {
+				if err := q.DeleteItem(NewQueueItem(tc.deleted)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if err := q.Reorder(tc.order); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order := playOrder(t, q); !reflect.DeepEqual(order, tc.expect) {
+				t.Fatalf("expected play order %v, got %v", tc.expect, order)
+			}
+		})
+	}
+}
+
+func TestRoundRobinDeleteItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance int
+		deleted string
+		// expectNext is the id of the item Next returns
+		// once the aggregated queue is deleted
+		expectNext string
+		expect     []string
+	}{
+		{
+			name:       "delete the queue at the current index",
+			advance:    1,
+			deleted:    "B",
+			expectNext: "C1",
+			expect:     []string{"A2", "C2"},
+		},
+		{
+			name:       "delete the queue before the current index",
+			advance:    1,
+			deleted:    "A",
+			expectNext: "B1",
+			expect:     []string{"C1", "B2", "C2"},
+		},
+		{
+			name:       "delete the last queue",
+			advance:    1,
+			deleted:    "C",
+			expectNext: "B1",
+			expect:     []string{"A2", "B2"},
+		},
+		{
+			name:       "delete the last queue at the current index",
+			advance:    2,
+			deleted:    "C",
+			expectNext: "A2",
+			expect:     []string{"B2"},
+		},
+		{
+			name:       "delete the first queue at the current index",
+			deleted:    "A",
+			expectNext: "B1",
+			expect:     []string{"C1", "B2", "C2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := newTestRoundRobinQueue(t, 2, "A", "B", "C")
+			for i := 0; i < tc.advance; i++ {
+				if _, err := q.Next(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if err := q.DeleteItem(NewQueueItem(tc.deleted)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			next, err := q.Next()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if next.UUID() != tc.expectNext {
+				t.Fatalf("expected the next item to be %q, got %q", tc.expectNext, next.UUID())
+			}
+			if order := playOrder(t, q); !reflect.DeepEqual(order, tc.expect) {
+				t.Fatalf("expected play order %v, got %v", tc.expect, order)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package playback
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	connutil "github.com/juanvallejo/streaming-server/pkg/socket/connection/util"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// QueuedStream composes a stream.Stream pushed to a queue and
+// implements queue.IdentifiableQueueItem. Its item id identifies
+// the stream's position in a queue, unlike its UUID, which is
+// shared by every queued copy of the same stream url.
+type QueuedStream struct {
+	stream.Stream
+
+	itemId   string
+	queuedAt time.Time
+}
+
+func (s *QueuedStream) ItemId() string {
+	return s.itemId
+}
+
+// QueuedAt returns the time the stream was pushed to the queue at
+func (s *QueuedStream) QueuedAt() time.Time {
+	return s.queuedAt
+}
+
+// MarshalJSON serializes the composed stream along with its item id
+func (s *QueuedStream) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(s.Stream)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return []byte{}, err
+	}
+
+	m["itemId"] = s.itemId
+	return json.Marshal(m)
+}
+
+func NewQueuedStream(s stream.Stream) (*QueuedStream, error) {
+	id, err := connutil.GenerateUUID()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate queue item id: %v", err)
+	}
+
+	return &QueuedStream{
+		Stream:   s,
+		itemId:   id,
+		queuedAt: time.Now(),
+	}, nil
+}
+
+// unwrapStream returns the stream.Stream composed by
+// a QueuedStream, or the given stream if not queued
+func unwrapStream(s stream.Stream) stream.Stream {
+	if queued, ok := s.(*QueuedStream); ok {
+		return queued.Stream
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package playback
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// REAP_REASON_EMPTY indicates a room was reaped once
+	// the grace period after its last client left ran out
+	REAP_REASON_EMPTY = "empty"
+	// REAP_REASON_IDLE indicates a room was reaped
+	// after going too long without being updated
+	REAP_REASON_IDLE = "idle"
+)
+
+// MaxReapedRooms is the maximum amount of
+// recently reaped rooms kept by a ReapAudit
+const MaxReapedRooms = 50
+
+// ReapedRoom is a record of a room removed by the PlaybackReaper
+type ReapedRoom struct {
+	Room     string    `json:"room"`
+	ReapedAt time.Time `json:"reapedAt"`
+	Reason   string    `json:"reason"`
+	// Lifetime is the amount of seconds the room existed for
+	Lifetime int `json:"lifetime"`
+}
+
+// ReapAudit keeps, in memory, the rooms most recently
+// removed by a PlaybackReaper, up to MaxReapedRooms.
+type ReapAudit struct {
+	mux   sync.Mutex
+	rooms []*ReapedRoom
+}
+
+// Record stores a reaped room, dropping the
+// oldest record once MaxReapedRooms is exceeded.
+func (a *ReapAudit) Record(p *Playback, reason string, reapedAt time.Time) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	a.rooms = append(a.rooms, &ReapedRoom{
+		Room:     p.UUID(),
+		ReapedAt: reapedAt,
+		Reason:   reason,
+		Lifetime: int(reapedAt.Sub(p.CreatedAt()).Seconds()),
+	})
+
+	if len(a.rooms) > MaxReapedRooms {
+		a.rooms = a.rooms[len(a.rooms)-MaxReapedRooms:]
+	}
+}
+
+// Rooms returns the recorded rooms, ordered
+// from the most recently reaped room.
+func (a *ReapAudit) Rooms() []*ReapedRoom {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	rooms := make([]*ReapedRoom, 0, len(a.rooms))
+	for i := len(a.rooms) - 1; i >= 0; i-- {
+		rooms = append(rooms, a.rooms[i])
+	}
+	return rooms
+}
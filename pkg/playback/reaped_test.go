@@ -0,0 +1,38 @@
+package playback
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+func TestReapAuditRecord(t *testing.T) {
+	handler := NewHandler(connection.NewNamespaceHandler())
+	audit := handler.ReapAudit()
+	if audit == nil {
+		t.Fatalf("expected the handler to keep a reap audit")
+	}
+
+	reapedAt := time.Now()
+	for i := 0; i < MaxReapedRooms+1; i++ {
+		p := NewPlayback(connection.NewNamespace(fmt.Sprintf("room-%v", i)))
+		audit.Record(p, REAP_REASON_EMPTY, reapedAt.Add(time.Duration(i)*time.Second))
+	}
+
+	rooms := audit.Rooms()
+	if len(rooms) != MaxReapedRooms {
+		t.Fatalf("expected %v rooms to be kept, got %v", MaxReapedRooms, len(rooms))
+	}
+	if expect := fmt.Sprintf("room-%v", MaxReapedRooms); rooms[0].Room != expect {
+		t.Fatalf("expected the most recently reaped room %q first, got %q", expect, rooms[0].Room)
+	}
+	if rooms[len(rooms)-1].Room != "room-1" {
+		t.Fatalf("expected the oldest record to be dropped, got %q last", rooms[len(rooms)-1].Room)
+	}
+
+	if other := NewHandler(connection.NewNamespaceHandler()).ReapAudit(); len(other.Rooms()) > 0 {
+		t.Fatalf("expected handlers not to share their reap audit, got %v rooms", len(other.Rooms()))
+	}
+}
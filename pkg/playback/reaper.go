@@ -7,6 +7,12 @@ import (
 
 const (
 	MaxStaleSPlaybackObjectDuration time.Duration = 5 * time.Minute // amount of time to wait before reaping a stale playback object
+
+	// DEFAULT_EMPTY_ROOM_GRACE_PERIOD is the amount of time to wait before reaping a
+	// playback object whose last client has left, unless set otherwise through
+	// Handler.SetEmptyRoomGracePeriod. Kept shorter than the stale duration so that
+	// empty rooms are reaped sooner, while still surviving a quick page refresh.
+	DEFAULT_EMPTY_ROOM_GRACE_PERIOD time.Duration = 1 * time.Minute
 )
 
 // PlaybackReaper is a PlaybackHandler's Garbage Collector.
@@ -19,7 +25,9 @@ type PlaybackReaper struct {
 	// a "stale" playbackobject is defined as a playback object that has not had its data
 	// updated in more than 1 second.
 	maxStalePlaybackObjectLifetime time.Duration
-	stopChan                       chan bool
+	// audit records the rooms removed by the reaper
+	audit    *ReapAudit
+	stopChan chan bool
 }
 
 func (r *PlaybackReaper) Stop() {
@@ -33,9 +41,21 @@ func (r *PlaybackReaper) Init(handler PlaybackHandler) {
 func reap(reaper *PlaybackReaper, handler PlaybackHandler, stop chan bool) {
 	for {
 		for _, s := range handler.Playbacks() {
-			if handler.IsReapable(s) && time.Now().Sub(s.GetLastUpdated()) > reaper.maxStalePlaybackObjectLifetime {
+			if !handler.IsReapable(s) {
+				continue
+			}
+
+			if elapsed, expired := reaper.expiry(s, time.Now()); expired {
+				reason := REAP_REASON_IDLE
+				if _, isEmpty := s.EmptySince(); isEmpty {
+					reason = REAP_REASON_EMPTY
+				}
+
 				if handler.ReapPlayback(s) {
-					log.Printf("INF REAPER room with name %q has become a candidate for reaping after %v. Reaping...\n", s.name, time.Now().Sub(s.GetLastUpdated()))
+					log.Printf("INF REAPER room with name %q has become a candidate for reaping after %v. Reaping...\n", s.name, elapsed)
+					if reaper.audit != nil {
+						reaper.audit.Record(s, reason, time.Now())
+					}
 				}
 			}
 		}
@@ -50,9 +70,38 @@ func reap(reaper *PlaybackReaper, handler PlaybackHandler, stop chan bool) {
 	}
 }
 
-func NewPlaybackReaper() *PlaybackReaper {
+// expiry returns the time elapsed in a reap-eligible room's reap window
+// at the given time, and a boolean (true) if the room has outlived it
+func (r *PlaybackReaper) expiry(p *Playback, now time.Time) (time.Duration, bool) {
+	since, lifetime := reapWindow(p, r.maxStalePlaybackObjectLifetime)
+	elapsed := now.Sub(since)
+	return elapsed, elapsed > lifetime
+}
+
+// ReapWindow returns the time from which the room's reaping countdown
+// is measured, and how long after that time a reap-eligible room is
+// reaped: its last update for idle rooms, or the time its last client
+// left for empty rooms.
+func (p *Playback) ReapWindow() (time.Time, time.Duration) {
+	return reapWindow(p, MaxStaleSPlaybackObjectDuration)
+}
+
+// reapWindow returns the time from which the room's reaping countdown is
+// measured, and the room's lifetime from that time: the given stale lifetime
+// for idle rooms, or the room's grace period for empty rooms.
+func reapWindow(p *Playback, staleLifetime time.Duration) (time.Time, time.Duration) {
+	if emptiedAt, isEmpty := p.EmptySince(); isEmpty {
+		return emptiedAt, p.emptyGracePeriod
+	}
+	return p.GetLastUpdated(), staleLifetime
+}
+
+// NewPlaybackReaper returns a PlaybackReaper recording
+// the rooms it reaps in the given ReapAudit, if not nil
+func NewPlaybackReaper(audit *ReapAudit) *PlaybackReaper {
 	return &PlaybackReaper{
 		maxStalePlaybackObjectLifetime: MaxStaleSPlaybackObjectDuration,
+		audit:                          audit,
 		stopChan:                       make(chan bool, 1),
 	}
 }
@@ -0,0 +1,240 @@
+package playback
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/webhook"
+)
+
+// fakeConn is a connection that only identifies itself
+type fakeConn struct {
+	connection.Connection
+
+	id       string
+	observer bool
+}
+
+func (c *fakeConn) UUID() string     { return c.id }
+func (c *fakeConn) IsObserver() bool { return c.observer }
+
+func TestReaperExpiry(t *testing.T) {
+	reaper := &PlaybackReaper{
+		maxStalePlaybackObjectLifetime: 5 * time.Minute,
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		lastUpdated time.Duration
+		// emptiedAt is how long ago the room's last client
+		// left, or 0 if the room was never emptied
+		emptiedAt time.Duration
+		// gracePeriod is the room's empty grace period, if not the default
+		gracePeriod   time.Duration
+		repopulated   bool
+		expectElapsed time.Duration
+		expectExpired bool
+	}{
+		{
+			name:          "idle room within stale duration",
+			lastUpdated:   2 * time.Minute,
+			expectElapsed: 2 * time.Minute,
+		},
+		{
+			name:          "idle room past stale duration",
+			lastUpdated:   6 * time.Minute,
+			expectElapsed: 6 * time.Minute,
+			expectExpired: true,
+		},
+		{
+			name:          "empty room within grace period",
+			lastUpdated:   30 * time.Second,
+			emptiedAt:     30 * time.Second,
+			expectElapsed: 30 * time.Second,
+		},
+		{
+			name:          "empty room past grace period",
+			lastUpdated:   2 * time.Minute,
+			emptiedAt:     2 * time.Minute,
+			expectElapsed: 2 * time.Minute,
+			expectExpired: true,
+		},
+		{
+			name:          "empty room within a longer grace period",
+			lastUpdated:   2 * time.Minute,
+			emptiedAt:     2 * time.Minute,
+			gracePeriod:   3 * time.Minute,
+			expectElapsed: 2 * time.Minute,
+		},
+		{
+			name:          "emptied room updated since",
+			lastUpdated:   10 * time.Second,
+			emptiedAt:     2 * time.Minute,
+			expectElapsed: 2 * time.Minute,
+			expectExpired: true,
+		},
+		{
+			name:          "repopulated room is measured as idle",
+			lastUpdated:   2 * time.Minute,
+			emptiedAt:     2 * time.Minute,
+			repopulated:   true,
+			expectElapsed: 2 * time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPlayback(connection.NewNamespace("reap"))
+			p.SetLastUpdated(now.Add(-tc.lastUpdated))
+			if tc.gracePeriod > 0 {
+				p.emptyGracePeriod = tc.gracePeriod
+			}
+			if tc.emptiedAt > 0 {
+				p.MarkEmpty(now.Add(-tc.emptiedAt))
+			}
+			if tc.repopulated {
+				p.MarkPopulated()
+			}
+
+			elapsed, expired := reaper.expiry(p, now)
+			if elapsed != tc.expectElapsed {
+				t.Fatalf("expected %v elapsed in the reap window, got %v", tc.expectElapsed, elapsed)
+			}
+			if expired != tc.expectExpired {
+				t.Fatalf("expected expired to be %v, got %v", tc.expectExpired, expired)
+			}
+		})
+	}
+}
+
+func TestIsReapable(t *testing.T) {
+	tests := []struct {
+		name          string
+		conns         []*fakeConn
+		expectReaping bool
+	}{
+		{
+			name:          "no connections",
+			expectReaping: true,
+		},
+		{
+			name:          "observers only",
+			conns:         []*fakeConn{{id: "observer", observer: true}},
+			expectReaping: true,
+		},
+		{
+			name:  "participant",
+			conns: []*fakeConn{{id: "observer", observer: true}, {id: "participant"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nsHandler := connection.NewNamespaceHandler()
+			handler := NewHandler(nsHandler)
+			p, err := handler.NewPlayback(nsHandler.NewNamespace("reap"), nil, client.NewHandler())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, conn := range tc.conns {
+				nsHandler.AddToNamespace(p.UUID(), conn)
+			}
+
+			emptiedAt := time.Now().Add(-time.Minute)
+			p.MarkEmpty(emptiedAt)
+
+			if reapable := handler.IsReapable(p); reapable != tc.expectReaping {
+				t.Fatalf("expected reapable to be %v, got %v", tc.expectReaping, reapable)
+			}
+
+			// querying a room's status must not change it
+			if since, isEmpty := p.EmptySince(); !isEmpty || !since.Equal(emptiedAt) {
+				t.Fatalf("expected the room to remain empty since %v, got %v (empty: %v)", emptiedAt, since, isEmpty)
+			}
+		})
+	}
+}
+
+func TestReapWindowConcurrentDepartures(t *testing.T) {
+	p := NewPlayback(connection.NewNamespace("reap"))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			p.SetLastUpdated(time.Now())
+			p.MarkEmpty(time.Now())
+			p.MarkPopulated()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		p.ReapWindow()
+	}
+	wg.Wait()
+}
+
+// fakeNotifier records every event it is notified of
+type fakeNotifier struct {
+	events []*webhook.Event
+}
+
+func (n *fakeNotifier) Notify(e *webhook.Event) {
+	n.events = append(n.events, e)
+}
+
+func TestReapPlaybackLifecycleEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		// streamUrl is the url of the stream playing when
+		// the room is reaped, or empty if nothing is playing
+		streamUrl string
+	}{
+		{
+			name:      "stream playing",
+			streamUrl: "http://example.com/a.mp4",
+		},
+		{
+			name: "nothing playing",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := &fakeNotifier{}
+			nsHandler := connection.NewNamespaceHandler()
+			handler := NewHandler(nsHandler)
+			handler.SetLifecycleNotifier(notifier)
+			p, err := handler.NewPlayback(nsHandler.NewNamespace("reap"), nil, client.NewHandler())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tc.streamUrl) > 0 {
+				p.SetStream(stream.NewRemoteVideoStream(tc.streamUrl))
+			}
+
+			if !handler.ReapPlayback(p) {
+				t.Fatalf("expected the room to be reaped")
+			}
+
+			last := notifier.events[len(notifier.events)-1]
+			if last.Event != webhook.EVENT_ROOM_REAPED {
+				t.Fatalf("expected a %q event, got %q", webhook.EVENT_ROOM_REAPED, last.Event)
+			}
+			if len(tc.streamUrl) == 0 {
+				if last.Stream != nil {
+					t.Fatalf("expected no stream in the %q event, got %q", last.Event, last.Stream.Url)
+				}
+				return
+			}
+			if last.Stream == nil || last.Stream.Url != tc.streamUrl {
+				t.Fatalf("expected the %q event to include stream %q, got %+v", last.Event, tc.streamUrl, last.Stream)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+)
+
+// MaxQueueRequests is the maximum amount of pending
+// queue requests held by a moderated room at a time
+var MaxQueueRequests = 50
+
+// MaxUserQueueRequests is the maximum amount of pending queue
+// requests a single user may hold in a moderated room at a time
+const MaxUserQueueRequests = 5
+
+var ErrMaxQueueRequestsExceeded = errors.New("the room's request line is full, try again once an admin has reviewed some requests")
+
+var ErrMaxUserQueueRequestsExceeded = fmt.Errorf("you cannot have more than %v pending queue requests, try again once an admin has reviewed some of them", MaxUserQueueRequests)
+
+// QueueRequest is a stream a user has asked to add to
+// their queue in a moderated room, pending admin approval
+type QueueRequest struct {
+	Id            string    `json:"id"`
+	Url           string    `json:"url"`
+	RequesterId   string    `json:"requesterId"`
+	RequesterName string    `json:"requesterName"`
+	RequestedAt   time.Time `json:"requestedAt"`
+}
+
+// SetModerated receives a boolean determining whether streams added to the
+// room's queue by non-admins must first be approved by an admin
+func (p *Playback) SetModerated(moderated bool) {
+	p.requestMux.Lock()
+	defer p.requestMux.Unlock()
+
+	p.moderated = moderated
+}
+
+// Moderated returns true if streams added to the room's queue
+// by non-admins must first be approved by an admin
+func (p *Playback) Moderated() bool {
+	p.requestMux.Lock()
+	defer p.requestMux.Unlock()
+
+	return p.moderated
+}
+
+// AddQueueRequest receives a stream url and the user requesting it be
+// added to their queue, and appends a QueueRequest to the room's request
+// line. Returns an error if the request line is full, or if the requester
+// already holds MaxUserQueueRequests pending requests.
+func (p *Playback) AddQueueRequest(url string, requester *client.Client) (*QueueRequest, error) {
+	p.requestMux.Lock()
+	defer p.requestMux.Unlock()
+
+	if len(p.queueRequests) >= MaxQueueRequests {
+		return nil, ErrMaxQueueRequestsExceeded
+	}
+
+	pending := 0
+	for _, req := range p.queueRequests {
+		if req.RequesterId == requester.UUID() {
+			pending++
+		}
+	}
+	if pending >= MaxUserQueueRequests {
+		return nil, ErrMaxUserQueueRequestsExceeded
+	}
+
+	p.queueRequestCount++
+	req := &QueueRequest{
+		Id:            strconv.Itoa(p.queueRequestCount),
+		Url:           url,
+		RequesterId:   requester.UUID(),
+		RequesterName: requester.GetUsernameOrId(),
+		RequestedAt:   time.Now(),
+	}
+	p.queueRequests = append(p.queueRequests, req)
+	return req, nil
+}
+
+// QueueRequests returns the room's pending queue requests, oldest first
+func (p *Playback) QueueRequests() []*QueueRequest {
+	p.requestMux.Lock()
+	defer p.requestMux.Unlock()
+
+	requests := make([]*QueueRequest, len(p.queueRequests))
+	copy(requests, p.queueRequests)
+	return requests
+}
+
+// TakeQueueRequest removes and returns the pending queue request
+// with the given id, or a boolean (false) if none exists.
+func (p *Playback) TakeQueueRequest(id string) (*QueueRequest, bool) {
+	p.requestMux.Lock()
+	defer p.requestMux.Unlock()
+
+	for idx, req := range p.queueRequests {
+		if req.Id == id {
+			p.queueRequests = append(p.queueRequests[:idx], p.queueRequests[idx+1:]...)
+			return req, true
+		}
+	}
+	return nil, false
+}
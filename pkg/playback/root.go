@@ -0,0 +1,83 @@
+package playback
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	paths "github.com/juanvallejo/streaming-server/pkg/server/path"
+)
+
+// SetStreamRoot receives a directory, relative to the server's stream data
+// root, and scopes the room's local streams to it. Local stream locations
+// in the room are then resolved relative to that directory, and may not
+// refer to files outside of it. An empty directory removes the scope.
+// Returns an error if the directory does not exist.
+func (p *Playback) SetStreamRoot(dir string) error {
+	root, err := resolveStreamRoot(dir)
+	if err != nil {
+		return err
+	}
+
+	p.statusMux.Lock()
+	defer p.statusMux.Unlock()
+
+	p.streamRoot = root
+	return nil
+}
+
+// resolveStreamRoot receives a directory and returns it relative to the
+// server's stream data root. Returns an error if the directory does not exist.
+func resolveStreamRoot(dir string) (string, error) {
+	root := paths.StreamDataRelativePath(dir)
+	if len(root) == 0 {
+		return "", nil
+	}
+
+	info, err := os.Stat(paths.StreamDataFilePathFromFilename(root))
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("error: stream directory %q does not exist", dir)
+	}
+	return root, nil
+}
+
+// StreamRoot returns the directory, relative to the server's stream data
+// root, that the room's local streams are scoped to, or a boolean (false)
+// if the room may play any file in the data root.
+func (p *Playback) StreamRoot() (string, bool) {
+	p.statusMux.RLock()
+	defer p.statusMux.RUnlock()
+
+	return p.streamRoot, len(p.streamRoot) > 0
+}
+
+// ResolveStreamUrl receives a user-provided stream location and, if it
+// refers to a local file and the room is scoped to a directory, returns
+// its location within that directory. Other locations are returned unchanged.
+func (p *Playback) ResolveStreamUrl(url string) string {
+	root, scoped := p.StreamRoot()
+	if !scoped || isRemoteStreamUrl(url) {
+		return url
+	}
+
+	return path.Join(root, paths.StreamDataRelativePath(url))
+}
+
+// verifyStreamRoot returns an error if the given stream location
+// refers to a local file outside of the room's stream directory.
+func (p *Playback) verifyStreamRoot(url string) error {
+	root, scoped := p.StreamRoot()
+	if !scoped || isRemoteStreamUrl(url) {
+		return nil
+	}
+
+	if !strings.HasPrefix(paths.StreamDataRelativePath(url), root+"/") {
+		return fmt.Errorf("error: streams in this room must be located in the %q directory", root)
+	}
+	return nil
+}
+
+func isRemoteStreamUrl(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
@@ -0,0 +1,63 @@
+package playback
+
+import (
+	"sync"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// QueueRuntime describes the total duration of every stream queued in a room
+type QueueRuntime struct {
+	// Seconds is the sum of the durations of every queued stream
+	Seconds float64 `json:"seconds"`
+	// Partial is true if the duration of at least one
+	// queued stream is not known, and is not counted
+	Partial bool `json:"partial"`
+	// Items is the amount of streams queued in the room
+	Items int `json:"items"`
+}
+
+// queueRuntimeState holds the queue runtime last reported to clients
+type queueRuntimeState struct {
+	mux sync.Mutex
+
+	reported QueueRuntime
+}
+
+// QueueRuntime returns the total duration of every stream queued in the room
+func (p *Playback) QueueRuntime() QueueRuntime {
+	runtime := QueueRuntime{}
+	for _, item := range p.GetQueue().List() {
+		userQueue, ok := item.(queue.AggregatableQueue)
+		if !ok {
+			continue
+		}
+
+		for _, queued := range userQueue.List() {
+			runtime.Items++
+
+			s, ok := queued.(stream.Stream)
+			if !ok || s.GetDuration() <= 0 {
+				runtime.Partial = true
+				continue
+			}
+			runtime.Seconds += s.GetDuration()
+		}
+	}
+	return runtime
+}
+
+// UpdateQueueRuntime computes the room's queue runtime and stores it as the
+// value last reported to clients. Returns the computed runtime, and a boolean
+// (true) if it differs from the previously reported value.
+func (p *Playback) UpdateQueueRuntime() (QueueRuntime, bool) {
+	runtime := p.QueueRuntime()
+
+	p.queueRuntime.mux.Lock()
+	defer p.queueRuntime.mux.Unlock()
+
+	changed := runtime != p.queueRuntime.reported
+	p.queueRuntime.reported = runtime
+	return runtime, changed
+}
@@ -0,0 +1,199 @@
+package playback
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// MaxQueueSlotsPerRoom is the maximum amount of
+// saved queue slots retained for a single room
+const MaxQueueSlotsPerRoom = 10
+
+// MaxQueueSlotRooms is the maximum amount of rooms saved queue slots are
+// retained for. Once reached, saving a queue for another room evicts the
+// slots of the room whose queue was saved the longest time ago.
+const MaxQueueSlotRooms = 100
+
+var ErrMaxQueueSlotsExceeded = errors.New("maximum amount of saved queues exceeded for this room")
+
+// QueueSlotOwner is a saved user queue: the id and name of the user
+// that owned the queue, and the urls of the streams it contained.
+type QueueSlotOwner struct {
+	Id   string   `json:"id"`
+	Name string   `json:"name"`
+	Urls []string `json:"urls"`
+}
+
+// QueueSlot is a snapshot of a room's queue saved under a given name.
+// Its user queues are stored in their round-robin order, starting
+// with the user queue due to play next.
+type QueueSlot struct {
+	Name    string            `json:"name"`
+	SavedAt time.Time         `json:"savedAt"`
+	Owners  []*QueueSlotOwner `json:"owners"`
+}
+
+// queueSlotStore keeps saved queue slots keyed by room name and slot name.
+// Slots are kept until their room is reaped, and are written to disk with
+// the room's snapshot (see Handler.SaveSnapshots).
+type queueSlotStore struct {
+	mux   sync.Mutex
+	slots map[string]map[string]*QueueSlot
+	// maxRooms is the maximum amount of rooms slots are retained for
+	maxRooms int
+}
+
+func newQueueSlotStore(maxRooms int) *queueSlotStore {
+	return &queueSlotStore{
+		slots:    make(map[string]map[string]*QueueSlot),
+		maxRooms: maxRooms,
+	}
+}
+
+// evictLeastRecentlySaved removes the slots of the room
+// whose most recent slot was saved the longest time ago
+func (s *queueSlotStore) evictLeastRecentlySaved() {
+	evict := ""
+	var evictSavedAt time.Time
+	for room, roomSlots := range s.slots {
+		var savedAt time.Time
+		for _, slot := range roomSlots {
+			if slot.SavedAt.After(savedAt) {
+				savedAt = slot.SavedAt
+			}
+		}
+
+		if len(evict) == 0 || savedAt.Before(evictSavedAt) {
+			evict = room
+			evictSavedAt = savedAt
+		}
+	}
+
+	delete(s.slots, evict)
+}
+
+// NewQueueSlot receives a slot name and a room's round-robin queue and
+// returns a QueueSlot containing the urls of every queued stream. The
+// given nameFunc is used to resolve the name of each user queue's owner.
+func NewQueueSlot(name string, roomQueue queue.RoundRobinQueue, nameFunc func(id string) string) *QueueSlot {
+	slot := &QueueSlot{
+		Name:    name,
+		SavedAt: time.Now(),
+		Owners:  []*QueueSlotOwner{},
+	}
+
+	items := roomQueue.List()
+	start := roomQueue.CurrentIndex()
+	if start >= len(items) {
+		start = 0
+	}
+
+	for i := range items {
+		userQueue, ok := items[(start+i)%len(items)].(queue.AggregatableQueue)
+		if !ok || userQueue.Size() == 0 {
+			continue
+		}
+
+		owner := &QueueSlotOwner{
+			Id:   userQueue.UUID(),
+			Name: nameFunc(userQueue.UUID()),
+			Urls: []string{},
+		}
+		for _, queued := range userQueue.List() {
+			if s, ok := queued.(stream.Stream); ok {
+				owner.Urls = append(owner.Urls, s.GetStreamURL())
+			}
+		}
+		slot.Owners = append(slot.Owners, owner)
+	}
+
+	return slot
+}
+
+// Save stores the given slot for the given room, replacing any slot
+// saved under the same name. Returns an error if the room has reached
+// its maximum amount of saved slots.
+func (s *queueSlotStore) Save(room string, slot *QueueSlot) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	roomSlots, exists := s.slots[room]
+	if !exists {
+		if len(s.slots) >= s.maxRooms {
+			s.evictLeastRecentlySaved()
+		}
+
+		roomSlots = make(map[string]*QueueSlot)
+		s.slots[room] = roomSlots
+	}
+
+	if _, exists := roomSlots[slot.Name]; !exists && len(roomSlots) >= MaxQueueSlotsPerRoom {
+		return ErrMaxQueueSlotsExceeded
+	}
+
+	roomSlots[slot.Name] = slot
+	return nil
+}
+
+// Get returns the slot saved under the given name
+// for the given room, or a boolean (false) if none exists.
+func (s *queueSlotStore) Get(room, name string) (*QueueSlot, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	slot, exists := s.slots[room][name]
+	return slot, exists
+}
+
+// Names returns the sorted names of every slot saved for the given room
+func (s *queueSlotStore) Names(room string) []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	names := []string{}
+	for name := range s.slots[room] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List returns every slot saved for the given room, sorted by name
+func (s *queueSlotStore) List(room string) []*QueueSlot {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	slots := []*QueueSlot{}
+	for _, slot := range s.slots[room] {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Name < slots[j].Name
+	})
+	return slots
+}
+
+// Delete removes every slot saved for the given room
+func (s *queueSlotStore) Delete(room string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	delete(s.slots, room)
+}
+
+func (h *Handler) SaveQueueSlot(room string, slot *QueueSlot) error {
+	return h.slots.Save(room, slot)
+}
+
+func (h *Handler) QueueSlot(room, name string) (*QueueSlot, bool) {
+	return h.slots.Get(room, name)
+}
+
+func (h *Handler) QueueSlotNames(room string) []string {
+	return h.slots.Names(room)
+}
@@ -0,0 +1,113 @@
+package playback
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+func TestSaveQueueSlotEviction(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxRooms int
+		// saves are the rooms slots are saved for, in order
+		saves       []string
+		expectRooms []string
+	}{
+		{
+			name:        "below the limit",
+			maxRooms:    3,
+			saves:       []string{"a", "b"},
+			expectRooms: []string{"a", "b"},
+		},
+		{
+			name:        "evict the least recently saved room",
+			maxRooms:    2,
+			saves:       []string{"a", "b", "c"},
+			expectRooms: []string{"b", "c"},
+		},
+		{
+			name:        "saving again keeps a room",
+			maxRooms:    2,
+			saves:       []string{"a", "b", "a", "c"},
+			expectRooms: []string{"a", "c"},
+		},
+		{
+			name:        "saving to a retained room evicts nothing",
+			maxRooms:    2,
+			saves:       []string{"a", "b", "b", "a"},
+			expectRooms: []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newQueueSlotStore(tc.maxRooms)
+
+			savedAt := time.Now()
+			for i, room := range tc.saves {
+				slot := &QueueSlot{
+					Name:    fmt.Sprintf("slot-%v", i),
+					SavedAt: savedAt.Add(time.Duration(i) * time.Second),
+				}
+				if err := store.Save(room, slot); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			rooms := []string{}
+			for room := range store.slots {
+				rooms = append(rooms, room)
+			}
+			sort.Strings(rooms)
+			if !reflect.DeepEqual(rooms, tc.expectRooms) {
+				t.Fatalf("expected slots to be retained for rooms %v, got %v", tc.expectRooms, rooms)
+			}
+		})
+	}
+}
+
+func TestQueueSlotsLifetime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slots")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	nsHandler := connection.NewNamespaceHandler()
+	handler := NewHandler(nsHandler)
+	p, err := handler.NewPlayback(nsHandler.NewNamespace("slots"), nil, client.NewHandler())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler.SaveQueueSlot(p.UUID(), &QueueSlot{Name: "lineup", SavedAt: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// slots are saved with the room's snapshot
+	if err := handler.SaveSnapshots(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := NewHandler(connection.NewNamespaceHandler())
+	if err := restored.LoadSnapshots(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := restored.QueueSlotNames(p.UUID()); !reflect.DeepEqual(names, []string{"lineup"}) {
+		t.Fatalf("expected slots %v to be restored, got %v", []string{"lineup"}, names)
+	}
+
+	// and are removed once the room is reaped
+	if !handler.ReapPlayback(p) {
+		t.Fatalf("expected the room to be reaped")
+	}
+	if names := handler.QueueSlotNames(p.UUID()); len(names) > 0 {
+		t.Fatalf("expected no slots once the room is reaped, got %v", names)
+	}
+}
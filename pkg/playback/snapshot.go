@@ -0,0 +1,409 @@
+package playback
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/playback/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// SnapshotInterval is the amount of time between
+// snapshots of every room written to a state directory
+var SnapshotInterval = 30 * time.Second
+
+// MaxPendingSnapshotAge is the amount of time a snapshot restored on
+// boot is kept, and left on disk, while its room is not created again
+const MaxPendingSnapshotAge = 24 * time.Hour
+
+const snapshotFileExt = ".json"
+
+// PlaybackSnapshot is a serializable copy of a room's state: its queued
+// streams, its current stream and timer position, the user that started
+// the current stream, and the queue slots saved for the room.
+type PlaybackSnapshot struct {
+	Room      string       `json:"room"`
+	SavedAt   time.Time    `json:"savedAt"`
+	StreamUrl string       `json:"streamUrl,omitempty"`
+	Position  int          `json:"position"`
+	Playing   bool         `json:"playing"`
+	StartedBy string       `json:"startedBy,omitempty"`
+	QueueMode string       `json:"queueMode"`
+	Queue     *QueueSlot   `json:"queue"`
+	Slots     []*QueueSlot `json:"slots,omitempty"`
+}
+
+// restoredQueues holds the streams restored from a snapshot for each
+// queue owner, keyed by username, until a client with that username
+// rejoins the room and claims them.
+type restoredQueues struct {
+	mux    sync.Mutex
+	owners map[string][]string
+}
+
+// pendingSnapshots holds snapshots restored from disk, keyed by room name,
+// until their room is created again by a returning client.
+type pendingSnapshots struct {
+	mux       sync.Mutex
+	snapshots map[string]*PlaybackSnapshot
+}
+
+// Snapshot returns a serialized PlaybackSnapshot of the room. Each user
+// queue is saved under its owner's username, since connection ids do not
+// outlive a server restart. Returns an error if the room has been reaped.
+func (p *Playback) Snapshot() ([]byte, error) {
+	snapshot, err := p.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snapshot)
+}
+
+// snapshot returns a PlaybackSnapshot of the room, without the
+// queue slots saved for it, which are kept by the Handler
+func (p *Playback) snapshot() (*PlaybackSnapshot, error) {
+	// keep the current stream from changing while it is saved
+	p.streamMux.Lock()
+	defer p.streamMux.Unlock()
+
+	if p.reaped {
+		return nil, fmt.Errorf("room %q has been reaped", p.name)
+	}
+
+	snapshot := &PlaybackSnapshot{
+		Room:      p.name,
+		SavedAt:   time.Now(),
+		Position:  p.GetTime(),
+		Playing:   p.TimerState() == TIMER_PLAY,
+		QueueMode: p.QueueMode(),
+		Queue:     NewQueueSlot(p.name, p.GetQueue(), p.queueOwnerName),
+	}
+
+	if s, exists := p.GetStream(); exists {
+		snapshot.StreamUrl = s.GetStreamURL()
+		snapshot.StartedBy = p.StartedBy()
+	}
+
+	return snapshot, nil
+}
+
+// queueOwnerName returns the username of the client that owns the user
+// queue with the given id, as referenced by the streams in its queue, or
+// an empty string if the owner has no username.
+func (p *Playback) queueOwnerName(id string) string {
+	userQueue, exists, err := util.GetQueueForId(id, p.GetQueue())
+	if err != nil || !exists {
+		return ""
+	}
+
+	for _, item := range userQueue.List() {
+		s, ok := item.(stream.Stream)
+		if !ok {
+			continue
+		}
+		ref, exists := s.Metadata().GetLabelledRef(p.UUID())
+		if !exists || ref.UUID() != id {
+			continue
+		}
+		if u, ok := ref.(*client.Client); ok {
+			name, _ := u.GetUsername()
+			return name
+		}
+	}
+	return ""
+}
+
+// RestoreSnapshot loads the queued streams and current stream saved in
+// the given snapshot into the room. Saved user queues are kept until a
+// client with their owner's username claims them (see ClaimRestoredQueue),
+// starting with the given client. Queues saved without an owner username,
+// and streams that can no longer be resolved, are skipped.
+// Returns the amount of streams that were skipped.
+func (p *Playback) RestoreSnapshot(snapshot *PlaybackSnapshot, user *client.Client, streamHandler stream.StreamHandler) int {
+	skipped := 0
+	noop := func(data []byte, created bool, err error) {}
+
+	if len(snapshot.QueueMode) > 0 {
+		if err := p.SetQueueMode(snapshot.QueueMode); err != nil {
+			log.Printf("WRN PLAYBACK unable to restore queue mode %q for room %q: %v", snapshot.QueueMode, p.name, err)
+		}
+	}
+
+	if snapshot.Queue != nil {
+		p.restored.mux.Lock()
+		if p.restored.owners == nil {
+			p.restored.owners = make(map[string][]string)
+		}
+		for _, owner := range snapshot.Queue.Owners {
+			if len(owner.Name) == 0 {
+				log.Printf("INF PLAYBACK skipping queue %q while restoring room %q: its owner has no username", owner.Id, p.name)
+				skipped += len(owner.Urls)
+				continue
+			}
+			p.restored.owners[owner.Name] = append(p.restored.owners[owner.Name], owner.Urls...)
+		}
+		p.restored.mux.Unlock()
+
+		p.ClaimRestoredQueue(user, streamHandler)
+	}
+
+	if len(snapshot.StreamUrl) == 0 {
+		return skipped
+	}
+
+	s, err := p.GetOrCreateStreamFromUrl(snapshot.StreamUrl, user, streamHandler, noop)
+	if err != nil {
+		log.Printf("INF PLAYBACK skipping current stream %q while restoring room %q: %v", snapshot.StreamUrl, p.name, err)
+		return skipped + 1
+	}
+
+	// the current stream was not necessarily started by the given client
+	if name, _ := user.GetUsername(); name != snapshot.StartedBy {
+		s.Metadata().RemoveLabelledRef(p.UUID())
+	}
+
+	p.LoadStream(s, nil)
+	if len(snapshot.StartedBy) > 0 {
+		p.UpdateStartedBy(snapshot.StartedBy)
+	}
+	p.SetState(PLAYBACK_STATE_STARTED)
+	if err := p.SetTime(snapshot.Position); err != nil {
+		log.Printf("WRN PLAYBACK unable to restore timer position for room %q: %v", p.name, err)
+	}
+	if snapshot.Playing {
+		if err := p.Play(); err != nil {
+			log.Printf("WRN PLAYBACK unable to resume playback for room %q: %v", p.name, err)
+		}
+	}
+	return skipped
+}
+
+// ClaimRestoredQueue queues the streams restored from a snapshot for the
+// given client's username under the client's own queue. Streams that can
+// no longer be resolved, or that do not fit in the client's queue, are
+// skipped. Returns the amount of streams queued.
+func (p *Playback) ClaimRestoredQueue(user *client.Client, streamHandler stream.StreamHandler) int {
+	name, exists := user.GetUsername()
+	if !exists {
+		return 0
+	}
+
+	p.restored.mux.Lock()
+	urls := p.restored.owners[name]
+	delete(p.restored.owners, name)
+	p.restored.mux.Unlock()
+
+	if len(urls) == 0 {
+		return 0
+	}
+
+	userQueue, exists, err := util.GetUserQueue(user, p.GetQueue())
+	if err != nil {
+		log.Printf("WRN PLAYBACK unable to restore queue for %q in room %q: %v", name, p.name, err)
+		return 0
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		if err := p.GetQueue().Push(userQueue); err != nil {
+			log.Printf("WRN PLAYBACK unable to restore queue for %q in room %q: %v", name, p.name, err)
+			return 0
+		}
+	}
+
+	queued := 0
+	noop := func(data []byte, created bool, err error) {}
+	for _, url := range urls {
+		if p.UserQueueFull(userQueue) {
+			log.Printf("INF PLAYBACK skipping stream %q while restoring the queue of %q in room %q: queue is full", url, name, p.name)
+			continue
+		}
+
+		s, err := p.GetOrCreateStreamFromUrl(url, user, streamHandler, noop)
+		if err != nil {
+			log.Printf("INF PLAYBACK skipping stream %q while restoring the queue of %q in room %q: %v", url, name, p.name, err)
+			continue
+		}
+		if _, err := p.PushToQueue(userQueue, s); err != nil {
+			log.Printf("INF PLAYBACK skipping stream %q while restoring the queue of %q in room %q: %v", url, name, p.name, err)
+			continue
+		}
+		queued++
+	}
+
+	if userQueue.Size() == 0 {
+		p.GetQueue().DeleteItem(userQueue)
+	}
+	return queued
+}
+
+func (h *Handler) RestoreFromSnapshot(data []byte) error {
+	snapshot := &PlaybackSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return err
+	}
+	if len(snapshot.Room) == 0 {
+		return fmt.Errorf("snapshot does not contain a room name")
+	}
+
+	// the room's slots are kept by the handler, for as long as its snapshot
+	for _, slot := range snapshot.Slots {
+		if err := h.slots.Save(snapshot.Room, slot); err != nil {
+			log.Printf("WRN PLAYBACK unable to restore queue slot %q for room %q: %v", slot.Name, snapshot.Room, err)
+		}
+	}
+
+	h.pending.mux.Lock()
+	defer h.pending.mux.Unlock()
+
+	h.pending.snapshots[snapshot.Room] = snapshot
+	return nil
+}
+
+func (h *Handler) TakeSnapshot(room string) (*PlaybackSnapshot, bool) {
+	h.pending.mux.Lock()
+	defer h.pending.mux.Unlock()
+
+	snapshot, exists := h.pending.snapshots[room]
+	if exists {
+		delete(h.pending.snapshots, room)
+	}
+	return snapshot, exists
+}
+
+// keepPendingSnapshot returns true if a snapshot restored for the given
+// room has not yet been taken, and was saved less than MaxPendingSnapshotAge
+// before the given time. Expired snapshots are discarded, along with the
+// queue slots restored from them.
+func (h *Handler) keepPendingSnapshot(room string, now time.Time) bool {
+	h.pending.mux.Lock()
+	defer h.pending.mux.Unlock()
+
+	snapshot, exists := h.pending.snapshots[room]
+	if !exists {
+		return false
+	}
+	if now.Sub(snapshot.SavedAt) < MaxPendingSnapshotAge {
+		return true
+	}
+
+	log.Printf("INF PLAYBACK discarding snapshot of room %q saved at %v: the room has not been created again", room, snapshot.SavedAt)
+	delete(h.pending.snapshots, room)
+	h.slots.Delete(room)
+	return false
+}
+
+// snapshotFile returns the path of the file
+// a room's snapshot is written to in dir
+func snapshotFile(dir, room string) string {
+	return filepath.Join(dir, base64.RawURLEncoding.EncodeToString([]byte(room))+snapshotFileExt)
+}
+
+// LoadSnapshots restores every snapshot file in the given directory
+// into the handler. Files that cannot be read or parsed are skipped.
+func (h *Handler) LoadSnapshots(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), snapshotFileExt) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			log.Printf("WRN PLAYBACK unable to read room snapshot %q: %v", f.Name(), err)
+			continue
+		}
+		if err := h.RestoreFromSnapshot(data); err != nil {
+			log.Printf("WRN PLAYBACK unable to restore room snapshot %q: %v", f.Name(), err)
+			continue
+		}
+	}
+	return nil
+}
+
+// SaveSnapshots writes a snapshot of every room to the given directory,
+// removing the snapshots of rooms that have since been reaped. Snapshots
+// restored on boot whose room has not been created again are kept for
+// up to MaxPendingSnapshotAge.
+func (h *Handler) SaveSnapshots(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	saved := make(map[string]bool)
+	for _, p := range h.Playbacks() {
+		snapshot, err := p.snapshot()
+		if err != nil {
+			log.Printf("ERR PLAYBACK unable to snapshot room %q: %v", p.UUID(), err)
+			continue
+		}
+		snapshot.Slots = h.slots.List(p.UUID())
+
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			log.Printf("ERR PLAYBACK unable to snapshot room %q: %v", p.UUID(), err)
+			continue
+		}
+
+		// write to a temporary file first, so that
+		// a partial write never replaces a snapshot
+		file := snapshotFile(dir, p.UUID())
+		if err := ioutil.WriteFile(file+".tmp", data, 0644); err != nil {
+			log.Printf("ERR PLAYBACK unable to write snapshot for room %q: %v", p.UUID(), err)
+			continue
+		}
+		if err := os.Rename(file+".tmp", file); err != nil {
+			log.Printf("ERR PLAYBACK unable to write snapshot for room %q: %v", p.UUID(), err)
+			continue
+		}
+		saved[filepath.Base(file)] = true
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), snapshotFileExt) || saved[f.Name()] {
+			continue
+		}
+
+		room, err := base64.RawURLEncoding.DecodeString(strings.TrimSuffix(f.Name(), snapshotFileExt))
+		if err == nil && h.keepPendingSnapshot(string(room), time.Now()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			log.Printf("WRN PLAYBACK unable to remove stale room snapshot %q: %v", f.Name(), err)
+		}
+	}
+	return nil
+}
+
+// PersistSnapshots writes a snapshot of every room managed
+// by the given handler to dir every SnapshotInterval.
+func PersistSnapshots(handler PlaybackHandler, dir string) {
+	go func() {
+		for {
+			time.Sleep(SnapshotInterval)
+			if err := handler.SaveSnapshots(dir); err != nil {
+				log.Printf("ERR PLAYBACK unable to save room snapshots to %q: %v", dir, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package playback
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+func TestSaveSnapshotsPendingExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		savedAgo    time.Duration
+		expectKept  bool
+		expectSlots bool
+	}{
+		{
+			name:        "recent snapshot is kept",
+			savedAgo:    time.Hour,
+			expectKept:  true,
+			expectSlots: true,
+		},
+		{
+			name:     "expired snapshot is removed",
+			savedAgo: MaxPendingSnapshotAge + time.Hour,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "snapshots")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			data, err := json.Marshal(&PlaybackSnapshot{
+				Room:    "pending",
+				SavedAt: time.Now().Add(-tc.savedAgo),
+				Slots:   []*QueueSlot{{Name: "lineup"}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			file := snapshotFile(dir, "pending")
+			if err := ioutil.WriteFile(file, data, 0644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			handler := NewHandler(connection.NewNamespaceHandler())
+			if err := handler.LoadSnapshots(dir); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := handler.SaveSnapshots(dir); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err = os.Stat(file)
+			if kept := err == nil; kept != tc.expectKept {
+				t.Fatalf("expected the snapshot file to be kept: %v, got %v", tc.expectKept, kept)
+			}
+			if _, kept := handler.TakeSnapshot("pending"); kept != tc.expectKept {
+				t.Fatalf("expected the pending snapshot to be kept: %v, got %v", tc.expectKept, kept)
+			}
+			if slots := len(handler.QueueSlotNames("pending")) > 0; slots != tc.expectSlots {
+				t.Fatalf("expected the restored slots to be kept: %v, got %v", tc.expectSlots, slots)
+			}
+		})
+	}
+}
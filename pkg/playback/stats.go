@@ -0,0 +1,132 @@
+package playback
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// roomStats holds counters tracked over the lifetime of a room
+type roomStats struct {
+	mux sync.Mutex
+
+	peakUsers     int
+	chatMessages  int
+	watchTime     int
+	contributions map[string]int
+}
+
+// ReportEntry describes a stream played in a room
+type ReportEntry struct {
+	Title    string    `json:"title"`
+	Url      string    `json:"url"`
+	Kind     string    `json:"kind"`
+	QueuedBy string    `json:"queuedBy"`
+	PlayedAt time.Time `json:"playedAt"`
+}
+
+// Report is a serializable summary of a room's session
+type Report struct {
+	Room        string         `json:"room"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Streams     []*ReportEntry `json:"streams"`
+	// WatchTime is the amount of seconds streams have played for
+	WatchTime          int            `json:"watchTime"`
+	PeakUsers          int            `json:"peakUsers"`
+	ChatMessages       int            `json:"chatMessages"`
+	QueueContributions map[string]int `json:"queueContributions"`
+}
+
+func (r *Report) Serialize() ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return b, nil
+}
+
+// RecordPresence receives the amount of participants currently
+// in the room, updating the room's peak concurrent users.
+func (p *Playback) RecordPresence(participants int) {
+	p.stats.mux.Lock()
+	defer p.stats.mux.Unlock()
+
+	if participants > p.stats.peakUsers {
+		p.stats.peakUsers = participants
+	}
+}
+
+// CountChatMessage records a chat message sent in the room
+func (p *Playback) CountChatMessage() {
+	p.stats.mux.Lock()
+	defer p.stats.mux.Unlock()
+
+	p.stats.chatMessages++
+}
+
+// countWatchTime records a second of stream playback
+func (p *Playback) countWatchTime(int) {
+	p.stats.mux.Lock()
+	defer p.stats.mux.Unlock()
+
+	p.stats.watchTime++
+}
+
+// countContribution records a stream queued by the user
+// the stream is labelled with for the room, if any.
+func (p *Playback) countContribution(s stream.Stream) {
+	ref, exists := s.Metadata().GetLabelledRef(p.UUID())
+	if !exists {
+		return
+	}
+	u, ok := ref.(*client.Client)
+	if !ok {
+		return
+	}
+
+	p.stats.mux.Lock()
+	defer p.stats.mux.Unlock()
+
+	if p.stats.contributions == nil {
+		p.stats.contributions = make(map[string]int)
+	}
+	p.stats.contributions[u.GetUsernameOrId()]++
+}
+
+// Report returns a summary of the room's session: the streams played in
+// the room (up to MaxHistoryItems), and who queued them, the total amount
+// of seconds streams have played for, the peak amount of concurrent users,
+// the amount of chat messages sent, and the amount of streams each user queued.
+func (p *Playback) Report() *Report {
+	report := &Report{
+		Room:               p.name,
+		GeneratedAt:        time.Now(),
+		Streams:            []*ReportEntry{},
+		QueueContributions: make(map[string]int),
+	}
+
+	for _, entry := range p.history {
+		report.Streams = append(report.Streams, &ReportEntry{
+			Title:    entry.Name(),
+			Url:      entry.Url,
+			Kind:     entry.Kind,
+			QueuedBy: entry.StartedBy,
+			PlayedAt: entry.PlayedAt,
+		})
+	}
+
+	p.stats.mux.Lock()
+	defer p.stats.mux.Unlock()
+
+	report.WatchTime = p.stats.watchTime
+	report.PeakUsers = p.stats.peakUsers
+	report.ChatMessages = p.stats.chatMessages
+	for name, count := range p.stats.contributions {
+		report.QueueContributions[name] = count
+	}
+	return report
+}
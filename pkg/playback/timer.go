@@ -4,48 +4,70 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	api "github.com/juanvallejo/streaming-server/pkg/api/types"
 )
 
 const (
-	MAX_TIMER_CHAN_BUFFER = 1
-
-	TIMER_PLAY = iota
+	TIMER_PLAY = iota + 1
 	TIMER_PAUSE
 	TIMER_STOP
 )
 
 type TimerCallback func(int)
 
+// activeTimers is the amount of Increment
+// goroutines currently advancing a timer
+var activeTimers int64
+
+// ActiveTimers returns the amount of timers currently being advanced.
+// Each playing timer is expected to be advanced by a single goroutine.
+func ActiveTimers() int {
+	return int(atomic.LoadInt64(&activeTimers))
+}
+
 // Timer keeps track of playback time
 type Timer struct {
 	time      int
 	state     int
 	callbacks []TimerCallback
-	timeChan  chan int
+
+	// done is closed once the timer's current run is paused or stopped
+	done chan struct{}
+
+	// held timers do not advance their time or
+	// call their callbacks, but retain their state
+	held bool
+
+	// mux guards the timer's fields between its
+	// Increment goroutine and its callers
+	mux sync.Mutex
 }
 
 func (t *Timer) Play() error {
-	if t.timeChan == nil {
-		panic("attempt to start a nil timer channel")
-	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
 
 	if t.state == TIMER_PLAY {
 		log.Printf("STREAM PLAYBACK TIMER attempt to play an already playing timer, ignoring...")
 		return nil
 	}
 
+	// every run of the timer receives its own done channel, so that a
+	// goroutine advancing a previous run always observes the end of its
+	// own run, and can never advance the timer alongside this run.
 	t.state = TIMER_PLAY
-	go Increment(t, t.timeChan)
+	t.done = make(chan struct{})
+	go Increment(t, t.done)
 	return nil
 }
 
 func (t *Timer) Stop() error {
-	if t.timeChan == nil {
-		panic("attempt to stop a nil timer channel")
-	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
 
 	t.time = 0
 	if t.state != TIMER_PLAY {
@@ -53,42 +75,95 @@ func (t *Timer) Stop() error {
 	}
 
 	t.state = TIMER_STOP
-	t.timeChan <- TIMER_STOP
+	t.endRun()
 	return nil
 }
 
 func (t *Timer) Pause() error {
-	if t.timeChan == nil {
-		panic("attempt to pause a nil timer channel")
-	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
 
 	if t.state != TIMER_PLAY {
 		return nil
 	}
 
 	t.state = TIMER_PAUSE
-	t.timeChan <- TIMER_PAUSE
+	t.endRun()
 	return nil
 }
 
+// endRun signals the goroutine advancing the timer's
+// current run to exit. Caller must hold the timer's lock.
+func (t *Timer) endRun() {
+	if t.done == nil {
+		panic("attempt to end a timer run with a nil done channel")
+	}
+
+	close(t.done)
+	t.done = nil
+}
+
 func (t *Timer) Set(time int) error {
 	if time < 0 {
 		return fmt.Errorf("time must be a positive integer")
 	}
 
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
 	t.time = time
 	return nil
 }
 
+// Hold stops the timer from advancing without changing its state
+func (t *Timer) Hold() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.held = true
+}
+
+// Release allows a held timer to continue advancing
+func (t *Timer) Release() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.held = false
+}
+
+func (t *Timer) IsHeld() bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.held
+}
+
 func (t *Timer) OnTick(callback TimerCallback) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
 	t.callbacks = append(t.callbacks, callback)
 }
 
+// clearCallbacks removes every callback added through OnTick
+func (t *Timer) clearCallbacks() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.callbacks = []TimerCallback{}
+}
+
 func (t *Timer) GetTime() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
 	return t.time
 }
 
 func (t *Timer) State() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
 	return t.state
 }
 
@@ -98,6 +173,7 @@ type TimerStatus struct {
 	IsPlaying bool `json:"isPlaying"`
 	IsPaused  bool `json:"isPaused"`
 	IsStopped bool `json:"isStopped"`
+	IsHeld    bool `json:"isHeld"`
 	Time      int  `json:"time"`
 }
 
@@ -111,10 +187,14 @@ func (s *TimerStatus) Serialize() ([]byte, error) {
 }
 
 func (t *Timer) Status() api.ApiCodec {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
 	return &TimerStatus{
 		IsPlaying: t.state == TIMER_PLAY,
 		IsStopped: t.state == TIMER_STOP,
 		IsPaused:  t.state == TIMER_PAUSE,
+		IsHeld:    t.held,
 		Time:      t.time,
 	}
 }
@@ -122,40 +202,58 @@ func (t *Timer) Status() api.ApiCodec {
 // Increment is a convenience function for incrementing
 // a timer's time value every second. If a timer.callback
 // func exists, it is called every increment interval.
-// Warning: this is not a concurrency-safe function
-func Increment(timer *Timer, c chan int) {
+// Increment returns as soon as the given done channel is
+// closed, and never increments the timer's time afterwards.
+func Increment(timer *Timer, done <-chan struct{}) {
 	if timer == nil {
 		panic("attempt to increment a nil timer")
 	}
 
-	for {
-		time.Sleep(time.Duration(1 * time.Second))
-		timer.time++
+	atomic.AddInt64(&activeTimers, 1)
+	defer atomic.AddInt64(&activeTimers, -1)
 
-		if len(timer.callbacks) > 0 {
-			for _, c := range timer.callbacks {
-				c(timer.time)
-			}
-		}
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
 
+	for {
 		select {
-		case sig := <-c:
-			if sig == TIMER_PAUSE || sig == TIMER_STOP {
-				log.Printf("STREAM PLAYBACK TIMER kill signal received: %v", sig)
-				return
-			}
+		case <-done:
+			log.Printf("STREAM PLAYBACK TIMER run ended, stopping timer goroutine")
+			return
+		case <-ticker.C:
+		}
 
-			log.Printf("STREAM PLAYBACK TIMER invalid timer signal code: %v is not a recognized channel operation", sig)
+		timer.mux.Lock()
+
+		// the run may have ended while waiting for the lock
+		select {
+		case <-done:
+			timer.mux.Unlock()
+			return
 		default:
+		}
+
+		if timer.held {
+			timer.mux.Unlock()
 			continue
 		}
+
+		timer.time++
+		currentTime := timer.time
+		callbacks := timer.callbacks
+		timer.mux.Unlock()
+
+		// callbacks are called without holding the timer's
+		// lock, as they may themselves play or stop the timer
+		for _, callback := range callbacks {
+			callback(currentTime)
+		}
 	}
 }
 
 func NewTimer() *Timer {
 	return &Timer{
 		state:     TIMER_STOP,
-		timeChan:  make(chan int, MAX_TIMER_CHAN_BUFFER),
 		callbacks: []TimerCallback{},
 	}
 }
@@ -0,0 +1,155 @@
+package playback
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForActiveTimers waits for the amount of goroutines advancing
+// timers to settle on the given amount, as goroutines advancing a
+// paused or stopped timer exit asynchronously.
+func waitForActiveTimers(t *testing.T, expect int) {
+	deadline := time.Now().Add(time.Second)
+	for ActiveTimers() != expect && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if active := ActiveTimers(); active != expect {
+		t.Fatalf("expected %v timer goroutines, got %v", expect, active)
+	}
+}
+
+func TestTimerIncrementLeak(t *testing.T) {
+	tests := []struct {
+		name    string
+		toggles []func(*Timer) error
+		// expectRunning is true if the timer is
+		// expected to be playing after the toggles
+		expectRunning bool
+	}{
+		{
+			name:          "play",
+			toggles:       []func(*Timer) error{(*Timer).Play},
+			expectRunning: true,
+		},
+		{
+			name:          "play an already playing timer",
+			toggles:       []func(*Timer) error{(*Timer).Play, (*Timer).Play, (*Timer).Play},
+			expectRunning: true,
+		},
+		{
+			name:    "play then pause",
+			toggles: []func(*Timer) error{(*Timer).Play, (*Timer).Pause},
+		},
+		{
+			name:    "play then stop",
+			toggles: []func(*Timer) error{(*Timer).Play, (*Timer).Stop},
+		},
+		{
+			name:          "pause then play",
+			toggles:       []func(*Timer) error{(*Timer).Play, (*Timer).Pause, (*Timer).Play},
+			expectRunning: true,
+		},
+		{
+			name:          "stop then play",
+			toggles:       []func(*Timer) error{(*Timer).Play, (*Timer).Stop, (*Timer).Play},
+			expectRunning: true,
+		},
+		{
+			name:    "pause and stop a stopped timer",
+			toggles: []func(*Timer) error{(*Timer).Pause, (*Timer).Stop, (*Timer).Pause},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			baseline := ActiveTimers()
+			timer := NewTimer()
+
+			// repeat the toggles to surface goroutines left running by each round
+			for i := 0; i < 100; i++ {
+				for _, toggle := range tc.toggles {
+					if err := toggle(timer); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+			}
+
+			expect := baseline
+			if tc.expectRunning {
+				expect++
+			}
+			waitForActiveTimers(t, expect)
+
+			if err := timer.Stop(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waitForActiveTimers(t, baseline)
+		})
+	}
+}
+
+func TestTimerToggleStress(t *testing.T) {
+	tests := []struct {
+		name    string
+		toggles []func(*Timer) error
+	}{
+		{
+			name:    "play and pause",
+			toggles: []func(*Timer) error{(*Timer).Play, (*Timer).Pause},
+		},
+		{
+			name:    "play and stop",
+			toggles: []func(*Timer) error{(*Timer).Play, (*Timer).Stop},
+		},
+		{
+			name:    "play, pause, and stop",
+			toggles: []func(*Timer) error{(*Timer).Play, (*Timer).Pause, (*Timer).Stop},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			baseline := ActiveTimers()
+			timer := NewTimer()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func(offset int) {
+					defer wg.Done()
+					for j := 0; j < 500; j++ {
+						tc.toggles[(offset+j)%len(tc.toggles)](timer)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			timer.Stop()
+			waitForActiveTimers(t, baseline)
+
+			var mux sync.Mutex
+			ticks := []int{}
+			timer.OnTick(func(currentTime int) {
+				mux.Lock()
+				defer mux.Unlock()
+				ticks = append(ticks, currentTime)
+			})
+
+			if err := timer.Play(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			time.Sleep(2500 * time.Millisecond)
+			timer.Stop()
+			waitForActiveTimers(t, baseline)
+
+			// a single goroutine advances the timer once a second
+			mux.Lock()
+			defer mux.Unlock()
+			if expect := []int{1, 2}; !reflect.DeepEqual(ticks, expect) {
+				t.Fatalf("expected the timer to tick %v, got %v", expect, ticks)
+			}
+		})
+	}
+}
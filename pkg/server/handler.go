@@ -136,7 +136,7 @@ func NewRequestHandler(socketRequestHandler *socket.Handler, connHandler connect
 		router:         NewRequestRouter(),
 		paths:          make(map[string]path.Path),
 		sockReqHandler: socketRequestHandler,
-		apiHandler:     api.NewHandler(connHandler),
+		apiHandler:     api.NewHandler(connHandler, socketRequestHandler.PlaybackHandler),
 	}
 	addRequestHandlers(handler)
 	return handler
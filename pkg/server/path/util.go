@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"mime"
 	"net/http"
+	"path"
 	"strings"
 )
 
@@ -33,11 +34,26 @@ func FileExtensionFromFilePath(fpath string) string {
 }
 
 func StreamDataFilePathFromFilename(fname string) string {
-	return StreamDataRootPath + "/" + fname
+	return StreamDataRootPath + "/" + StreamDataRelativePath(fname)
 }
 
 func StreamDataFilePathFromUrl(url string) string {
-	return StreamDataRootPath + "/" + StreamDataFilenameFromUrl(url)
+	return StreamDataRootPath + "/" + StreamDataRelativePathFromUrl(url)
+}
+
+// StreamDataRelativePath receives a path to a file or directory in the
+// stream data root and returns it cleaned, relative to the data root.
+// The returned path never refers to a location outside of the data root.
+func StreamDataRelativePath(fpath string) string {
+	return strings.TrimPrefix(path.Clean("/"+fpath), "/")
+}
+
+// StreamDataRelativePathFromUrl receives a stream-formatted request url
+// and returns the path it refers to, relative to the stream data root.
+func StreamDataRelativePathFromUrl(url string) string {
+	url = strings.SplitN(url, "?", 2)[0]
+	url = strings.TrimPrefix(url, "/s/")
+	return StreamDataRelativePath(url)
 }
 
 // StreamDataFilenameFromUrl receives a stream-formatted request url and
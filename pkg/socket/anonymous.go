@@ -0,0 +1,45 @@
+package socket
+
+import (
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd"
+)
+
+// ErrUsernameRequired is returned to clients without a username
+// that attempt to chat or queue streams while usernames are required.
+var ErrUsernameRequired = cmd.NewCmdError(cmd.CMD_ERR_USERNAME_REQUIRED, "error: you must choose a username before you can chat or queue streams. Use \"/%s name &lt;username&gt;\" to choose one", cmd.USER_NAME)
+
+// usernameRequiredCommands are the commands a client
+// without a username may not run while usernames are required
+var usernameRequiredCommands = map[string]bool{
+	cmd.QUEUE_NAME:    true,
+	cmd.PLAYLIST_NAME: true,
+}
+
+// SetRequireUsername receives a boolean determining whether clients must
+// choose a username before they are allowed to chat or queue streams.
+func (h *Handler) SetRequireUsername(require bool) {
+	h.requireUsername = require
+}
+
+// isAnonymous returns true if the given client
+// must choose a username before chatting or queueing
+func (h *Handler) isAnonymous(c *client.Client) bool {
+	if !h.requireUsername {
+		return false
+	}
+	_, hasUsername := c.GetUsername()
+	return !hasUsername
+}
+
+// commandRequiresUsername receives a command name or alias
+// and returns true if anonymous clients may not run it
+func (h *Handler) commandRequiresUsername(name string) bool {
+	if command, exists := h.CommandHandler.Commands()[name]; exists {
+		return usernameRequiredCommands[command.Name()]
+	}
+	if command, exists := h.CommandHandler.Aliases()[name]; exists {
+		return usernameRequiredCommands[command.Name()]
+	}
+	return false
+}
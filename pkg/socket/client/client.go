@@ -2,7 +2,9 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 
 	"github.com/juanvallejo/streaming-server/pkg/api/endpoint/query"
@@ -14,6 +16,10 @@ const (
 	USER_SYSTEM       = "system"
 )
 
+// ErrNoNamespace is returned when a client that does not belong to a
+// namespace (e.g. one that is mid-disconnect) attempts to broadcast to it
+var ErrNoNamespace = errors.New("broadcast attempt from client without a namespace")
+
 var RESERVED_USERNAMES = map[string]bool{
 	"system": true,
 }
@@ -21,6 +27,12 @@ var RESERVED_USERNAMES = map[string]bool{
 type Client struct {
 	connection connection.Connection
 	usernames  []string // stores MAX_USERNAME_HIST usernames; tail represents current username
+	// commandAcks determines whether the client is sent a system
+	// message when a command it runs succeeds without a result
+	commandAcks bool
+	// noInlineMedia determines whether image urls in the client's
+	// chat messages are left as links, rather than shown inline
+	noInlineMedia bool
 }
 
 type SerializableClientList struct {
@@ -174,14 +186,38 @@ func (c *Client) BroadcastErrorTo(err error) {
 	})
 }
 
-func (c *Client) BroadcastAll(evt string, data connection.MessageDataCodec) {
+// BroadcastErrorCodeTo broadcasts an error message event to the current
+// client, along with a machine-readable code describing the error
+func (c *Client) BroadcastErrorCodeTo(code string, err error) {
+	c.BroadcastTo("info_clienterror", &Response{
+		ErrMessage: err.Error(),
+		IsSystem:   true,
+		Extra: map[string]interface{}{
+			"code": code,
+		},
+	})
+}
+
+// BroadcastAll emits an event to every client in the current client's namespace,
+// including the current client. Returns ErrNoNamespace, and sends nothing,
+// if the client does not belong to a namespace.
+func (c *Client) BroadcastAll(evt string, data connection.MessageDataCodec) error {
 	ns, inRoom := c.Namespace()
 	if !inRoom {
-		panic("broadcast attempt from client without a namespace")
+		log.Printf("WRN SOCKET CLIENT client with id %q attempted to broadcast event %q without a namespace\n", c.UUID(), evt)
+		return ErrNoNamespace
 	}
 
 	m := getBroadcastMessage(evt, data)
 	c.connection.Broadcast(ns.Name(), evt, m)
+	return nil
+}
+
+// BroadcastToNamespace emits an event to every client in the given
+// namespace, regardless of the namespace the current client belongs to.
+func (c *Client) BroadcastToNamespace(ns string, evt string, data connection.MessageDataCodec) {
+	m := getBroadcastMessage(evt, data)
+	c.connection.Broadcast(ns, evt, m)
 }
 
 func (c *Client) BroadcastTo(evt string, data connection.MessageDataCodec) {
@@ -189,14 +225,25 @@ func (c *Client) BroadcastTo(evt string, data connection.MessageDataCodec) {
 	c.connection.Send(m)
 }
 
-func (c *Client) BroadcastFrom(evt string, data connection.MessageDataCodec) {
+// BroadcastToConnection emits an event to the given connection only
+func BroadcastToConnection(conn connection.Connection, evt string, data connection.MessageDataCodec) {
+	m := getBroadcastMessage(evt, data)
+	conn.Send(m)
+}
+
+// BroadcastFrom emits an event to every other client in the current client's
+// namespace. Returns ErrNoNamespace, and sends nothing, if the client does
+// not belong to a namespace.
+func (c *Client) BroadcastFrom(evt string, data connection.MessageDataCodec) error {
 	ns, exists := c.Namespace()
 	if !exists {
-		panic("broadcast attempt from client without a namespace")
+		log.Printf("WRN SOCKET CLIENT client with id %q attempted to broadcast event %q without a namespace\n", c.UUID(), evt)
+		return ErrNoNamespace
 	}
 
 	m := getBroadcastMessage(evt, data)
 	c.connection.BroadcastFrom(ns.Name(), evt, m)
+	return nil
 }
 
 func (c *Client) BroadcastAuthRequestTo(seg string) {
@@ -337,6 +384,36 @@ func (c *Client) Namespace() (connection.Namespace, bool) {
 	return ns, exists
 }
 
+// IsObserver returns true if the client receives broadcasts for its
+// room, but is not counted as one of the room's participants.
+func (c *Client) IsObserver() bool {
+	return c.connection.IsObserver()
+}
+
+// SetCommandAcks receives a boolean determining whether the client is sent a
+// system message when a command it runs succeeds without producing output
+func (c *Client) SetCommandAcks(acks bool) {
+	c.commandAcks = acks
+}
+
+// CommandAcks returns true if the client is sent a system message
+// when a command it runs succeeds without producing output
+func (c *Client) CommandAcks() bool {
+	return c.commandAcks
+}
+
+// SetInlineMedia receives a boolean determining whether image urls in
+// the client's chat messages are extracted and shown inline
+func (c *Client) SetInlineMedia(inline bool) {
+	c.noInlineMedia = !inline
+}
+
+// InlineMedia returns true if image urls in the client's
+// chat messages are extracted and shown inline
+func (c *Client) InlineMedia() bool {
+	return !c.noInlineMedia
+}
+
 // Connection returns the socket connection for the current client
 func (c *Client) Connection() connection.Connection {
 	return c.connection
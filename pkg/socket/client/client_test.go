@@ -0,0 +1,105 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+// fakeConn is a connection that records the namespaces it broadcasts to
+type fakeConn struct {
+	connection.Connection
+
+	ns         connection.Namespace
+	broadcasts []string
+	sent       int
+}
+
+func (c *fakeConn) UUID() string { return "id" }
+
+func (c *fakeConn) Namespace() (connection.Namespace, bool) {
+	return c.ns, c.ns != nil
+}
+
+func (c *fakeConn) Broadcast(ns, eventName string, data []byte) {
+	c.broadcasts = append(c.broadcasts, ns)
+}
+
+func (c *fakeConn) BroadcastFrom(ns, eventName string, data []byte) {
+	c.broadcasts = append(c.broadcasts, ns)
+}
+
+func (c *fakeConn) Send(data []byte) {
+	c.sent++
+}
+
+func TestBroadcastWithoutNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		broadcast func(*Client) error
+		// expectSent is the amount of messages sent
+		// to the client itself, which has no room
+		expectSent int
+	}{
+		{
+			name: "broadcast all",
+			broadcast: func(c *Client) error {
+				return c.BroadcastAll("streamsync", &Response{})
+			},
+		},
+		{
+			name: "broadcast from",
+			broadcast: func(c *Client) error {
+				return c.BroadcastFrom("streamsync", &Response{})
+			},
+		},
+		{
+			name: "system message from",
+			broadcast: func(c *Client) error {
+				c.BroadcastSystemMessageFrom("message")
+				return nil
+			},
+		},
+		{
+			name: "system message all",
+			broadcast: func(c *Client) error {
+				c.BroadcastSystemMessageAll("message")
+				return nil
+			},
+			expectSent: 1,
+		},
+		{
+			name: "chat action all",
+			broadcast: func(c *Client) error {
+				c.BroadcastChatActionAll("method", nil)
+				return nil
+			},
+			expectSent: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			roomless := &fakeConn{}
+			err := tc.broadcast(NewClient(roomless))
+			if err != nil && err != ErrNoNamespace {
+				t.Fatalf("expected error %q, got %q", ErrNoNamespace, err)
+			}
+			if len(roomless.broadcasts) > 0 {
+				t.Fatalf("expected nothing to be broadcast without a namespace, got %v broadcasts", len(roomless.broadcasts))
+			}
+			if roomless.sent != tc.expectSent {
+				t.Fatalf("expected %v messages sent to the client, got %v", tc.expectSent, roomless.sent)
+			}
+
+			// the same broadcast reaches the client's room once it has one
+			inRoom := &fakeConn{ns: connection.NewNamespace("room")}
+			if err := tc.broadcast(NewClient(inRoom)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(inRoom.broadcasts) != 1 || inRoom.broadcasts[0] != "room" {
+				t.Fatalf("expected a single broadcast to %q, got %v", "room", inRoom.broadcasts)
+			}
+		})
+	}
+}
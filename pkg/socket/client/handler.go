@@ -2,6 +2,8 @@ package client
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 )
@@ -21,11 +23,43 @@ type SocketClientHandler interface {
 	GetClientSize() int
 	// Clients returns a slice of Client instances saved in the internal map
 	Clients() []*Client
+	// SetSuffixTakenUsernames receives a boolean determining whether a client
+	// requesting a taken username is assigned the same username followed by
+	// the smallest available number (e.g. "alice" becomes "alice2"), rather
+	// than having its request rejected.
+	SetSuffixTakenUsernames(bool)
+	// SuffixTakenUsernames returns true if clients requesting
+	// a taken username are assigned a numbered username instead
+	SuffixTakenUsernames() bool
+	// SetUsernameReclaimGracePeriod sets the amount of time a disconnected
+	// client's username remains reserved for a client reconnecting from the
+	// same host. A value of 0 releases usernames immediately.
+	SetUsernameReclaimGracePeriod(time.Duration)
+	// UsernameReclaimGracePeriod returns the amount of time
+	// a disconnected client's username remains reserved
+	UsernameReclaimGracePeriod() time.Duration
+	// ReserveUsername receives a username released by a client connected
+	// from the given host, and reserves it for that host for the reclaim
+	// grace period. Usernames that are already reserved are left as they are.
+	ReserveUsername(username, host string, now time.Time)
+	// ReservedUsernames returns the usernames reserved for hosts
+	// other than the given one. Expired reservations are removed.
+	ReservedUsernames(host string, now time.Time) map[string]bool
+	// ReclaimUsername removes a username's reservation, and
+	// returns true if the username was reserved
+	ReclaimUsername(string) bool
 }
 
 // Handler implements ClientHandler
 type Handler struct {
 	clientsById map[string]*Client
+
+	suffixTakenUsernames bool
+
+	// reservationMux guards the reclaim grace period and username reservations
+	reservationMux     sync.Mutex
+	reclaimGracePeriod time.Duration
+	reservedUsernames  map[string]usernameReservation
 }
 
 func (h *Handler) CreateClient(socket connection.Connection) *Client {
@@ -64,8 +98,18 @@ func (h *Handler) GetClientSize() int {
 	return len(h.clientsById)
 }
 
+func (h *Handler) SetSuffixTakenUsernames(suffix bool) {
+	h.suffixTakenUsernames = suffix
+}
+
+func (h *Handler) SuffixTakenUsernames() bool {
+	return h.suffixTakenUsernames
+}
+
 func NewHandler() SocketClientHandler {
 	return &Handler{
-		clientsById: make(map[string]*Client),
+		clientsById:        make(map[string]*Client),
+		reclaimGracePeriod: DEFAULT_USERNAME_RECLAIM_GRACE_PERIOD,
+		reservedUsernames:  make(map[string]usernameReservation),
 	}
 }
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"time"
+)
+
+// DEFAULT_USERNAME_RECLAIM_GRACE_PERIOD is the amount of time a disconnected
+// client's username remains reserved for a client reconnecting from the same host
+const DEFAULT_USERNAME_RECLAIM_GRACE_PERIOD = 30 * time.Second
+
+// usernameReservation holds a released username for the host it was released by
+type usernameReservation struct {
+	host    string
+	expires time.Time
+}
+
+func (h *Handler) SetUsernameReclaimGracePeriod(d time.Duration) {
+	h.reservationMux.Lock()
+	defer h.reservationMux.Unlock()
+
+	h.reclaimGracePeriod = d
+}
+
+func (h *Handler) UsernameReclaimGracePeriod() time.Duration {
+	h.reservationMux.Lock()
+	defer h.reservationMux.Unlock()
+
+	return h.reclaimGracePeriod
+}
+
+func (h *Handler) ReserveUsername(username, host string, now time.Time) {
+	h.reservationMux.Lock()
+	defer h.reservationMux.Unlock()
+
+	if h.reclaimGracePeriod <= 0 {
+		return
+	}
+
+	if _, exists := h.reservedUsernames[username]; exists {
+		return
+	}
+
+	h.reservedUsernames[username] = usernameReservation{
+		host:    host,
+		expires: now.Add(h.reclaimGracePeriod),
+	}
+}
+
+func (h *Handler) ReservedUsernames(host string, now time.Time) map[string]bool {
+	h.reservationMux.Lock()
+	defer h.reservationMux.Unlock()
+
+	others := make(map[string]bool)
+	for username, r := range h.reservedUsernames {
+		if !now.Before(r.expires) {
+			delete(h.reservedUsernames, username)
+			continue
+		}
+		if r.host != host {
+			others[username] = true
+		}
+	}
+	return others
+}
+
+func (h *Handler) ReclaimUsername(username string) bool {
+	h.reservationMux.Lock()
+	defer h.reservationMux.Unlock()
+
+	if _, reserved := h.reservedUsernames[username]; !reserved {
+		return false
+	}
+
+	delete(h.reservedUsernames, username)
+	return true
+}
@@ -13,7 +13,7 @@ type ClearCmd struct {
 const (
 	CLEAR_NAME        = "clear"
 	CLEAR_DESCRIPTION = "clears all messages from the chat window"
-	CLEAR_USAGE       = "Usage: /" + CLEAR_NAME
+	CLEAR_USAGE       = "Usage: /" + CLEAR_NAME + " [me]"
 )
 
 var (
@@ -21,6 +21,11 @@ var (
 )
 
 func (h *ClearCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) > 0 && args[0] != "me" {
+		return h.usage, nil
+	}
+
+	// only the caller's chat window is cleared
 	user.BroadcastChatActionTo("clearView", nil)
 	return "Clearing chat window messages...", nil
 }
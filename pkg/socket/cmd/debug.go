@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+	"html"
+	"runtime"
+	"strings"
+	"time"
+
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
@@ -13,7 +19,7 @@ type DebugCmd struct {
 const (
 	DEBUG_NAME        = "debug"
 	DEBUG_DESCRIPTION = "suite of basic admin debugging tools"
-	DEBUG_USAGE       = "Usage: /" + DEBUG_NAME + " &lt;refresh&gt;"
+	DEBUG_USAGE       = "Usage: /" + DEBUG_NAME + " &lt;refresh|timers|refs&gt;"
 )
 
 func (h *DebugCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
@@ -26,9 +32,109 @@ func (h *DebugCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		return "Reloading all clients", nil
 	}
 
+	if args[0] == "timers" {
+		return timerDiagnostics(playbackHandler), nil
+	}
+
+	if args[0] == "refs" {
+		return refDiagnostics(playbackHandler, streamHandler), nil
+	}
+
 	return h.usage, nil
 }
 
+// timerDiagnostics reports the amount of playing room timers against the
+// amount of goroutines advancing timers. Every playing timer should be
+// advanced by exactly one goroutine; any excess indicates a leak.
+func timerDiagnostics(playbackHandler playback.PlaybackHandler) string {
+	rooms := playbackHandler.Playbacks()
+	playing := 0
+	for _, p := range rooms {
+		if p.TimerState() == playback.TIMER_PLAY {
+			playing++
+		}
+	}
+	active := playback.ActiveTimers()
+
+	output := "Timer diagnostics:<br />"
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>rooms</span>: %v", len(rooms))
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>playing timers</span>: %v", playing)
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>timer goroutines</span>: %v", active)
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>goroutines</span>: %v", runtime.NumGoroutine())
+
+	if active > playing {
+		output += fmt.Sprintf("<br /><br />%v timer goroutines are still running for paused or stopped timers. If this persists, timer goroutines may be leaking.", active-playing)
+	}
+	return output
+}
+
+// refDiagnostics lists the registered streams that cannot currently be
+// reaped, along with why. Streams are only reaped once no room holds a
+// parent ref to them, and they have gone unused for longer than
+// stream.MaxStaleStreamDuration. Parent refs held by rooms that no
+// longer exist indicate a leaked ref.
+func refDiagnostics(playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) string {
+	rooms := make(map[string]bool)
+	for _, p := range playbackHandler.Playbacks() {
+		rooms[p.UUID()] = true
+	}
+
+	streams := streamHandler.GetStreams()
+	output := ""
+	unreapable := 0
+	for _, s := range streams {
+		var reasons []string
+
+		var pinning, leaked []string
+		for _, id := range s.Metadata().ParentRefKeys() {
+			if rooms[id] {
+				pinning = append(pinning, html.EscapeString(id))
+			} else {
+				leaked = append(leaked, html.EscapeString(id))
+			}
+		}
+		if len(pinning) > 0 {
+			reasons = append(reasons, "pinned by room(s) "+strings.Join(pinning, ", "))
+		}
+		if len(leaked) > 0 {
+			reasons = append(reasons, "pinned by room(s) that no longer exist (leaked refs): "+strings.Join(leaked, ", "))
+		}
+		if len(reasons) == 0 {
+			idle := time.Since(s.Metadata().GetLastUpdated())
+			if idle <= stream.MaxStaleStreamDuration {
+				reasons = append(reasons, fmt.Sprintf("last used %v ago, not yet stale (%v)", idle.Truncate(time.Second), stream.MaxStaleStreamDuration))
+			}
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		unreapable++
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>%s</span>: %s", html.EscapeString(s.GetStreamURL()), strings.Join(reasons, "; "))
+
+		var labels []string
+		for _, key := range s.Metadata().LabelledRefKeys() {
+			ref, _ := s.Metadata().GetLabelledRef(key)
+			owner := ref.UUID()
+			if c, ok := ref.(*client.Client); ok {
+				owner = c.GetUsernameOrId()
+			}
+			labels = append(labels, fmt.Sprintf("%s in room %s", html.EscapeString(owner), html.EscapeString(key)))
+		}
+		if len(labels) > 0 {
+			output += "<br />&nbsp;&nbsp;queued by " + strings.Join(labels, ", ")
+		}
+	}
+
+	header := "Stream ref diagnostics:<br />"
+	header += fmt.Sprintf("<br /><span class='text-hl-name'>registered streams</span>: %v", len(streams))
+	header += fmt.Sprintf("<br /><span class='text-hl-name'>unreapable streams</span>: %v", unreapable)
+	if unreapable == 0 {
+		return header
+	}
+	return header + "<br />" + output
+}
+
 func NewCmdDebug() SocketCommand {
 	return &DebugCmd{
 		&Command{
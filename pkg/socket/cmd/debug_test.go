@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDebugTimers(t *testing.T) {
+	tests := []struct {
+		name          string
+		toggle        func(*testRoom) error
+		expectPlaying int
+	}{
+		{
+			name:   "stopped room",
+			toggle: func(r *testRoom) error { return r.playback.Stop() },
+		},
+		{
+			name:          "playing room",
+			toggle:        func(r *testRoom) error { return r.playback.Play() },
+			expectPlaying: 1,
+		},
+		{
+			name: "paused room",
+			toggle: func(r *testRoom) error {
+				if err := r.playback.Play(); err != nil {
+					return err
+				}
+				return r.playback.Pause()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "timers")
+			user, _ := room.join("alice")
+			if err := tc.toggle(room); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer room.playback.Stop()
+
+			result, err := room.exec(user, "/debug timers")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expect := range []string{
+				"rooms</span>: 1",
+				fmt.Sprintf("playing timers</span>: %v", tc.expectPlaying),
+			} {
+				if !strings.Contains(result, expect) {
+					t.Fatalf("expected %q in the timer diagnostics, got %q", expect, result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+)
+
+// machine-readable codes describing why a command failed,
+// sent to clients along with the command's error message
+const (
+	CMD_ERR_UNKNOWN           = "unknown"
+	CMD_ERR_NOT_FOUND         = "commandNotFound"
+	CMD_ERR_UNAUTHORIZED      = "unauthorized"
+	CMD_ERR_NOT_IN_ROOM       = "notInRoom"
+	CMD_ERR_NO_PLAYBACK       = "noPlayback"
+	CMD_ERR_QUEUE_FULL        = "queueFull"
+	CMD_ERR_USERNAME_REQUIRED = "usernameRequired"
+)
+
+// ErrNoPlayback is returned by commands run in a room with no playback
+var ErrNoPlayback = NewCmdError(CMD_ERR_NO_PLAYBACK, "error: no stream playback is currently loaded for your room")
+
+// CmdError is an error returned by a SocketCommand,
+// along with a code describing the kind of error
+type CmdError struct {
+	Code    string
+	Message string
+}
+
+func (e *CmdError) Error() string {
+	return e.Message
+}
+
+// NewCmdError returns a CmdError with the given code,
+// and a message formatted according to the given format
+func NewCmdError(code, format string, args ...interface{}) *CmdError {
+	return &CmdError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ErrorCode returns the code of the given error if it is
+// a CmdError, or CMD_ERR_UNKNOWN for any other error
+func ErrorCode(err error) string {
+	if cmdErr, ok := err.(*CmdError); ok {
+		return cmdErr.Code
+	}
+	return CMD_ERR_UNKNOWN
+}
+
+// queueFullError returns the given error from pushing to a user
+// queue as a CmdError if the user's queue, or the room's queue,
+// is full. Any other error is returned unchanged.
+func queueFullError(sPlayback *playback.Playback, err error) error {
+	switch err {
+	case queue.ErrMaxQueueSizeExceeded:
+		return NewCmdError(CMD_ERR_QUEUE_FULL, "%v", err)
+	case playback.ErrRoomQueueFull:
+		return NewCmdError(CMD_ERR_QUEUE_FULL, "error: this room cannot store more than %v items in its queue.", sPlayback.MaxQueueItems())
+	}
+	return err
+}
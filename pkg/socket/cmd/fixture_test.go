@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// fakeConn is a socket connection that records every message written to it
+type fakeConn struct {
+	connection.Connection
+
+	id        string
+	ns        string
+	nsHandler connection.NamespaceHandler
+	observer  bool
+
+	mux      sync.Mutex
+	messages [][]byte
+}
+
+func (c *fakeConn) UUID() string { return c.id }
+
+func (c *fakeConn) Send(data []byte) {
+	c.WriteMessage(0, data)
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.messages = append(c.messages, data)
+	return nil
+}
+
+func (c *fakeConn) Broadcast(ns, eventName string, data []byte) {
+	c.nsHandler.Broadcast(0, ns, eventName, data)
+}
+
+func (c *fakeConn) BroadcastFrom(ns, eventName string, data []byte) {
+	c.nsHandler.BroadcastFrom(0, c.id, ns, eventName, data)
+}
+
+func (c *fakeConn) Join(ns string) {
+	c.ns = ns
+	c.nsHandler.AddToNamespace(ns, c)
+}
+
+func (c *fakeConn) Leave(ns string) {
+	c.nsHandler.RemoveFromNamespace(ns, c)
+}
+
+func (c *fakeConn) Namespace() (connection.Namespace, bool) {
+	return c.nsHandler.NamespaceByName(c.ns)
+}
+
+func (c *fakeConn) Connections() []connection.Connection {
+	ns, exists := c.Namespace()
+	if !exists {
+		return []connection.Connection{}
+	}
+	return ns.Connections()
+}
+
+func (c *fakeConn) IsClosed() bool   { return false }
+func (c *fakeConn) IsObserver() bool { return c.observer }
+
+func (c *fakeConn) Request() *http.Request {
+	return &http.Request{
+		RemoteAddr: "127.0.0.1:8080",
+		URL:        &url.URL{},
+	}
+}
+
+func (c *fakeConn) Metadata() connection.ConnectionMetadata {
+	return connection.NewConnectionMetadata()
+}
+
+// Events returns the data of every event by the given name sent to the connection
+func (c *fakeConn) Events(eventName string) []client.Response {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	events := []client.Response{}
+	for _, m := range c.messages {
+		message := struct {
+			Event string          `json:"event"`
+			Data  client.Response `json:"data"`
+		}{}
+		if err := json.Unmarshal(m, &message); err != nil || message.Event != eventName {
+			continue
+		}
+		events = append(events, message.Data)
+	}
+	return events
+}
+
+// Reset discards the messages sent to the connection so far
+func (c *fakeConn) Reset() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.messages = nil
+}
+
+// testRoom is a room with its own handlers, joined by fake connections
+type testRoom struct {
+	t *testing.T
+
+	name            string
+	nsHandler       connection.NamespaceHandler
+	clientHandler   client.SocketClientHandler
+	playbackHandler playback.PlaybackHandler
+	streamHandler   stream.StreamHandler
+	cmdHandler      SocketCommandHandler
+	playback        *playback.Playback
+}
+
+func newTestRoom(t *testing.T, name string) *testRoom {
+	return newConfiguredTestRoom(t, name, nil)
+}
+
+// newConfiguredTestRoom creates a room like newTestRoom, once the given
+// func, if any, sets options on the playback handler creating the room
+func newConfiguredTestRoom(t *testing.T, name string, configure func(playback.PlaybackHandler)) *testRoom {
+	nsHandler := connection.NewNamespaceHandler()
+	clientHandler := client.NewHandler()
+	playbackHandler := playback.NewHandler(nsHandler)
+	if configure != nil {
+		configure(playbackHandler)
+	}
+
+	sPlayback, err := playbackHandler.NewPlayback(nsHandler.NewNamespace(name), nil, clientHandler)
+	if err != nil {
+		t.Fatalf("unable to create room %q: %v", name, err)
+	}
+
+	return &testRoom{
+		t:               t,
+		name:            name,
+		nsHandler:       nsHandler,
+		clientHandler:   clientHandler,
+		playbackHandler: playbackHandler,
+		streamHandler:   stream.NewHandler(),
+		cmdHandler:      NewHandler(),
+		playback:        sPlayback,
+	}
+}
+
+// sibling creates another room by the given name, served by the same
+// handlers as the room. Rooms are only bound to the same authorizer if
+// rbac is enabled for the room before the sibling is created.
+func (r *testRoom) sibling(name string) *testRoom {
+	sPlayback, err := r.playbackHandler.NewPlayback(r.nsHandler.NewNamespace(name), nil, r.clientHandler)
+	if err != nil {
+		r.t.Fatalf("unable to create room %q: %v", name, err)
+	}
+
+	sibling := *r
+	sibling.name = name
+	sibling.playback = sPlayback
+	return &sibling
+}
+
+// bind enables role-based access control for the room's
+// commands, if it is not yet enabled, and binds the given
+// clients to the role by the given name
+func (r *testRoom) bind(roleName string, users ...*client.Client) {
+	authorizer := r.cmdHandler.Authorizer()
+	if authorizer == nil {
+		authorizer = rbac.NewAuthorizer()
+		AddDefaultRoles(authorizer)
+		r.cmdHandler = NewHandlerWithRBAC(authorizer)
+	}
+
+	role, exists := authorizer.Role(roleName)
+	if !exists {
+		r.t.Fatalf("role %q not found", roleName)
+	}
+	for _, u := range users {
+		authorizer.Bind(role, u.Connection())
+	}
+}
+
+// join adds a client with the given username to the room
+func (r *testRoom) join(username string) (*client.Client, *fakeConn) {
+	return r.connect(username, false)
+}
+
+// observe adds an observer client with the given username to the room
+func (r *testRoom) observe(username string) (*client.Client, *fakeConn) {
+	return r.connect(username, true)
+}
+
+// reconnect adds a client with the given username to the room under a new
+// connection id, as when a client reconnects after a server restart
+func (r *testRoom) reconnect(username string) (*client.Client, *fakeConn) {
+	return r.connectWithId("new-"+username, username, false)
+}
+
+func (r *testRoom) connect(username string, observer bool) (*client.Client, *fakeConn) {
+	return r.connectWithId("id-"+username, username, observer)
+}
+
+func (r *testRoom) connectWithId(id, username string, observer bool) (*client.Client, *fakeConn) {
+	conn := &fakeConn{
+		id:        id,
+		nsHandler: r.nsHandler,
+		observer:  observer,
+	}
+
+	c := r.clientHandler.CreateClient(conn)
+	c.SetNamespace(r.name)
+	if err := c.UpdateUsername(username); err != nil {
+		r.t.Fatalf("unable to set username %q: %v", username, err)
+	}
+	return c, conn
+}
+
+// exec runs a command, given as it would be typed in chat, as the given client
+func (r *testRoom) exec(user *client.Client, command string) (string, error) {
+	segs := strings.Split(strings.TrimPrefix(command, "/"), " ")
+	return r.cmdHandler.ExecuteCommand(segs[0], segs[1:], user, r.clientHandler, r.playbackHandler, r.streamHandler)
+}
+
+// setStream loads a remote video stream with the given url in the room
+func (r *testRoom) setStream(streamUrl string) stream.Stream {
+	s := stream.NewRemoteVideoStream(streamUrl)
+	r.playback.SetStream(s)
+	return s
+}
+
+// enqueue pushes remote video streams with the given urls to the user's queue
+func (r *testRoom) enqueue(user *client.Client, urls ...string) {
+	userQueue, exists, err := playbackutil.GetUserQueue(user, r.playback.GetQueue())
+	if err != nil {
+		r.t.Fatalf("unexpected error: %v", err)
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		if err := r.playback.GetQueue().Push(userQueue); err != nil {
+			r.t.Fatalf("unable to create queue for %q: %v", user.GetUsernameOrId(), err)
+		}
+	}
+
+	for _, u := range urls {
+		if _, err := r.playback.PushToQueue(userQueue, stream.NewRemoteVideoStream(u)); err != nil {
+			r.t.Fatalf("unable to queue %q: %v", u, err)
+		}
+	}
+}
+
+// streamUrl returns the url of the stream in the playback status sent with an event
+func streamUrl(res client.Response) string {
+	s, _ := res.Extra["stream"].(map[string]interface{})
+	u, _ := s["url"].(string)
+	return u
+}
@@ -74,7 +74,7 @@ func (h *Handler) Aliases() map[string]SocketCommand {
 func (h *Handler) ExecuteCommand(cmdRoot string, args []string, client *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
 	command, exists := resolveCommandAlias(cmdRoot, h.commands, h.aliases)
 	if !exists {
-		return "", fmt.Errorf("error: that command does not exist")
+		return "", NewCmdError(CMD_ERR_NOT_FOUND, "error: that command does not exist")
 	}
 
 	return command.Execute(h, args, client, clientHandler, playbackHandler, streamHandler)
@@ -109,7 +109,7 @@ func (c *HandlerWithRBAC) Authorizer() rbac.Authorizer {
 func (c *HandlerWithRBAC) ExecuteCommand(cmdRoot string, args []string, client *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
 	command, exists := resolveCommandAlias(cmdRoot, c.Commands(), c.Aliases())
 	if !exists {
-		return "", fmt.Errorf("error: that command does not exist")
+		return "", NewCmdError(CMD_ERR_NOT_FOUND, "error: that command does not exist")
 	}
 
 	action := util.CommandAction(command.Name(), args)
@@ -117,7 +117,7 @@ func (c *HandlerWithRBAC) ExecuteCommand(cmdRoot string, args []string, client *
 	rule, exists := rbac.RuleByAction(c.AccessController.Bindings(), action)
 	if !exists {
 		log.Printf("ERR SOCKET CMD AUTHZ unable to find rule for action %q for client %q with id (%s)", action, client.GetUsernameOrId(), client.UUID())
-		return "", fmt.Errorf("error: unable to authorize the requested command\n%s", command.GetUsage())
+		return "", NewCmdError(CMD_ERR_UNAUTHORIZED, "error: unable to authorize the requested command\n%s", command.GetUsage())
 	}
 
 	if c.AccessController.Verify(client.Connection(), rule) {
@@ -125,7 +125,7 @@ func (c *HandlerWithRBAC) ExecuteCommand(cmdRoot string, args []string, client *
 	}
 
 	log.Printf("ERR SOCKET CMD AUTHZ client %q with id (%s) has attempted to perform unauthorized action: %q", client.GetUsernameOrId(), client.UUID(), action)
-	return "", fmt.Errorf("error: you are not authorized to perform that command")
+	return "", NewCmdError(CMD_ERR_UNAUTHORIZED, "error: you are not authorized to perform that command")
 }
 
 // NewControlledHandler returns a command handler capable
@@ -144,9 +144,17 @@ func addSocketCommands(handler SocketCommandHandler) {
 	handler.AddCommand(NewCmdClear())
 	handler.AddCommand(NewCmdDebug())
 	handler.AddCommand(NewCmdHelp())
+	handler.AddCommand(NewCmdHistory())
 	handler.AddCommand(NewCmdStream())
 	handler.AddCommand(NewCmdSubtitles())
+	handler.AddCommand(NewCmdLyrics())
+	handler.AddCommand(NewCmdMute())
+	handler.AddCommand(NewCmdNowPlaying())
+	handler.AddCommand(NewCmdPlaylist())
 	handler.AddCommand(NewCmdQueue())
+	handler.AddCommand(NewCmdRoom())
+	handler.AddCommand(NewCmdServer())
+	handler.AddCommand(NewCmdUnmute())
 	handler.AddCommand(NewCmdUser())
 	handler.AddCommand(NewCmdVolume())
 	handler.AddCommand(NewCmdWhoami())
@@ -164,13 +172,49 @@ func resolveCommandAlias(cmdRoot string, commands, aliases map[string]SocketComm
 func AddDefaultRoles(authz rbac.Authorizer) {
 	// default rules
 	clearChat := rbac.NewRule("clear the chat", []string{"clear"})
+	clearChatSelf := rbac.NewRule("clear your own chat window", []string{"clear/me"})
+	chatMute := rbac.NewRule("mute or unmute a user's chat messages", []string{
+		"mute/*",
+		"unmute/*",
+	})
 	debugReload := rbac.NewRule("reload all clients", []string{
 		"debug/reload",
 		"debug/refresh",
 	})
+	debugTimers := rbac.NewRule("report playback timer and goroutine diagnostics", []string{
+		"debug/timers",
+	})
+	debugRefs := rbac.NewRule("list the registered streams that cannot be reaped, and why", []string{
+		"debug/refs",
+	})
 	help := rbac.NewRule("access command help", []string{"help"})
 	streamInfo := rbac.NewRule("access stream info", []string{"stream/info"})
-	streamControl := rbac.NewRule("play/pause/skip/reset/load the stream", []string{
+	streamState := rbac.NewRule("describe the room's playback state", []string{"stream/state"})
+	streamMeta := rbac.NewRule("inspect the current stream's internal metadata", []string{"stream/meta"})
+	streamInspect := rbac.NewRule("resolve a stream and fetch its metadata for debugging", []string{"stream/inspect"})
+	streamMirror := rbac.NewRule("have the room mirror another room's playback", []string{
+		"stream/mirror",
+		"stream/mirror/*",
+	})
+	streamPlayUser := rbac.NewRule("play a user's next queued stream right away", []string{
+		"stream/playuser",
+		"stream/playuser/*",
+	})
+	streamPrepare := rbac.NewRule("fetch a stream's metadata before loading it", []string{"stream/prepare"})
+	streamHistory := rbac.NewRule("list the room's play history", []string{"stream/history"})
+	history := rbac.NewRule("list the streams recently played in the room", []string{
+		"history",
+		"history/*",
+	})
+	historyClear := rbac.NewRule("clear the room's play history", []string{
+		"history/clear",
+	})
+	streamHistoryPlay := rbac.NewRule("replay or go back to a stream from the room's play history", []string{
+		"stream/history/play",
+		"stream/history/play/*",
+		"stream/previous",
+	})
+	streamControl := rbac.NewRule("play/pause/skip/reset/load/resync the stream", []string{
 		"stream/play",
 		"stream/skip",
 		"stream/load",
@@ -178,6 +222,10 @@ func AddDefaultRoles(authz rbac.Authorizer) {
 		"stream/pause",
 		"stream/stop",
 		"stream/seek",
+		"stream/resync",
+		"stream/mode",
+		"stream/hold",
+		"stream/resume",
 	})
 	subtitles := rbac.NewRule("control stream subtitles", []string{
 		"subs",
@@ -185,17 +233,35 @@ func AddDefaultRoles(authz rbac.Authorizer) {
 		"subtitles/*",
 		"subs/*",
 	})
+	lyrics := rbac.NewRule("control stream lyrics", []string{
+		"lyrics",
+		"lyrics/*",
+	})
 	queueAdd := rbac.NewRule("add streams to the queue", []string{
 		"queue/add/*",
+		"queue/requeue",
 	})
 	queueList := rbac.NewRule("list items in the queue", []string{
 		"queue/list/*",
 	})
+	playlist := rbac.NewRule("add the videos in a YouTube playlist to your queue", []string{
+		"playlist/*",
+	})
+	queueSources := rbac.NewRule("count the room's queued streams by provider", []string{
+		"queue/sources",
+	})
+	queueListUser := rbac.NewRule("list the items in another user's queue", []string{
+		"queue/list/user/*",
+	})
+	queueListPrivate := rbac.NewRule("list the items in another user's queue, or in the room's queue, in rooms with private queues", []string{
+		QUEUE_LIST_PRIVATE_ACTION,
+	})
 	queueClearMine := rbac.NewRule("clear items in your queue", []string{
 		"queue/clear/mine",
 		"queue/clear/mine/*",
 		"queue/clear/me",
 		"queue/clear/me/*",
+		"queue/remove/*",
 	})
 	queueClearRoom := rbac.NewRule("clear items in the room's queue", []string{
 		"queue/clear/room",
@@ -208,6 +274,7 @@ func AddDefaultRoles(authz rbac.Authorizer) {
 		"queue/order/mine/*",
 		"queue/order/me",
 		"queue/order/me/*",
+		"queue/shuffle/mine",
 	})
 	queueOrderRoom := rbac.NewRule("re-order items in the room's queue", []string{
 		"queue/order/room",
@@ -215,59 +282,237 @@ func AddDefaultRoles(authz rbac.Authorizer) {
 		"queue/order/all",
 		"queue/order/all/*",
 		"queue/order/next/*",
+		"queue/balance",
+		"queue/shuffle/room",
 	})
 	roleEdit := rbac.NewRule("Add, replace, or remove roles for a subject", []string{
 		"role/set/*",
 		"role/add/*",
 		"role/remove/*",
 	})
+	roleGrant := rbac.NewRule("temporarily grant a subject control of stream playback", []string{
+		"role/grant/*",
+	})
+	roleTest := rbac.NewRule("test which rule authorizes an action", []string{
+		"role/test/*",
+	})
+	roomInvite := rbac.NewRule("display an invite link for the room", []string{
+		"room/invite",
+	})
+	roomAdmins := rbac.NewRule("list the room's admins", []string{
+		"room/admins",
+	})
+	roomAutoload := rbac.NewRule("toggle whether new joiners load the room's current stream", []string{
+		"room/autoload",
+		"room/autoload/*",
+	})
+	roomPrivateQueues := rbac.NewRule("toggle whether only admins can list another user's queue", []string{
+		"room/privatequeues",
+		"room/privatequeues/*",
+	})
+	roomAdvance := rbac.NewRule("require an admin to be present for the room's queue to auto-advance", []string{
+		"room/advanceneedsadmin",
+		"room/advanceneedsadmin/*",
+	})
+	roomMaxPlay := rbac.NewRule("set the maximum amount of time any stream may play in the room", []string{
+		"room/maxplay",
+		"room/maxplay/*",
+	})
+	roomLoadTimeout := rbac.NewRule("skip streams that fail to start within a set amount of time", []string{
+		"room/loadtimeout",
+		"room/loadtimeout/*",
+	})
+	roomUserLimit := rbac.NewRule("set the maximum amount of items each user may queue in the room", []string{
+		"room/userlimit",
+		"room/userlimit/*",
+	})
+	roomReset := rbac.NewRule("reset the room's stream, queue, and play history, keeping its users and settings", []string{
+		"room/reset",
+	})
+	roomReport := rbac.NewRule("export a summary of the room's session", []string{
+		"room/report",
+	})
+	roomModerate := rbac.NewRule("require admin approval for streams added to the room's queue", []string{
+		"room/moderate",
+		"room/moderate/*",
+	})
+	roomOnEmpty := rbac.NewRule("choose what the room does once its queue runs out", []string{
+		"room/onempty",
+		"room/onempty/*",
+	})
+	roomLog := rbac.NewRule("list the commands most recently run in the room", []string{
+		"room/log",
+		"room/log/*",
+	})
+	roomMirroring := rbac.NewRule("allow other rooms to mirror the room's playback", []string{
+		"room/mirroring",
+		"room/mirroring/*",
+	})
+	roomNoSeekAhead := rbac.NewRule("prevent clients from seeking ahead of the room", []string{
+		"room/noseekahead",
+		"room/noseekahead/*",
+	})
+	roomReapStatus := rbac.NewRule("display when the room will be reaped", []string{
+		"room/reapstatus",
+	})
+	roomKeepAlive := rbac.NewRule("postpone reaping the room", []string{
+		"room/keepalive",
+	})
+	roomWelcome := rbac.NewRule("update the room's welcome message", []string{
+		"room/welcome",
+		"room/welcome/*",
+	})
+	roomFilters := rbac.NewRule("block or allow domains and videos in the room", []string{
+		"room/block/*",
+		"room/allow/*",
+		"room/filters",
+	})
+	serverStatus := rbac.NewRule("view the server's current load", []string{
+		"server/status",
+	})
+	serverUptime := rbac.NewRule("view the server's uptime and the rooms created and reaped since it started", []string{
+		"server/uptime",
+	})
 	userUpdateName := rbac.NewRule("update a client's username", []string{
 		"user/name/*",
 	})
+	userCommandAcks := rbac.NewRule("toggle acknowledgements for commands that succeed without output", []string{
+		"user/acks",
+		"user/acks/*",
+	})
+	userInlineMedia := rbac.NewRule("toggle whether image links in your messages are shown inline", []string{
+		"user/inlinemedia",
+		"user/inlinemedia/*",
+	})
 	userList := rbac.NewRule("list users in a room", []string{
 		"user/list",
 	})
 	volume := rbac.NewRule("update your volume", []string{
 		"volume/*",
 	})
+	volumeDefault := rbac.NewRule("update the room's default volume", []string{
+		"volume/default",
+		"volume/default/*",
+	})
+	nowPlaying := rbac.NewRule("display the current stream and who queued it", []string{
+		"nowplaying",
+	})
 	whoami := rbac.NewRule("list your current username", []string{
 		"whoami",
 	})
 
+	queueStrict := rbac.NewRule("toggle whether re-adding a queued stream is an error", []string{
+		"queue/strict",
+		"queue/strict/*",
+	})
+	queueModerate := rbac.NewRule("review streams users have requested to add to the queue in moderated rooms", []string{
+		"queue/requests",
+		QUEUE_APPROVE_ACTION,
+		"queue/approve/*",
+		"queue/deny/*",
+	})
+	queueMode := rbac.NewRule("toggle between a round-robin and a shared queue", []string{
+		"queue/mode",
+		"queue/mode/*",
+	})
 	queueMigrate := rbac.NewRule("migrate a user's queue to yours", []string{
 		"queue/migrate/*",
 	})
+	queueSlots := rbac.NewRule("save the room's queue to a named slot, or load it back", []string{
+		"queue/save/*",
+		"queue/load",
+		"queue/load/*",
+	})
+	queueMerge := rbac.NewRule("merge a user's queue into another user's queue", []string{
+		"queue/merge/*",
+	})
 
 	// default roles
 	viewerRole := rbac.NewRole(rbac.VIEWER_ROLE, []rbac.Rule{
+		clearChatSelf,
 		help,
+		history,
+		nowPlaying,
 		streamInfo,
+		streamState,
+		streamHistory,
 		queueList,
+		queueListUser,
+		queueSources,
+		roomAdmins,
+		roomInvite,
+		roomReapStatus,
+		userCommandAcks,
+		userInlineMedia,
 		userList,
 		volume,
 		whoami,
 	})
 	userRole := rbac.NewRole(rbac.USER_ROLE, append([]rbac.Rule{
 		clearChat,
+		playlist,
 		queueAdd,
 		queueClearMine,
 		queueOrderMine,
 		userUpdateName,
 	}, viewerRole.Rules()...))
 	adminRole := rbac.NewRole(rbac.ADMIN_ROLE, append([]rbac.Rule{
+		chatMute,
+		debugRefs,
 		debugReload,
+		debugTimers,
+		historyClear,
+		lyrics,
 		subtitles,
 		queueClearRoom,
+		queueListPrivate,
+		queueMerge,
 		queueMigrate,
+		queueMode,
+		queueModerate,
 		queueOrderRoom,
+		queueSlots,
+		queueStrict,
 		roleEdit,
+		roleGrant,
+		roleTest,
+		roomAdvance,
+		roomAutoload,
+		roomFilters,
+		roomKeepAlive,
+		roomLoadTimeout,
+		roomLog,
+		roomMaxPlay,
+		roomMirroring,
+		roomModerate,
+		roomNoSeekAhead,
+		roomOnEmpty,
+		roomPrivateQueues,
+		roomReport,
+		roomReset,
+		roomUserLimit,
+		roomWelcome,
+		serverStatus,
+		serverUptime,
 		streamControl,
+		streamHistoryPlay,
+		streamMeta,
+		streamInspect,
+		streamMirror,
+		streamPlayUser,
+		streamPrepare,
+		volumeDefault,
 	}, userRole.Rules()...))
+	// granted temporarily, in addition to a subject's other roles
+	djRole := rbac.NewRole(rbac.DJ_ROLE, []rbac.Rule{
+		streamControl,
+	})
 
 	roles := []rbac.Role{
 		viewerRole,
 		userRole,
 		adminRole,
+		djRole,
 	}
 
 	for _, role := range roles {
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type HistoryCmd struct {
+	*Command
+}
+
+const (
+	HISTORY_NAME        = "history"
+	HISTORY_DESCRIPTION = "lists the streams recently played in the room, so that they can be queued again"
+	HISTORY_USAGE       = "Usage: /" + HISTORY_NAME + " [count|clear]"
+
+	// HISTORY_DEFAULT_COUNT is the amount of streams listed by default
+	HISTORY_DEFAULT_COUNT = 10
+)
+
+func (h *HistoryCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to use this command")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		return "", ErrNoPlayback
+	}
+
+	count := HISTORY_DEFAULT_COUNT
+	if len(args) > 0 {
+		if args[0] == "clear" {
+			sPlayback.ClearHistory()
+
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has cleared the room's play history", user.GetUsernameOrId()))
+			return "the room's play history has been cleared", nil
+		}
+
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("error: the amount of streams to list must be a positive number")
+		}
+		count = n
+	}
+
+	history := sPlayback.History()
+	if len(history) == 0 {
+		return "No streams have been played in this room yet.", nil
+	}
+	if count > len(history) {
+		count = len(history)
+	}
+
+	output := fmt.Sprintf("Last %v streams played in this room:<br />", count)
+	for _, entry := range history[:count] {
+		output += fmt.Sprintf("<br />[%s] <span class='text-hl-name'>%s</span>: %s (started by %s)", entry.PlayedAt.Format("15:04:05"), html.EscapeString(entry.Name()), html.EscapeString(entry.Url), html.EscapeString(entry.StartedBy))
+	}
+	output += "<br /><br />Queue a stream again with \"/" + QUEUE_NAME + " add &lt;url&gt;\"."
+	return output, nil
+}
+
+func NewCmdHistory() SocketCommand {
+	return &HistoryCmd{
+		&Command{
+			name:        HISTORY_NAME,
+			description: HISTORY_DESCRIPTION,
+			usage:       HISTORY_USAGE,
+		},
+	}
+}
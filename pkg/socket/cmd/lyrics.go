@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/socket/util"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type LyricsCmd struct {
+	*Command
+}
+
+const (
+	LYRICS_NAME        = "lyrics"
+	LYRICS_DESCRIPTION = "displays time-synced lyrics for the current stream on every client"
+	LYRICS_USAGE       = "Usage: /" + LYRICS_NAME + " &lt;(off|path/to/lyrics.lrc)&gt;"
+
+	LYRICS_FILE_ROOT = "/webclient/src/static/lyrics/"
+)
+
+var (
+	lyrics_aliases = []string{}
+)
+
+func (h *LyricsCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	username, hasUsername := user.GetUsername()
+	if !hasUsername {
+		username = user.UUID()
+	}
+
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		log.Printf("SOCKET CLIENT ERR client with id %q attempted to control stream lyrics with no room assigned", user.UUID())
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a stream to control stream lyrics")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		log.Printf("SOCKET CLIENT ERR unable to associate client %q (%s) in room %q with any stream playback objects", user.UUID(), username, userRoom.Name())
+		return "", ErrNoPlayback
+	}
+
+	currentDir := util.GetCurrentDirectory()
+	lyricsRootDir := path.Join(currentDir, "/../../", LYRICS_FILE_ROOT)
+
+	lyricsFilepath := ""
+	if len(args) == 0 {
+		lyricsFilepath = findLyricsFilepathGivenCurrentNamespace(playbackHandler, userRoom, lyricsRootDir)
+		if len(lyricsFilepath) == 0 {
+			return "", fmt.Errorf("error: no lyrics found for the current stream")
+		}
+	} else if args[0] == "off" {
+		sPlayback.ClearLyrics()
+		user.BroadcastAll("info_lyrics", &client.Response{
+			Id:   user.UUID(),
+			From: username,
+			Extra: map[string]interface{}{
+				"on": false,
+			},
+		})
+
+		user.BroadcastSystemMessageAll(fmt.Sprintf("%q has requested to remove lyrics from the stream", username))
+		return "attempting to remove lyrics from the stream...", nil
+	} else {
+		lyricsFilepath = path.Join(lyricsRootDir, args[0])
+		if !strings.HasPrefix(lyricsFilepath, lyricsRootDir+"/") {
+			return "", fmt.Errorf("error: lyrics files must be located in the lyrics directory")
+		}
+	}
+
+	f, err := os.Open(lyricsFilepath)
+	if err != nil {
+		log.Printf("SOCKET CLIENT ERR unable to load lyrics file for stream %q: %v", userRoom, err)
+		return "", fmt.Errorf("error: missing lyrics file for current stream")
+	}
+	defer f.Close()
+
+	log.Printf("SOCKET CLIENT INFO attempting to load lyrics file %q\n", lyricsFilepath)
+
+	lines, err := playback.ParseLRC(f)
+	if err != nil {
+		log.Printf("SOCKET CLIENT ERR unable to parse lyrics file %q: %v", lyricsFilepath, err)
+		return "", fmt.Errorf("error: unable to parse lyrics file: %v", err)
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("error: lyrics file contains no timed lines")
+	}
+
+	clientRelativeLyricsFilepath := strings.Split(lyricsFilepath, "/webclient/")
+	if len(clientRelativeLyricsFilepath) < 2 {
+		return "", fmt.Errorf("error: unable to parse client-relative lyrics URL")
+	}
+
+	clientLyricsPath := path.Join("/", clientRelativeLyricsFilepath[1])
+	sPlayback.SetLyrics(clientLyricsPath, lines)
+
+	user.BroadcastAll("info_lyrics", &client.Response{
+		Id:   user.UUID(),
+		From: username,
+		Extra: map[string]interface{}{
+			"path":  clientLyricsPath,
+			"lines": lines,
+			"on":    true,
+		},
+	})
+
+	user.BroadcastSystemMessageAll(fmt.Sprintf("%q has requested to add lyrics to the stream", username))
+	return "attempting to add lyrics to the stream...", nil
+}
+
+// findLyricsFilepathGivenCurrentNamespace lists all lyrics files in the LYRICS_FILE_ROOT and attempts
+// to find the first file whose name matches the current stream's URL, or its name, minus its extension
+func findLyricsFilepathGivenCurrentNamespace(playbackHandler playback.PlaybackHandler, userRoom connection.Namespace, lyricsRootDir string) string {
+	dir, err := os.Stat(lyricsRootDir)
+	if err != nil || !dir.IsDir() {
+		return ""
+	}
+
+	files, err := ioutil.ReadDir(lyricsRootDir)
+	if err != nil {
+		return ""
+	}
+
+	validFilenames := []string{}
+	for _, file := range files {
+		if path.Ext(file.Name()) != ".lrc" {
+			continue
+		}
+
+		validFilenames = append(validFilenames, file.Name())
+	}
+
+	if len(validFilenames) == 0 {
+		return ""
+	}
+
+	nsPlayback, exists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !exists {
+		return ""
+	}
+
+	currentStream, exists := nsPlayback.GetStream()
+	if !exists {
+		return ""
+	}
+
+	streamURLWithNoExt := strings.TrimSuffix(currentStream.GetStreamURL(),
+		path.Ext(currentStream.GetStreamURL()))
+
+	candidates := map[string]bool{
+		streamURLWithNoExt:            true,
+		path.Base(streamURLWithNoExt): true,
+	}
+	if name := currentStream.GetName(); len(name) > 0 {
+		candidates[name] = true
+	}
+
+	for _, validLyricsFilename := range validFilenames {
+		lyricsFilenameWithNoExt := strings.TrimSuffix(validLyricsFilename,
+			path.Ext(validLyricsFilename))
+
+		if !candidates[lyricsFilenameWithNoExt] {
+			continue
+		}
+
+		return path.Join(lyricsRootDir, validLyricsFilename)
+	}
+
+	return ""
+}
+
+func NewCmdLyrics() SocketCommand {
+	return &LyricsCmd{
+		&Command{
+			name:        LYRICS_NAME,
+			description: LYRICS_DESCRIPTION,
+			usage:       LYRICS_USAGE,
+
+			aliases: lyrics_aliases,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type MuteCmd struct {
+	*Command
+}
+
+type UnmuteCmd struct {
+	*Command
+}
+
+const (
+	MUTE_NAME        = "mute"
+	MUTE_DESCRIPTION = "silences a user's chat messages in the room, without removing them"
+	MUTE_USAGE       = "Usage: /" + MUTE_NAME + " &lt;username&gt;"
+
+	UNMUTE_NAME        = "unmute"
+	UNMUTE_DESCRIPTION = "allows a muted user to chat in the room again"
+	UNMUTE_USAGE       = "Usage: /" + UNMUTE_NAME + " &lt;username&gt;"
+)
+
+func (h *MuteCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) == 0 {
+		return h.usage, nil
+	}
+
+	sPlayback, target, err := muteTarget(args[0], user, clientHandler, playbackHandler)
+	if err != nil {
+		return "", err
+	}
+	if target.UUID() == user.UUID() {
+		return "", fmt.Errorf("error: you cannot mute yourself")
+	}
+
+	if !sPlayback.Mute(target.UUID()) {
+		return "", fmt.Errorf("error: %q is already muted", args[0])
+	}
+
+	target.BroadcastSystemMessageTo("You have been muted. Your chat messages will not be seen by the room.")
+	return fmt.Sprintf("%q has been muted. Use /%s %s to let them chat again.", args[0], UNMUTE_NAME, args[0]), nil
+}
+
+func (h *UnmuteCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) == 0 {
+		return h.usage, nil
+	}
+
+	sPlayback, target, err := muteTarget(args[0], user, clientHandler, playbackHandler)
+	if err != nil {
+		return "", err
+	}
+
+	if !sPlayback.Unmute(target.UUID()) {
+		return "", fmt.Errorf("error: %q is not muted", args[0])
+	}
+
+	target.BroadcastSystemMessageTo("You have been unmuted and may chat again.")
+	return fmt.Sprintf("%q has been unmuted.", args[0]), nil
+}
+
+// muteTarget returns the user's room playback, and
+// the client with the given username in the user's room
+func muteTarget(username string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler) (*playback.Playback, *client.Client, error) {
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return nil, nil, NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to mute or unmute users")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		return nil, nil, ErrNoPlayback
+	}
+
+	target, exists := findSubjectByName(user, clientHandler, username)
+	if !exists {
+		return nil, nil, fmt.Errorf("error: no user named %q was found in your room", username)
+	}
+
+	return sPlayback, target, nil
+}
+
+func NewCmdMute() SocketCommand {
+	return &MuteCmd{
+		&Command{
+			name:        MUTE_NAME,
+			description: MUTE_DESCRIPTION,
+			usage:       MUTE_USAGE,
+		},
+	}
+}
+
+func NewCmdUnmute() SocketCommand {
+	return &UnmuteCmd{
+		&Command{
+			name:        UNMUTE_NAME,
+			description: UNMUTE_DESCRIPTION,
+			usage:       UNMUTE_USAGE,
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type NowPlayingCmd struct {
+	*Command
+}
+
+const (
+	NOWPLAYING_NAME        = "nowplaying"
+	NOWPLAYING_DESCRIPTION = "displays the current stream, how far into it the room is, and who queued it"
+	NOWPLAYING_USAGE       = "Usage: /" + NOWPLAYING_NAME
+)
+
+var (
+	nowplaying_aliases = []string{"np"}
+)
+
+func (h *NowPlayingCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to use this command")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		return "", ErrNoPlayback
+	}
+
+	s, exists := sPlayback.GetStream()
+	if !exists {
+		return "Nothing is playing right now. Add a stream with \"/" + QUEUE_NAME + " add &lt;url&gt;\".", nil
+	}
+
+	name := s.GetName()
+	if len(name) == 0 {
+		name = s.GetStreamURL()
+	}
+
+	elapsed := formatPlayTime(time.Duration(sPlayback.GetTime()) * time.Second)
+	duration := "--:--"
+	if s.GetDuration() > 0 {
+		duration = formatPlayTime(time.Duration(s.GetDuration() * float64(time.Second)))
+	}
+
+	output := fmt.Sprintf("Now playing: %s [%s / %s]", html.EscapeString(name), elapsed, duration)
+	if status, ok := sPlayback.GetStatus().(*playback.PlaybackStatus); ok {
+		queuedBy := status.StartedBy
+		if len(queuedBy) == 0 {
+			queuedBy = status.CreatedBy
+		}
+		if len(queuedBy) > 0 {
+			output += fmt.Sprintf(", queued by %s", html.EscapeString(queuedBy))
+		}
+	}
+	return output, nil
+}
+
+func NewCmdNowPlaying() SocketCommand {
+	return &NowPlayingCmd{
+		&Command{
+			name:        NOWPLAYING_NAME,
+			description: NOWPLAYING_DESCRIPTION,
+			usage:       NOWPLAYING_USAGE,
+
+			aliases: nowplaying_aliases,
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/juanvallejo/streaming-server/pkg/api/endpoint"
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	sockutil "github.com/juanvallejo/streaming-server/pkg/socket/util"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type PlaylistCmd struct {
+	*Command
+}
+
+const (
+	PLAYLIST_NAME        = "playlist"
+	PLAYLIST_DESCRIPTION = "adds the videos in a YouTube playlist to your queue"
+	PLAYLIST_USAGE       = "Usage: /" + PLAYLIST_NAME + " &lt;playlistId|playlistUrl&gt;"
+)
+
+func (h *PlaylistCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) == 0 {
+		return h.usage, nil
+	}
+
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to use this command")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		return "", ErrNoPlayback
+	}
+
+	if sPlayback.Moderated() && !authorizedFor(cmdHandler, user, QUEUE_APPROVE_ACTION) {
+		return "", fmt.Errorf("error: this room is moderated. Request streams one at a time with \"/%s add &lt;url&gt;\"", QUEUE_NAME)
+	}
+
+	// the playlist is fetched in the background, so that a slow response
+	// from the youtube api does not hold up the client's other messages
+	listId := playlistIdFromArg(args[0])
+	go importPlaylist(user, listId, sPlayback, streamHandler)
+
+	return fmt.Sprintf("fetching playlist %q...", listId), nil
+}
+
+// importPlaylist fetches the youtube playlist with the given id and adds its
+// videos to the user's queue, sending the user the result once it is done
+func importPlaylist(user *client.Client, listId string, sPlayback *playback.Playback, streamHandler stream.StreamHandler) {
+	output, err := queuePlaylist(user, listId, sPlayback, streamHandler)
+	if err != nil {
+		user.BroadcastErrorTo(err)
+		return
+	}
+	user.BroadcastSystemMessageTo(output)
+}
+
+// queuePlaylist fetches the youtube playlist with the given id, adds
+// its videos to the user's queue, and returns a summary of the result
+func queuePlaylist(user *client.Client, listId string, sPlayback *playback.Playback, streamHandler stream.StreamHandler) (string, error) {
+	playlist, err := endpoint.FetchYoutubePlaylist(listId)
+	if err != nil {
+		return "", fmt.Errorf("error: unable to retrieve playlist %q: %v", listId, err)
+	}
+	if len(playlist.Items) == 0 {
+		return "", fmt.Errorf("error: playlist %q has no videos, or could not be found", listId)
+	}
+
+	userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		if err := sPlayback.GetQueue().Push(userQueue); err != nil {
+			return "", err
+		}
+	}
+
+	added := 0
+	skipped := 0
+	full := false
+	for _, item := range playlist.Items {
+		if sPlayback.UserQueueFull(userQueue) {
+			full = true
+			break
+		}
+
+		s, err := sPlayback.GetOrCreateStreamFromUrl(item.Url, user, streamHandler, func(data []byte, created bool, err error) {})
+		if err != nil {
+			log.Printf("INF SOCKET CLIENT skipping %q while importing playlist %q: %v", item.Url, listId, err)
+			skipped++
+			continue
+		}
+
+		if _, err := sPlayback.PushToQueue(userQueue, s); err != nil {
+			log.Printf("INF SOCKET CLIENT skipping %q while importing playlist %q: %v", item.Url, listId, err)
+			skipped++
+			continue
+		}
+		added++
+	}
+
+	if userQueue.Size() == 0 {
+		sPlayback.GetQueue().DeleteItem(userQueue)
+	}
+
+	// sync clients once, rather than once per imported video
+	if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+		return "", err
+	}
+	if err := sendUserQueueSyncEvent(user, sPlayback); err != nil {
+		return "", err
+	}
+
+	if added > 0 {
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has added %v videos from a playlist to the queue", user.GetUsernameOrId(), added))
+	}
+
+	output := fmt.Sprintf("added %v of %v videos from playlist %q to your queue", added, len(playlist.Items), listId)
+	if skipped > 0 {
+		output += fmt.Sprintf(" (skipped %v that could not be queued)", skipped)
+	}
+	if full {
+		output += fmt.Sprintf(". Your queue is full: the remaining %v videos were not added", len(playlist.Items)-added-skipped)
+	}
+
+	if added > 0 && (sPlayback.State() == playback.PLAYBACK_STATE_ENDED || sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED) {
+		if err := autoPlayQueue(user, sPlayback); err != nil {
+			return fmt.Sprintf("%s - The queue will not auto-play due to an error: %v", output, err), nil
+		}
+		output += " (auto-playing...)"
+	}
+	return output, nil
+}
+
+// autoPlayQueue loads and plays the next stream in the room's queue
+func autoPlayQueue(user *client.Client, sPlayback *playback.Playback) error {
+	if _, err := sPlayback.AdvanceQueue(StreamLoadBroadcaster(user, user.GetUsernameOrId(), sPlayback)); err != nil {
+		return err
+	}
+
+	if err := sPlayback.Play(); err != nil {
+		return err
+	}
+
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+	}
+	if err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra); err != nil {
+		return err
+	}
+
+	user.BroadcastAll("streamsync", res)
+	return nil
+}
+
+// playlistIdFromArg receives a youtube playlist id, or a url
+// containing one in its "list" parameter, and returns the id
+func playlistIdFromArg(arg string) string {
+	u, err := url.Parse(arg)
+	if err != nil {
+		return arg
+	}
+	if listId := u.Query().Get("list"); len(listId) > 0 {
+		return listId
+	}
+	return arg
+}
+
+func NewCmdPlaylist() SocketCommand {
+	return &PlaylistCmd{
+		&Command{
+			name:        PLAYLIST_NAME,
+			description: PLAYLIST_DESCRIPTION,
+			usage:       PLAYLIST_USAGE,
+		},
+	}
+}
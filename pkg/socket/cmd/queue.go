@@ -3,14 +3,20 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
+	"math/rand"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
 	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
 	sockutil "github.com/juanvallejo/streaming-server/pkg/socket/util"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
@@ -22,11 +28,33 @@ type QueueCmd struct {
 const (
 	QUEUE_NAME        = "queue"
 	QUEUE_DESCRIPTION = "control the room queue"
-	QUEUE_USAGE       = "Usage: /" + QUEUE_NAME + " (migrate &lt;newQueueKey&gt;|add &lt;url&gt;|clear &lt;room|mine [url]&gt;|list &lt;mine|room&gt;|order &lt;next &lt;url&gt;|mine &lt;url newposition|0,1,2...&gt;|room &lt; url newposition|0,1,2...&gt;&gt;)"
+	QUEUE_USAGE       = "Usage: /" + QUEUE_NAME + " (save &lt;name&gt;|load [name]|migrate &lt;newQueueKey&gt;|merge &lt;fromUser&gt; &lt;toUser&gt;|add &lt;url&gt;|sources|requests|approve &lt;id&gt;|deny &lt;id&gt;|requeue|balance|shuffle &lt;mine|room&gt;|strict &lt;on|off&gt;|mode [roundrobin|shared]|clear &lt;room|mine [url]&gt;|remove &lt;position&gt;|list &lt;mine|room|detailed|user &lt;username&gt;&gt;|move &lt;url&gt; &lt;position&gt;|order &lt;next &lt;url&gt;|mine &lt;url newposition|0,1,2...|shortest|longest&gt;|room &lt; url newposition|0,1,2...|shortest|longest&gt;&gt;)"
+)
+
+// QUEUE_BALANCE_PREVIEW_MAX is the maximum amount of upcoming
+// streams listed after balancing the room's queue.
+const QUEUE_BALANCE_PREVIEW_MAX = 10
+
+// QUEUE_LIST_PRIVATE_ACTION is the action a user must be authorized
+// for to list another user's queue in rooms with private queues.
+const QUEUE_LIST_PRIVATE_ACTION = "queue/list/private"
+
+// QUEUE_APPROVE_ACTION is the action a user must be authorized for
+// to add streams to their queue directly in moderated rooms.
+const QUEUE_APPROVE_ACTION = "queue/approve"
+
+// orderings accepted by "/queue order <mine|room>"
+// that sort queued streams by their duration
+const (
+	QUEUE_ORDER_SHORTEST = "shortest"
+	QUEUE_ORDER_LONGEST  = "longest"
 )
 
 var mux sync.Mutex
 
+// shuffleRand orders shuffled queues; calls are serialized by mux
+var shuffleRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
 func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
 	if len(args) == 0 {
 		return h.usage, nil
@@ -40,13 +68,13 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 	userRoom, hasRoom := user.Namespace()
 	if !hasRoom {
 		log.Printf("ERR SOCKET CLIENT client with id %q (%s) attempted to control stream playback with no room assigned", user.UUID(), username)
-		return "", fmt.Errorf("error: you must be in a stream to control stream playback.")
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a stream to control stream playback.")
 	}
 
 	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
 	if !sPlaybackExists {
 		log.Printf("ERR SOCKET CLIENT unable to associate client %q (%s) in room %q with any stream playback objects", user.UUID(), username, userRoom)
-		return "", fmt.Errorf("error: no stream playback is currently loaded for your room")
+		return "", ErrNoPlayback
 	}
 
 	switch args[0] {
@@ -56,86 +84,129 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		if err != nil {
 			return "", err
 		}
+		url = sPlayback.ResolveStreamUrl(url)
 
-		userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
-		if err != nil {
-			return "", err
-		}
-		if !exists {
-			userQueue = queue.NewAggregatableQueue(user.UUID())
-			err := sPlayback.GetQueue().Push(userQueue)
+		// in moderated rooms, streams are added once an admin approves them
+		if sPlayback.Moderated() && !authorizedFor(cmdHandler, user, QUEUE_APPROVE_ACTION) {
+			req, err := sPlayback.AddQueueRequest(url, user)
 			if err != nil {
-				return "", err
+				return "", fmt.Errorf("error: %v", err)
 			}
+			sendQueueRequestsEvent(cmdHandler, user, clientHandler, sPlayback)
+
+			return fmt.Sprintf("This room is moderated: your request to queue %q (#%s) has been sent to the room's admins for approval.", url, req.Id), nil
 		}
 
-		// do not create and push stream if user queue is at its storage limit
-		if userQueue.Size() >= queue.MaxAggregatableQueueItems {
-			return "", queue.ErrMaxQueueSizeExceeded
+		return addToQueue(user, url, sPlayback, streamHandler)
+	case "sources":
+		kinds := make(map[string]int)
+		total := 0
+		for _, next := range playOrder(sPlayback.GetQueue(), -1) {
+			kind := "unknown"
+			if s, ok := next.item.(stream.Stream); ok {
+				kind = s.GetKind()
+			}
+			kinds[kind]++
+			total++
+		}
+		if total == 0 {
+			return "The queue is empty.", nil
 		}
 
-		sendStreamSync := false
-		if sPlayback.State() == playback.PLAYBACK_STATE_ENDED || sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED {
-			sendStreamSync = true
+		names := []string{}
+		for kind := range kinds {
+			names = append(names, kind)
 		}
+		sort.Slice(names, func(i, j int) bool {
+			if kinds[names[i]] != kinds[names[j]] {
+				return kinds[names[i]] > kinds[names[j]]
+			}
+			return names[i] < names[j]
+		})
 
-		s, err := sPlayback.GetOrCreateStreamFromUrl(url, user, streamHandler, func(user *client.Client, pback *playback.Playback, shouldSync bool) func([]byte, bool, error) {
-			return func(data []byte, created bool, err error) {
-				// if a new stream was created, sync fetched metadata with client
-				if !created {
-					return
-				}
+		counts := []string{}
+		for _, kind := range names {
+			counts = append(counts, fmt.Sprintf("%v %s", kinds[kind], kind))
+		}
+		return fmt.Sprintf("%v queued streams: %s", total, strings.Join(counts, ", ")), nil
+	case "requests":
+		requests := sPlayback.QueueRequests()
+		if len(requests) == 0 {
+			return "There are no pending queue requests.", nil
+		}
 
-				streamIdentifier := url
-				s, ok := streamHandler.GetStream(url)
-				if ok && len(s.GetName()) > 0 {
-					streamIdentifier = s.GetName()
-				}
-				user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has added %q to the queue", username, streamIdentifier))
-				user.BroadcastSystemMessageTo(fmt.Sprintf("successfully queued %q", streamIdentifier))
+		output := "Pending queue requests:<br />"
+		for _, req := range requests {
+			output += fmt.Sprintf("<br />#%s <span class='text-hl-name'>%s</span>: %s (%v ago)", req.Id, html.EscapeString(req.RequesterName), html.EscapeString(req.Url), time.Now().Sub(req.RequestedAt).Round(time.Second))
+		}
+		output += fmt.Sprintf("<br /><br />Use \"/%s approve &lt;id&gt;\" or \"/%s deny &lt;id&gt;\" to review a request.", QUEUE_NAME, QUEUE_NAME)
+		return output, nil
+	case "approve", "deny":
+		if len(args) < 2 {
+			return "", fmt.Errorf("%v", h.usage)
+		}
 
-				err = sendQueueSyncEvent(user, pback)
-				if err != nil {
-					log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to send queue-sync event to client")
-					return
-				}
-				err = sendUserQueueSyncEvent(user, pback)
-				if err != nil {
-					log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to send user-queue-sync event to client")
-					return
-				}
+		id := strings.TrimPrefix(args[1], "#")
+		req, exists := sPlayback.TakeQueueRequest(id)
+		if !exists {
+			return "", fmt.Errorf("error: no pending queue request with id %q", id)
+		}
+		sendQueueRequestsEvent(cmdHandler, user, clientHandler, sPlayback)
 
-				if !shouldSync {
-					return
-				}
+		requester, err := clientHandler.GetClient(req.RequesterId)
+		if err != nil {
+			return fmt.Sprintf("%s has left the room: removed their request to queue %q.", req.RequesterName, req.Url), nil
+		}
 
-				log.Printf("INFO SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK calculated queued stream info - sending streamsync\n")
+		if args[0] == "deny" {
+			requester.BroadcastSystemMessageTo(fmt.Sprintf("Your request to queue %q was denied.", req.Url))
+			return fmt.Sprintf("denied %s's request to queue %q", req.RequesterName, req.Url), nil
+		}
 
-				res := &client.Response{
-					Id:   user.UUID(),
-					From: username,
-				}
+		result, err := addToQueue(requester, req.Url, sPlayback, streamHandler)
+		if err != nil {
+			requester.BroadcastSystemMessageTo(fmt.Sprintf("Your request to queue %q was approved, but it could not be queued: %v", req.Url, err))
+			return "", err
+		}
 
-				err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
-				if err != nil {
-					log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to serialize playback into streamsync response: %v\n", err)
-					return
-				}
+		requester.BroadcastSystemMessageTo(fmt.Sprintf("Your request to queue %q was approved: %s", req.Url, result))
+		return fmt.Sprintf("approved %s's request to queue %q", req.RequesterName, req.Url), nil
+	case "requeue":
+		// re-add the currently playing stream to the end of the user's queue
+		current, exists := sPlayback.GetStream()
+		if !exists {
+			return "", fmt.Errorf("error: there is no stream currently playing to requeue")
+		}
 
-				user.BroadcastAll("streamsync", res)
-			}
-		}(user, sPlayback, sendStreamSync))
+		userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
 		if err != nil {
-			user.BroadcastErrorTo(err)
 			return "", err
 		}
+		if !exists {
+			userQueue = queue.NewAggregatableQueue(user.UUID())
+			err := sPlayback.GetQueue().Push(userQueue)
+			if err != nil {
+				return "", err
+			}
+		}
 
-		err = sPlayback.PushToQueue(userQueue, s)
-		if err != nil {
+		if err := userQueueFullError(sPlayback, userQueue); err != nil {
 			return "", err
 		}
 
-		err = sendQueueSyncEvent(user, sPlayback)
+		// retrieve the stream by its url to apply the same duplicate
+		// checks and labelled refs as adding the stream by hand
+		s, err := sPlayback.GetOrCreateStreamFromUrl(current.GetStreamURL(), user, streamHandler, func(data []byte, created bool, err error) {})
+		if err != nil {
+			return "", streamCapacityError(user, sPlayback, err)
+		}
+
+		queued, err := sPlayback.PushToQueue(userQueue, s)
+		if err != nil {
+			return "", queueFullError(sPlayback, err)
+		}
+
+		err = sendQueueItemAddedEvent(user, sPlayback, userQueue, queued)
 		if err != nil {
 			return "", err
 		}
@@ -144,51 +215,51 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			return "", err
 		}
 
-		streamQueueMsg := "attempting to queue stream..."
-
-		_, ok := streamHandler.GetStream(url)
-		if ok && len(s.GetName()) > 0 {
-			streamQueueMsg = fmt.Sprintf("successfully queued %q", s.GetName())
+		streamIdentifier := s.GetStreamURL()
+		if len(s.GetName()) > 0 {
+			streamIdentifier = s.GetName()
 		}
 
-		// TODO: turn this code-block into a helper (currently used here, socket/handler.go, and cmd/stream.go)
-		// if room playback state is PLAYBACK_STATE_ENDED, auto-play the next queued item (if found)
-		if sPlayback.State() == playback.PLAYBACK_STATE_ENDED || sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED {
-			roomQueue := sPlayback.GetQueue()
-			nextQueueItem, err := roomQueue.Next()
-			if err == nil {
-				nextStream, ok := nextQueueItem.(stream.Stream)
-				if !ok {
-					return fmt.Sprintf("%s - The stream will not auto-play because it does appear to be a stream.Stream (programmer error)", streamQueueMsg), nil
-				}
-
-				sPlayback.SetStream(nextStream)
-				sPlayback.Reset()
-
-				res := &client.Response{
-					Id:   user.UUID(),
-					From: username,
-				}
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has re-added %q to the queue", username, streamIdentifier))
+		return fmt.Sprintf("successfully re-queued %q", streamIdentifier), nil
+	case "strict":
+		if len(args) < 2 {
+			if sPlayback.StrictQueue() {
+				return "strict queueing is on: adding a stream that is already in your queue is an error", nil
+			}
+			return "strict queueing is off: adding a stream that is already in your queue reports its position", nil
+		}
 
-				err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
-				if err != nil {
-					return fmt.Sprintf("%s - The stream will not auto-play due to a serialization error: %v", streamQueueMsg, err), nil
-				}
+		switch args[1] {
+		case "on":
+			sPlayback.SetStrictQueue(true)
+		case "off":
+			sPlayback.SetStrictQueue(false)
+		default:
+			return "", fmt.Errorf("%v", h.usage)
+		}
 
-				user.BroadcastAll("streamload", res)
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has turned strict queueing %s", username, args[1]))
+		return fmt.Sprintf("turning strict queueing %s...", args[1]), nil
+	case "mode":
+		if len(args) < 2 {
+			if sPlayback.QueueMode() == queue.SHARED_MODE {
+				return "the room's queue is shared: streams play in the order they were added, regardless of who added them", nil
+			}
+			return "the room's queue is round-robin: streams play taking one from each user's queue in turn", nil
+		}
 
-				// play the newly loaded stream
-				err := sPlayback.Play()
-				if err != nil {
-					return fmt.Sprintf("%s - The stream will not auto-play due to an error: %v", streamQueueMsg, err), nil
-				}
+		if err := sPlayback.SetQueueMode(args[1]); err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
 
-				user.BroadcastAll("streamsync", res)
-				return fmt.Sprintf("%s (auto-playing...)", streamQueueMsg), nil
-			}
+		err := sendQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
 		}
 
-		return streamQueueMsg, nil
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room's queue mode to %s", username, args[1]))
+		return fmt.Sprintf("setting the room's queue mode to %s...", args[1]), nil
 	case "list":
 		if len(args) < 2 {
 			return "", fmt.Errorf("%v", h.usage)
@@ -223,7 +294,49 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			return output, nil
 		}
 
+		if args[1] == "user" {
+			if len(args) < 3 {
+				return h.usage, nil
+			}
+
+			target, exists := findSubjectByName(user, clientHandler, args[2])
+			if !exists {
+				return "", fmt.Errorf("error: no user named %q was found in your room", args[2])
+			}
+
+			if sPlayback.PrivateQueues() && target.UUID() != user.UUID() && !authorizedFor(cmdHandler, user, QUEUE_LIST_PRIVATE_ACTION) {
+				return "", fmt.Errorf("error: queues are private in this room. Only admins can list another user's queue")
+			}
+
+			userQueue, exists, err := playbackutil.GetQueueForId(target.UUID(), sPlayback.GetQueue())
+			if err != nil {
+				return "", err
+			}
+
+			targetName := html.EscapeString(target.GetUsernameOrId())
+			if !exists || userQueue.Size() == 0 {
+				return fmt.Sprintf("%s has nothing queued.", targetName), nil
+			}
+
+			output := fmt.Sprintf("%s's queue:<br />", targetName)
+			for idx, item := range userQueue.List() {
+				output += fmt.Sprintf("<br />%v. %s", idx+1, html.EscapeString(queueItemName(item)))
+			}
+			return output, nil
+		}
+
+		if args[1] == "detailed" {
+			if sPlayback.PrivateQueues() && !authorizedFor(cmdHandler, user, QUEUE_LIST_PRIVATE_ACTION) {
+				return "", fmt.Errorf("error: queues are private in this room. Only admins can list the room's queue")
+			}
+			return "Queue play order:<br />" + detailedPlayOrder(sPlayback.GetQueue(), clientHandler), nil
+		}
+
 		if args[1] == "room" || args[1] == "all" {
+			if sPlayback.PrivateQueues() && !authorizedFor(cmdHandler, user, QUEUE_LIST_PRIVATE_ACTION) {
+				return "", fmt.Errorf("error: queues are private in this room. Only admins can list the room's queue")
+			}
+
 			status, err := sPlayback.GetQueue().Serialize()
 			if err != nil {
 				return "", err
@@ -255,7 +368,7 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 				userQueueIdx := -1
 
 				for idx, qItem := range sPlayback.GetQueue().PeekItems() {
-					if qItem.UUID() == args[2] {
+					if queue.QueueItemMatches(qItem, args[2]) {
 						itemToDelete = qItem
 						found = true
 						userQueueIdx = idx
@@ -278,16 +391,22 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 					return "", err
 				}
 
+				err = sendQueueItemRemovedEvent(user, sPlayback, userQueue, itemToDelete)
+				if err != nil {
+					return "", err
+				}
+
 				msg = fmt.Sprintf("deleting stream with url %q from the queue...", args[2])
 			} else {
 				sPlayback.ClearQueue()
-			}
 
-			err := sendQueueSyncEvent(user, sPlayback)
-			if err != nil {
-				return "", err
+				err := sendQueueSyncEvent(user, sPlayback)
+				if err != nil {
+					return "", err
+				}
 			}
-			err = sendUserQueueSyncEvent(user, sPlayback)
+
+			err := sendUserQueueSyncEvent(user, sPlayback)
 			if err != nil {
 				return "", err
 			}
@@ -308,25 +427,35 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 
 			// if 3 args, treat last arg as url of stream to delete
 			if len(args) > 2 {
-				s, exists := streamHandler.GetStream(args[2])
-				if !exists {
+				idx, found, err := queueItemIndex(args[2], userQueue.List())
+				if err != nil {
+					return "", fmt.Errorf("error: %v", err)
+				}
+				if !found {
 					return "", fmt.Errorf("The provided stream with id %q does not exist in your queue", args[2])
 				}
 
-				err := sPlayback.ClearQueueItem(userQueue, s)
+				item := userQueue.List()[idx]
+				err = sPlayback.ClearQueueItem(userQueue, item)
+				if err != nil {
+					return "", err
+				}
+
+				err = sendQueueItemRemovedEvent(user, sPlayback, userQueue, item)
 				if err != nil {
 					return "", err
 				}
 
-				msg = fmt.Sprintf("deleting stream with url %q", s.GetStreamURL())
+				msg = fmt.Sprintf("deleting stream with url %q", item.UUID())
 			} else {
 				sPlayback.ClearUserQueue(userQueue)
-			}
 
-			err = sendQueueSyncEvent(user, sPlayback)
-			if err != nil {
-				return "", err
+				err = sendQueueSyncEvent(user, sPlayback)
+				if err != nil {
+					return "", err
+				}
 			}
+
 			err = sendUserQueueSyncEvent(user, sPlayback)
 			if err != nil {
 				return "", err
@@ -335,11 +464,55 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		}
 
 		return h.usage, nil
+	case "remove":
+		if len(args) < 2 {
+			return "", fmt.Errorf("%v", h.usage)
+		}
+
+		position, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("error: the position must be a number, where 1 is the first stream in your queue")
+		}
+
+		userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
+		if err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+		if !exists || userQueue.Size() == 0 {
+			return "", fmt.Errorf("error: you have no streams in your queue to remove.")
+		}
+
+		items := userQueue.List()
+		if position < 1 || position > len(items) {
+			return "", fmt.Errorf("error: the position must be between 1 and %v, the amount of streams in your queue", len(items))
+		}
+
+		item := items[position-1]
+		err = sPlayback.ClearQueueItem(userQueue, item)
+		if err != nil {
+			return "", err
+		}
+
+		err = sendQueueItemRemovedEvent(user, sPlayback, userQueue, item)
+		if err != nil {
+			return "", err
+		}
+
+		err = sendUserQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("removing stream with url %q from position %v of your queue", item.UUID(), position), nil
 	case "order":
 		if len(args) < 3 {
 			return "", fmt.Errorf("%v", h.usage)
 		}
 
+		// shared queues play streams in the order they were added
+		if (args[1] == "next" || args[1] == "room") && sPlayback.QueueMode() == queue.SHARED_MODE {
+			return "", fmt.Errorf("error: the room's queue is shared and plays streams in the order they were added. Use \"/%s mode %s\" to re-order it", QUEUE_NAME, queue.ROUND_ROBIN_MODE)
+		}
+
 		// allow only a single client to perform an "order" operation on the queue
 		mux.Lock()
 		defer mux.Unlock()
@@ -365,12 +538,14 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 				return "", fmt.Errorf("error: %v", err)
 			}
 
-			err = sPlayback.GetQueue().Reorder(newOrder)
+			// re-ordering keeps the previously upcoming queue next;
+			// point the round-robin at the bumped queue instead.
+			err = sPlayback.GetQueue().ReorderWithCurrentIndex(newOrder, destIdx)
 			if err != nil {
 				return "", fmt.Errorf("error: unable to re-order queue: %v", err)
 			}
 
-			err = sendQueueSyncEvent(user, sPlayback)
+			err = broadcastReorder(user, sPlayback, false)
 			if err != nil {
 				return "", err
 			}
@@ -378,6 +553,58 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			return fmt.Sprintf("re-ordering queue: setting %v as the next stream in the queue...", streamId), nil
 		}
 
+		if (args[1] == "room" || args[1] == "mine") && (args[2] == QUEUE_ORDER_SHORTEST || args[2] == QUEUE_ORDER_LONGEST) {
+			longest := args[2] == QUEUE_ORDER_LONGEST
+
+			if args[1] == "mine" {
+				userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
+				if err != nil {
+					return "", fmt.Errorf("error: %v", err)
+				}
+				if !exists || userQueue.Size() == 0 {
+					return "", fmt.Errorf("error: unable to re-order an empty queue")
+				}
+
+				if err := userQueue.Reorder(durationOrder(userQueue.List(), longest)); err != nil {
+					return "", fmt.Errorf("error: unable to re-order your queue: %v", err)
+				}
+
+				err = broadcastReorder(user, sPlayback, true)
+				if err != nil {
+					return "", err
+				}
+
+				return fmt.Sprintf("re-ordering your queue: playing %s streams first...", args[2]), nil
+			}
+
+			// the round-robin order between users is kept;
+			// each user's queue is sorted individually.
+			for _, item := range sPlayback.GetQueue().List() {
+				userQueue, ok := item.(queue.AggregatableQueue)
+				if !ok || userQueue.Size() == 0 {
+					continue
+				}
+				if err := userQueue.Reorder(durationOrder(userQueue.List(), longest)); err != nil {
+					return "", fmt.Errorf("error: unable to re-order queue: %v", err)
+				}
+			}
+
+			err := sendQueueSyncEvent(user, sPlayback)
+			if err != nil {
+				return "", err
+			}
+			for _, item := range sPlayback.GetQueue().List() {
+				if owner, err := clientHandler.GetClient(item.UUID()); err == nil {
+					if err := sendUserQueueSyncEvent(owner, sPlayback); err != nil {
+						log.Printf("ERR SOCKET CLIENT unable to emit user-queue-sync event to client with id %q: %v", owner.UUID(), err)
+					}
+				}
+			}
+
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has re-ordered the queue to play %s streams first", user.GetUsernameOrId(), args[2]))
+			return fmt.Sprintf("re-ordering queue: playing %s streams first...", args[2]), nil
+		}
+
 		if args[1] == "room" || args[1] == "mine" {
 			// if less than 4 args, interpret remaining arg as a comma delimited input representing
 			// the new overall queue order: "0,2,1,3"
@@ -392,29 +619,27 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			// destination for the given item id.
 
 			streamId := args[2]
-			sourceIdx, found, err := queueItemIndex(streamId, sPlayback.GetQueue().PeekItems())
-			if !found {
-				return "", fmt.Errorf("error: source item id (%v) was not found in the queue", streamId)
-			}
-
 			destIdx, err := strconv.Atoi(args[3])
 			if err != nil {
 				return "", fmt.Errorf("error: unable to convert destination item index: %v", err)
 			}
 
-			newOrder, err := calculateQueueOrder(sourceIdx, destIdx, sPlayback.GetQueue().Size())
-			if err != nil {
-				return "", fmt.Errorf("error: %v", err)
+			// the room's queue is ordered by the user queue
+			// whose next stream matches the given id
+			source := func(item queue.QueueItem) bool {
+				userQueue, ok := item.(queue.AggregatableQueue)
+				if !ok || userQueue.Size() == 0 {
+					return false
+				}
+				return queue.QueueItemMatches(userQueue.List()[0], streamId)
 			}
 
-			err = sPlayback.GetQueue().Reorder(newOrder)
-			if err != nil {
-				return "", fmt.Errorf("error: unable to re-order queue: %v", err)
+			err = reorders.Order(user, sPlayback, sPlayback.GetQueue(), false, source, destIdx)
+			if err == errReorderSourceNotFound {
+				return "", fmt.Errorf("error: source item id (%v) was not found in the queue", streamId)
 			}
-
-			err = sendQueueSyncEvent(user, sPlayback)
 			if err != nil {
-				return "", err
+				return "", fmt.Errorf("error: unable to re-order queue: %v", err)
 			}
 
 			return fmt.Sprintf("re-ordering queue: moving %v to position %v...", streamId, destIdx), nil
@@ -431,80 +656,346 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 				return "", fmt.Errorf("error: unable to re-order an empty queue")
 			}
 
-			sourceIdx, found, err := queueItemIndex(streamId, userQueue.List())
-			if err != nil {
-				return "", fmt.Errorf("error: %v", err)
-			}
-			if !found {
-				return "", fmt.Errorf("error: source item id (%v) was not found in your queue", streamId)
-			}
-
 			destIdx, err := strconv.Atoi(args[3])
 			if err != nil {
 				return "", fmt.Errorf("error: unable to convert destination item index: %v", err)
 			}
 
-			newOrder, err := calculateQueueOrder(sourceIdx, destIdx, userQueue.Size())
-			if err != nil {
-				return "", fmt.Errorf("error: %v", err)
-			}
-
-			err = userQueue.Reorder(newOrder)
-			if err != nil {
-				return "", fmt.Errorf("error: unable to re-order your queue: %v", err)
+			source := func(item queue.QueueItem) bool {
+				return queue.QueueItemMatches(item, streamId)
 			}
 
-			err = sendUserQueueSyncEvent(user, sPlayback)
-			if err != nil {
-				return "", err
+			err = reorders.Order(user, sPlayback, userQueue, true, source, destIdx)
+			if err == errReorderSourceNotFound {
+				return "", fmt.Errorf("error: source item id (%v) was not found in your queue", streamId)
 			}
-
-			err = sendQueueSyncEvent(user, sPlayback)
 			if err != nil {
-				return "", err
+				return "", fmt.Errorf("error: unable to re-order your queue: %v", err)
 			}
 
 			return fmt.Sprintf("re-ordering your queue: moving %v to position %v...", streamId, destIdx), nil
 		}
 
 		return h.usage, nil
-	case "migrate":
-		if len(args) < 2 {
-			return h.usage, nil
+	case "move":
+		if len(args) < 3 {
+			return "", fmt.Errorf("%v", h.usage)
 		}
 
-		fromKey := args[1]
-		oldUserQueue, exists, err := playbackutil.GetQueueForId(fromKey, sPlayback.GetQueue())
+		position, err := strconv.Atoi(args[2])
 		if err != nil {
-			return "", fmt.Errorf("error: %v", err)
-		}
-		if !exists {
-			return "", fmt.Errorf("error: queue with id %q does not exist. Unable to migrate queue", fromKey)
+			return "", fmt.Errorf("error: the position must be a number, where 1 is the next stream to play")
 		}
 
-		// requested queue exists, migrate items to new queue
-		newQueue := queue.NewAggregatableQueue(user.UUID())
-		for _, oldItem := range oldUserQueue.List() {
-			newQueue.Push(oldItem)
+		// allow only a single client to perform an "order" operation on the queue
+		mux.Lock()
+		defer mux.Unlock()
+
+		roomQueue := sPlayback.GetQueue()
+		// shared queues play streams in the order they were added
+		if roomQueue.Mode() == queue.SHARED_MODE {
+			return "", fmt.Errorf("error: the room's queue is shared and plays streams in the order they were added. Use \"/%s mode %s\" to re-order it", QUEUE_NAME, queue.ROUND_ROBIN_MODE)
 		}
-		err = sPlayback.GetQueue().Push(newQueue)
-		if err != nil {
-			return "", fmt.Errorf("error: unable to migrate queue: %v", err)
+		if position < 1 || position > roomQueue.Size() {
+			return "", fmt.Errorf("error: the position must be between 1 and %v, the amount of streams up next in the room queue", roomQueue.Size())
 		}
 
-		// delete old queue - no need to delete parentRef
-		sPlayback.GetQueue().DeleteItem(oldUserQueue)
+		// only the first stream of each user's queue is part of
+		// the room's order; the rest play in their owner's turn
+		streamId := args[1]
+		sourceIdx := -1
+		for idx, item := range roomQueue.List() {
+			userQueue, ok := item.(queue.AggregatableQueue)
+			if !ok || userQueue.Size() == 0 {
+				continue
+			}
+			if queue.QueueItemMatches(userQueue.List()[0], streamId) {
+				sourceIdx = idx
+				break
+			}
+		}
+		if sourceIdx < 0 {
+			return "", fmt.Errorf("error: %v is not up next for any user in the room queue. Use \"/%s order mine\" to re-order streams within your own queue", streamId, QUEUE_NAME)
+		}
 
-		err = sendUserQueueSyncEvent(user, sPlayback)
-		if err != nil {
-			return "", err
+		newOrder := playbackOrderMove(roomQueue.Size(), roomQueue.CurrentIndex(), sourceIdx, position)
+		// the new order starts from the next item to play
+		if err := roomQueue.ReorderWithCurrentIndex(newOrder, 0); err != nil {
+			return "", fmt.Errorf("error: unable to re-order queue: %v", err)
 		}
-		err = sendQueueSyncEvent(user, sPlayback)
+
+		err = broadcastReorder(user, sPlayback, false)
 		if err != nil {
 			return "", err
 		}
 
-		// send user-queue-sync event to user with "fromKey" id, if still exists
+		return fmt.Sprintf("re-ordering queue: moving %v to play #%v from now...", streamId, position), nil
+	case "balance":
+		// allow only a single client to perform an "order" operation on the queue
+		mux.Lock()
+		defer mux.Unlock()
+
+		roomQueue := sPlayback.GetQueue()
+		if roomQueue.Size() == 0 {
+			return "", fmt.Errorf("error: unable to balance an empty queue")
+		}
+		if roomQueue.Mode() == queue.SHARED_MODE {
+			return "", fmt.Errorf("error: the room's queue is shared and plays streams in the order they were added. Use \"/%s mode %s\" to balance it", QUEUE_NAME, queue.ROUND_ROBIN_MODE)
+		}
+
+		// rotate the aggregated user queues so that the queue at the current
+		// round-robin index is first, then restart the round-robin from it.
+		// The resulting play order takes the next item from each user's queue
+		// in turn: A1, B1, C1, A2, B2, C2, ...
+		start := roomQueue.CurrentIndex()
+		newOrder := make([]int, 0, roomQueue.Size())
+		for i := 0; i < roomQueue.Size(); i++ {
+			newOrder = append(newOrder, (start+i)%roomQueue.Size())
+		}
+
+		err := roomQueue.ReorderWithCurrentIndex(newOrder, 0)
+		if err != nil {
+			return "", fmt.Errorf("error: unable to re-order queue: %v", err)
+		}
+
+		err = sendQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has balanced the room's queue", username))
+		return "Balancing the room's queue. Streams will play in the following order:<br />" + fairPlayOrder(roomQueue, QUEUE_BALANCE_PREVIEW_MAX), nil
+	case "shuffle":
+		if len(args) < 2 {
+			return h.usage, nil
+		}
+
+		// allow only a single client to perform an "order" operation on the queue
+		mux.Lock()
+		defer mux.Unlock()
+
+		if args[1] == "mine" {
+			userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
+			if err != nil {
+				return "", fmt.Errorf("error: %v", err)
+			}
+			if !exists || userQueue.Size() < 2 {
+				return "", fmt.Errorf("error: your queue needs at least two streams to be shuffled")
+			}
+
+			if err := userQueue.Reorder(shuffleRand.Perm(userQueue.Size())); err != nil {
+				return "", fmt.Errorf("error: unable to shuffle your queue: %v", err)
+			}
+
+			if err := sendUserQueueSyncEvent(user, sPlayback); err != nil {
+				return "", err
+			}
+			if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+				return "", err
+			}
+			return "Shuffling your queue...", nil
+		}
+
+		if args[1] != "room" {
+			return h.usage, nil
+		}
+
+		roomQueue := sPlayback.GetQueue()
+		if roomQueue.Size() < 2 {
+			return "", fmt.Errorf("error: the room's queue needs at least two users' streams to be shuffled")
+		}
+		if roomQueue.Mode() == queue.SHARED_MODE {
+			return "", fmt.Errorf("error: the room's queue is shared and plays streams in the order they were added. Use \"/%s mode %s\" to shuffle it", QUEUE_NAME, queue.ROUND_ROBIN_MODE)
+		}
+
+		// keep the user queue at the current round-robin index
+		// up next, and shuffle the order of every other queue.
+		next := roomQueue.CurrentIndex()
+		if next >= roomQueue.Size() {
+			next = 0
+		}
+		newOrder := []int{next}
+		for _, idx := range shuffleRand.Perm(roomQueue.Size()) {
+			if idx != next {
+				newOrder = append(newOrder, idx)
+			}
+		}
+
+		if err := roomQueue.ReorderWithCurrentIndex(newOrder, 0); err != nil {
+			return "", fmt.Errorf("error: unable to shuffle queue: %v", err)
+		}
+
+		if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+			return "", err
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has shuffled the room's queue", username))
+		return "Shuffling the room's queue...", nil
+	case "save":
+		if len(args) < 2 {
+			return h.usage, nil
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		name := args[1]
+		if sPlayback.GetQueue().Size() == 0 {
+			return "", fmt.Errorf("error: unable to save an empty queue")
+		}
+
+		slot := playback.NewQueueSlot(name, sPlayback.GetQueue(), func(id string) string {
+			if c, err := clientHandler.GetClient(id); err == nil {
+				return c.GetSourceName()
+			}
+			return id
+		})
+
+		err := playbackHandler.SaveQueueSlot(userRoom.Name(), slot)
+		if err != nil {
+			return "", fmt.Errorf("error: unable to save queue: %v", err)
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has saved the room's queue as %q", username, name))
+		return fmt.Sprintf("saved the room's queue as %q", name), nil
+	case "load":
+		if len(args) < 2 {
+			names := playbackHandler.QueueSlotNames(userRoom.Name())
+			if len(names) == 0 {
+				return "There are no saved queues for this room.", nil
+			}
+
+			output := "Saved queues:<br />"
+			for _, name := range names {
+				output += fmt.Sprintf("<br /><span class='text-hl-name'>%s</span>", html.EscapeString(name))
+			}
+			return output, nil
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		slot, exists := playbackHandler.QueueSlot(userRoom.Name(), args[1])
+		if !exists {
+			return "", fmt.Errorf("error: no saved queue with name %q exists for this room", args[1])
+		}
+
+		if err := sPlayback.ClearQueue(); err != nil {
+			log.Printf("ERR SOCKET CLIENT errors occurred while clearing the queue before loading saved queue %q: %v", slot.Name, err)
+		}
+
+		loaded := 0
+		skipped := 0
+		for _, owner := range slot.Owners {
+			// queue items under their owner's current client, if they are
+			// still in the room; otherwise, keep them under the saved queue id.
+			ownerKey := owner.Id
+			ownerRef := user
+			if c, exists := findSubjectByName(user, clientHandler, owner.Name); exists {
+				ownerKey = c.UUID()
+				ownerRef = c
+			} else if c, err := clientHandler.GetClient(owner.Id); err == nil {
+				ownerRef = c
+			}
+
+			userQueue, exists, err := playbackutil.GetQueueForId(ownerKey, sPlayback.GetQueue())
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				userQueue = queue.NewAggregatableQueue(ownerKey)
+				if err := sPlayback.GetQueue().Push(userQueue); err != nil {
+					return "", err
+				}
+			}
+
+			for _, url := range owner.Urls {
+				if sPlayback.UserQueueFull(userQueue) {
+					skipped++
+					continue
+				}
+
+				s, err := sPlayback.GetOrCreateStreamFromUrl(url, ownerRef, streamHandler, func(data []byte, created bool, err error) {
+					if !created {
+						return
+					}
+					if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+						log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to send queue-sync event to client")
+					}
+				})
+				if err != nil {
+					log.Printf("INF SOCKET CLIENT skipping stream %q while loading saved queue %q: %v", url, slot.Name, err)
+					skipped++
+					continue
+				}
+
+				if _, err := sPlayback.PushToQueue(userQueue, s); err != nil {
+					log.Printf("INF SOCKET CLIENT skipping stream %q while loading saved queue %q: %v", url, slot.Name, err)
+					skipped++
+					continue
+				}
+				loaded++
+			}
+
+			if userQueue.Size() == 0 {
+				sPlayback.GetQueue().DeleteItem(userQueue)
+			}
+		}
+		if err := sPlayback.GetQueue().SetCurrentIndex(0); err != nil {
+			return "", err
+		}
+
+		err := sendQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+		for _, conn := range user.Connections() {
+			c, err := clientHandler.GetClient(conn.UUID())
+			if err != nil {
+				continue
+			}
+			if err := sendUserQueueSyncEvent(c, sPlayback); err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to emit user-queue-sync event to client %q after loading a saved queue: %v", c.UUID(), err)
+			}
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has loaded the saved queue %q", username, slot.Name))
+
+		msg := fmt.Sprintf("loaded %v streams from saved queue %q", loaded, slot.Name)
+		if skipped > 0 {
+			msg += fmt.Sprintf(" (%v streams could not be loaded and were skipped)", skipped)
+		}
+		return msg, nil
+	case "migrate":
+		if len(args) < 2 {
+			return h.usage, nil
+		}
+
+		fromKey := args[1]
+		oldUserQueue, exists, err := playbackutil.GetQueueForId(fromKey, sPlayback.GetQueue())
+		if err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+		if !exists {
+			return "", fmt.Errorf("error: queue with id %q does not exist. Unable to migrate queue", fromKey)
+		}
+
+		// requested queue exists, migrate items to new queue
+		err = moveQueueItems(sPlayback, oldUserQueue, user.UUID(), user)
+		if err != nil {
+			return "", fmt.Errorf("error: unable to migrate queue: %v", err)
+		}
+
+		err = sendUserQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+		err = sendQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+
+		// send user-queue-sync event to user with "fromKey" id, if still exists
 		oldUser, err := clientHandler.GetClient(fromKey)
 		if err == nil {
 			err = sendUserQueueSyncEvent(oldUser, sPlayback)
@@ -515,11 +1006,265 @@ func (h *QueueCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			}
 		}
 		return "migrating queue...", nil
+	case "merge":
+		if len(args) < 3 {
+			return h.usage, nil
+		}
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		fromKey, fromUser := queueOwnerByName(args[1], user, clientHandler)
+		toKey, toUser := queueOwnerByName(args[2], user, clientHandler)
+		if fromKey == toKey {
+			return "", fmt.Errorf("error: unable to merge a queue into itself")
+		}
+
+		fromQueue, exists, err := playbackutil.GetQueueForId(fromKey, sPlayback.GetQueue())
+		if err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+		if !exists {
+			return "", fmt.Errorf("error: %q does not have a queue. Unable to merge queues", args[1])
+		}
+
+		toQueue, exists, err := playbackutil.GetQueueForId(toKey, sPlayback.GetQueue())
+		if err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+		if !exists {
+			return "", fmt.Errorf("error: %q does not have a queue. Unable to merge queues", args[2])
+		}
+
+		if fromQueue.Size()+toQueue.Size() > queue.MaxAggregatableQueueItems {
+			return "", fmt.Errorf("error: unable to merge queues: the merged queue would exceed the maximum of %v items", queue.MaxAggregatableQueueItems)
+		}
+
+		// items from the first queue are appended after the second queue's items
+		err = moveQueueItems(sPlayback, fromQueue, toKey, toUser)
+		if err != nil {
+			return "", fmt.Errorf("error: unable to merge queues: %v", err)
+		}
+
+		for _, affected := range []*client.Client{fromUser, toUser} {
+			if affected == nil {
+				continue
+			}
+
+			err = sendUserQueueSyncEvent(affected, sPlayback)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to emit user-queue-sync event to client %q after merging queues: %v", affected.UUID(), err)
+				continue
+			}
+			if affected != user {
+				affected.BroadcastSystemMessageTo(fmt.Sprintf("user %q has merged the queue of %q into the queue of %q", username, args[1], args[2]))
+			}
+		}
+
+		err = sendQueueSyncEvent(user, sPlayback)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("merging the queue of %q into the queue of %q...", args[1], args[2]), nil
 	}
 
 	return h.usage, nil
 }
 
+// sendQueueRequestsEvent sends an "info_queuerequests" event containing the
+// room's pending queue requests to every client in the room authorized to
+// review them
+func sendQueueRequestsEvent(cmdHandler SocketCommandHandler, user *client.Client, clientHandler client.SocketClientHandler, sPlayback *playback.Playback) {
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+		Extra: map[string]interface{}{
+			"moderated": sPlayback.Moderated(),
+			"requests":  sPlayback.QueueRequests(),
+		},
+	}
+
+	for _, conn := range user.Connections() {
+		c, err := clientHandler.GetClient(conn.UUID())
+		if err != nil {
+			continue
+		}
+		if authorizedFor(cmdHandler, c, QUEUE_APPROVE_ACTION) {
+			c.BroadcastTo("info_queuerequests", res)
+		}
+	}
+}
+
+// addToQueue creates or retrieves a stream from the given url and pushes
+// it to the given user's queue, auto-playing it if the room's playback
+// has ended or not yet started.
+func addToQueue(user *client.Client, url string, sPlayback *playback.Playback, streamHandler stream.StreamHandler) (string, error) {
+	username := user.GetUsernameOrId()
+
+	userQueue, exists, err := playbackutil.GetUserQueue(user, sPlayback.GetQueue())
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		err := sPlayback.GetQueue().Push(userQueue)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// in non-strict rooms, report the position of an already queued stream
+	// rather than returning an error
+	if !sPlayback.StrictQueue() {
+		idx, found, err := queueItemIndex(url, userQueue.List())
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return fmt.Sprintf("%q is already in your queue at #%v", url, idx+1), nil
+		}
+	}
+
+	// do not create and push stream if user queue is at the room's
+	// per-user limit, or at its storage limit
+	if err := userQueueFullError(sPlayback, userQueue); err != nil {
+		return "", err
+	}
+
+	sendStreamSync := false
+	if sPlayback.State() == playback.PLAYBACK_STATE_ENDED || sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED {
+		sendStreamSync = true
+	}
+
+	s, err := sPlayback.GetOrCreateStreamFromUrl(url, user, streamHandler, func(user *client.Client, pback *playback.Playback, shouldSync bool) func([]byte, bool, error) {
+		return func(data []byte, created bool, err error) {
+			// if a new stream was created, sync fetched metadata with client
+			if !created {
+				return
+			}
+
+			streamIdentifier := url
+			s, ok := streamHandler.GetStream(url)
+			if ok && len(s.GetName()) > 0 {
+				streamIdentifier = s.GetName()
+			}
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has added %q to the queue", username, streamIdentifier))
+			user.BroadcastSystemMessageTo(fmt.Sprintf("successfully queued %q", streamIdentifier))
+
+			err = sendQueueSyncEvent(user, pback)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to send queue-sync event to client")
+				return
+			}
+			err = sendUserQueueSyncEvent(user, pback)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to send user-queue-sync event to client")
+				return
+			}
+
+			if !shouldSync {
+				return
+			}
+
+			log.Printf("INFO SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK calculated queued stream info - sending streamsync\n")
+
+			res := &client.Response{
+				Id:   user.UUID(),
+				From: username,
+			}
+
+			err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT PLAYBACK-FETCHMETADATA-CALLBACK unable to serialize playback into streamsync response: %v\n", err)
+				return
+			}
+
+			user.BroadcastAll("streamsync", res)
+		}
+	}(user, sPlayback, sendStreamSync))
+	if err != nil {
+		err = streamCapacityError(user, sPlayback, err)
+		user.BroadcastErrorTo(err)
+		return "", err
+	}
+
+	queued, err := sPlayback.PushToQueue(userQueue, s)
+	if err != nil {
+		return "", queueFullError(sPlayback, err)
+	}
+
+	err = sendQueueItemAddedEvent(user, sPlayback, userQueue, queued)
+	if err != nil {
+		return "", err
+	}
+	err = sendUserQueueSyncEvent(user, sPlayback)
+	if err != nil {
+		return "", err
+	}
+
+	streamQueueMsg := "attempting to queue stream..."
+
+	_, ok := streamHandler.GetStream(url)
+	if ok && len(s.GetName()) > 0 {
+		streamQueueMsg = fmt.Sprintf("successfully queued %q", s.GetName())
+	}
+
+	// if room playback state is PLAYBACK_STATE_ENDED, auto-play the next queued item (if found)
+	if sPlayback.State() == playback.PLAYBACK_STATE_ENDED || sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED {
+		if err := autoPlayQueue(user, sPlayback); err != nil {
+			return fmt.Sprintf("%s - The stream will not auto-play due to an error: %v", streamQueueMsg, err), nil
+		}
+		return fmt.Sprintf("%s (auto-playing...)", streamQueueMsg), nil
+	}
+
+	return streamQueueMsg, nil
+}
+
+// moveQueueItems moves every item in a user queue to the end of the queue belonging to destId,
+// creating it if needed, and removes the now-empty source queue from the room queue.
+// If an owner is given, it is recorded as the user that queued each moved stream.
+// If the room's current stream was queued by the source queue's owner, its
+// attribution is handed to the owner as well.
+func moveQueueItems(sPlayback *playback.Playback, source queue.AggregatableQueue, destId string, owner *client.Client) error {
+	newQueue := queue.NewAggregatableQueue(destId)
+	for _, item := range source.List() {
+		newQueue.Push(item)
+
+		if s, ok := item.(stream.Stream); ok && owner != nil {
+			s.Metadata().SetLabelledRef(sPlayback.UUID(), owner)
+		}
+	}
+
+	err := sPlayback.GetQueue().Push(newQueue)
+	if err != nil {
+		return err
+	}
+
+	// delete old queue - no need to delete parentRef
+	sPlayback.GetQueue().DeleteItem(source)
+
+	if owner != nil {
+		sPlayback.ReassignCurrentStream(source.UUID(), owner)
+	}
+	return nil
+}
+
+// queueOwnerByName receives the username or client id of a user in the
+// current user's room and returns the id of that user's queue, along with
+// the user's client (if it is still connected).
+func queueOwnerByName(name string, user *client.Client, clientHandler client.SocketClientHandler) (string, *client.Client) {
+	if c, exists := findSubjectByName(user, clientHandler, name); exists {
+		return c.UUID(), c
+	}
+
+	c, err := clientHandler.GetClient(name)
+	if err != nil {
+		return name, nil
+	}
+	return name, c
+}
+
 func NewCmdQueue() SocketCommand {
 	return &QueueCmd{
 		&Command{
@@ -530,6 +1275,56 @@ func NewCmdQueue() SocketCommand {
 	}
 }
 
+// durationOrder receives a list of queued streams and returns a slice
+// describing their new order, sorted by ascending duration - or descending,
+// if longest is true. Streams with an unknown duration are always placed last.
+func durationOrder(items []queue.QueueItem, longest bool) []int {
+	durations := make([]float64, len(items))
+	newOrder := make([]int, 0, len(items))
+	for idx, item := range items {
+		if s, ok := item.(stream.Stream); ok {
+			durations[idx] = s.GetDuration()
+		}
+		newOrder = append(newOrder, idx)
+	}
+
+	sort.SliceStable(newOrder, func(i, j int) bool {
+		a, b := durations[newOrder[i]], durations[newOrder[j]]
+		if a <= 0 || b <= 0 {
+			return a > 0 && b <= 0
+		}
+		if longest {
+			return a > b
+		}
+		return a < b
+	})
+	return newOrder
+}
+
+// playbackOrderMove receives the size of a round-robin queue, its current
+// index, the index of an item in the queue, and a 1-based position in playback
+// order, and returns a slice describing the new order of the queue, starting
+// from the next item to play, with the item at the given position.
+func playbackOrderMove(size, current, sourceIdx, position int) []int {
+	if current >= size {
+		current = 0
+	}
+
+	order := make([]int, 0, size)
+	for i := 0; i < size; i++ {
+		idx := (current + i) % size
+		if idx == sourceIdx {
+			continue
+		}
+		order = append(order, idx)
+	}
+
+	order = append(order, 0)
+	copy(order[position:], order[position-1:])
+	order[position-1] = sourceIdx
+	return order
+}
+
 // calculateQueueOrder receives a sourceIdx and
 // a destIdx and returns a slice describing the
 // new order of the queue with slice[destIdx]
@@ -567,6 +1362,23 @@ func calculateQueueOrder(sourceIdx, destIdx, length int) ([]int, error) {
 	return append(newOrder, sourceIdx), nil
 }
 
+// ClaimRestoredQueue queues the streams restored from a room snapshot
+// for the user's username, if any, and sends the room the new queue.
+func ClaimRestoredQueue(user *client.Client, sPlayback *playback.Playback, streamHandler stream.StreamHandler) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	queued := sPlayback.ClaimRestoredQueue(user, streamHandler)
+	if queued == 0 {
+		return
+	}
+
+	if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+		log.Printf("ERR SOCKET CLIENT unable to send queue-sync event after restoring the queue of client %q: %v", user.UUID(), err)
+	}
+	user.BroadcastSystemMessageTo(fmt.Sprintf("restored %v stream(s) you had queued before the server restarted", queued))
+}
+
 func sendQueueSyncEvent(user *client.Client, sPlayback *playback.Playback) error {
 	username, hasUsername := user.GetUsername()
 	if !hasUsername {
@@ -584,9 +1396,303 @@ func sendQueueSyncEvent(user *client.Client, sPlayback *playback.Playback) error
 	}
 
 	user.BroadcastAll("queuesync", res)
+	BroadcastQueueRuntime(user, sPlayback)
+	return nil
+}
+
+// BroadcastQueueRuntime emits an "info_queueruntime" event containing the
+// total duration of every stream queued in the room, if it has changed
+// since it was last broadcast.
+func BroadcastQueueRuntime(user *client.Client, sPlayback *playback.Playback) {
+	runtime, changed := sPlayback.UpdateQueueRuntime()
+	if !changed {
+		return
+	}
+
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: "system",
+		Extra: map[string]interface{}{
+			"seconds": runtime.Seconds,
+			"partial": runtime.Partial,
+			"items":   runtime.Items,
+		},
+	}
+	user.BroadcastAll("info_queueruntime", res)
+}
+
+// sendQueueItemAddedEvent broadcasts a "queueitemadded" event containing only the
+// given item and the resulting order of the room queue. A full "queuesync" event
+// is sent instead if the room does not use incremental queue syncs.
+func sendQueueItemAddedEvent(user *client.Client, sPlayback *playback.Playback, userQueue queue.AggregatableQueue, item queue.QueueItem) error {
+	if !sPlayback.IncrementalQueueSync() {
+		return sendQueueSyncEvent(user, sPlayback)
+	}
+
+	serializedItem := map[string]interface{}{
+		"id": item.UUID(),
+	}
+	if s, ok := item.(stream.Stream); ok {
+		if err := sockutil.SerializeIntoResponse(s.Codec(), &serializedItem); err != nil {
+			return err
+		}
+	}
+	if identifiable, ok := item.(queue.IdentifiableQueueItem); ok {
+		serializedItem["itemId"] = identifiable.ItemId()
+	}
+
+	user.BroadcastAll("queueitemadded", &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+		Extra: map[string]interface{}{
+			"owner": userQueue.UUID(),
+			"item":  serializedItem,
+			"order": queueOrder(sPlayback.GetQueue()),
+		},
+	})
+	BroadcastQueueRuntime(user, sPlayback)
+	return nil
+}
+
+// sendQueueItemRemovedEvent broadcasts a "queueitemremoved" event containing only
+// the id of the removed item and the resulting order of the room queue. A full
+// "queuesync" event is sent instead if the room does not use incremental queue syncs.
+func sendQueueItemRemovedEvent(user *client.Client, sPlayback *playback.Playback, userQueue queue.AggregatableQueue, item queue.QueueItem) error {
+	if !sPlayback.IncrementalQueueSync() {
+		return sendQueueSyncEvent(user, sPlayback)
+	}
+
+	user.BroadcastAll("queueitemremoved", &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+		Extra: map[string]interface{}{
+			"owner":  userQueue.UUID(),
+			"id":     item.UUID(),
+			"itemId": queueItemId(item),
+			"order":  queueOrder(sPlayback.GetQueue()),
+		},
+	})
+	BroadcastQueueRuntime(user, sPlayback)
 	return nil
 }
 
+// sendQueueReorderedEvent broadcasts a "queuereordered" event containing only the
+// new order of the room queue. A full "queuesync" event is sent instead if
+// the room does not use incremental queue syncs.
+func sendQueueReorderedEvent(user *client.Client, sPlayback *playback.Playback) error {
+	if !sPlayback.IncrementalQueueSync() {
+		return sendQueueSyncEvent(user, sPlayback)
+	}
+
+	user.BroadcastAll("queuereordered", &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+		Extra: map[string]interface{}{
+			"order": queueOrder(sPlayback.GetQueue()),
+		},
+	})
+	return nil
+}
+
+// queueItemId returns the item id of an IdentifiableQueueItem,
+// or the QueueItem's UUID if it has no item id.
+func queueItemId(item queue.QueueItem) string {
+	if identifiable, ok := item.(queue.IdentifiableQueueItem); ok {
+		return identifiable.ItemId()
+	}
+	return item.UUID()
+}
+
+// queueOrder returns the item ids of the items in the room queue,
+// in the same round-robin order used when serializing the queue.
+func queueOrder(roomQueue queue.RoundRobinQueue) []string {
+	items := roomQueue.PeekItems()
+
+	start := roomQueue.CurrentIndex()
+	if start > len(items) {
+		start = 0
+	}
+
+	order := make([]string, 0, len(items))
+	for _, item := range append(items[start:], items[0:start]...) {
+		order = append(order, queueItemId(item))
+	}
+	return order
+}
+
+// userQueueFullError returns an error if the given user queue
+// has reached the room's per-user limit, or its storage limit.
+func userQueueFullError(sPlayback *playback.Playback, userQueue queue.AggregatableQueue) error {
+	if !sPlayback.UserQueueFull(userQueue) {
+		return nil
+	}
+	if limit, exists := sPlayback.UserQueueLimit(); exists && limit < queue.MaxAggregatableQueueItems {
+		return NewCmdError(CMD_ERR_QUEUE_FULL, "error: this room limits each user to %v queued items. Wait for one of your streams to play before adding another", limit)
+	}
+	return NewCmdError(CMD_ERR_QUEUE_FULL, "%v", queue.ErrMaxQueueSizeExceeded)
+}
+
+// authorizedFor returns true if the given user is authorized to perform
+// the given action, or if the server does not have rbac enabled.
+func authorizedFor(cmdHandler SocketCommandHandler, user *client.Client, action string) bool {
+	authorizer := cmdHandler.Authorizer()
+	if authorizer == nil {
+		return true
+	}
+
+	rule, exists := rbac.RuleByAction(authorizer.Bindings(), action)
+	if !exists {
+		return false
+	}
+	return authorizer.Verify(user.Connection(), rule)
+}
+
+// playOrderItem is a queued item along with the id of the user queue it belongs to
+type playOrderItem struct {
+	item    queue.QueueItem
+	ownerId string
+}
+
+// playOrder returns up to max upcoming queue items in the order they will be
+// played, taking one item from each user's queue in turn. A max value of less
+// than 0 returns every queued item.
+func playOrder(roomQueue queue.RoundRobinQueue, max int) []playOrderItem {
+	lists := []queue.AggregatableQueue{}
+	for _, item := range roomQueue.List() {
+		if aggQueue, ok := item.(queue.AggregatableQueue); ok {
+			lists = append(lists, aggQueue)
+		}
+	}
+
+	if roomQueue.Mode() == queue.SHARED_MODE {
+		return sharedPlayOrder(lists, max)
+	}
+
+	start := roomQueue.CurrentIndex()
+	if start > len(lists) {
+		start = 0
+	}
+	lists = append(lists[start:], lists[0:start]...)
+
+	order := []playOrderItem{}
+	for round := 0; max < 0 || len(order) < max; round++ {
+		added := false
+		for _, list := range lists {
+			items := list.List()
+			if round >= len(items) || (max >= 0 && len(order) >= max) {
+				continue
+			}
+
+			added = true
+			order = append(order, playOrderItem{
+				item:    items[round],
+				ownerId: list.UUID(),
+			})
+		}
+		if !added {
+			break
+		}
+	}
+	return order
+}
+
+// sharedPlayOrder returns up to max upcoming items from the given user
+// queues in the order they will be played in a shared queue: the first
+// item of the user queue whose first item was queued the earliest.
+func sharedPlayOrder(lists []queue.AggregatableQueue, max int) []playOrderItem {
+	items := make([][]queue.QueueItem, len(lists))
+	for idx, list := range lists {
+		items[idx] = list.List()
+	}
+
+	order := []playOrderItem{}
+	for max < 0 || len(order) < max {
+		next := -1
+		for idx := range items {
+			if len(items[idx]) == 0 {
+				continue
+			}
+			if next < 0 || queue.QueuedAt(items[idx][0]).Before(queue.QueuedAt(items[next][0])) {
+				next = idx
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		order = append(order, playOrderItem{
+			item:    items[next][0],
+			ownerId: lists[next].UUID(),
+		})
+		items[next] = items[next][1:]
+	}
+	return order
+}
+
+// fairPlayOrder returns a numbered list of up to max upcoming streams in the order
+// they will be played, taking one item from each user's queue in turn.
+func fairPlayOrder(roomQueue queue.RoundRobinQueue, max int) string {
+	output := ""
+	for idx, next := range playOrder(roomQueue, max) {
+		output += fmt.Sprintf("<br />%v. %s", idx+1, html.EscapeString(queueItemName(next.item)))
+	}
+	return output
+}
+
+// detailedPlayOrder returns a numbered list of every queued stream in the order
+// they will be played, along with each stream's owner and duration, followed by
+// the total runtime of the queue.
+func detailedPlayOrder(roomQueue queue.RoundRobinQueue, clientHandler client.SocketClientHandler) string {
+	order := playOrder(roomQueue, -1)
+	if len(order) == 0 {
+		return "<br />The queue is empty."
+	}
+
+	output := ""
+	total := time.Duration(0)
+	unknown := 0
+	for idx, next := range order {
+		owner := next.ownerId
+		if c, err := clientHandler.GetClient(next.ownerId); err == nil {
+			owner = c.GetSourceName()
+		}
+
+		duration := "--:--"
+		if s, ok := next.item.(stream.Stream); ok && s.GetDuration() > 0 {
+			d := time.Duration(s.GetDuration() * float64(time.Second))
+			total += d
+			duration = formatPlayTime(d)
+		} else {
+			unknown++
+		}
+
+		output += fmt.Sprintf("<br />%v. %s <span class='text-hl-name'>(%s)</span> [%s]", idx+1, html.EscapeString(queueItemName(next.item)), html.EscapeString(owner), duration)
+	}
+
+	output += fmt.Sprintf("<br /><br /><span class='text-hl-name'>total runtime</span>: %s", formatPlayTime(total))
+	if unknown > 0 {
+		output += fmt.Sprintf(" (excluding %v streams of unknown duration)", unknown)
+	}
+	return output
+}
+
+// queueItemName returns the name of a queued stream, or its id if it has none
+func queueItemName(item queue.QueueItem) string {
+	if s, ok := item.(stream.Stream); ok && len(s.GetName()) > 0 {
+		return s.GetName()
+	}
+	return item.UUID()
+}
+
+// formatPlayTime formats a duration as mm:ss, or h:mm:ss if an hour or longer
+func formatPlayTime(d time.Duration) string {
+	secs := int(d.Seconds())
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, (secs%3600)/60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
 // sendUserQueueSyncEvent sends a queue stacksync event only to the user requesting data
 func sendUserQueueSyncEvent(user *client.Client, sPlayback *playback.Playback) error {
 	username, hasUsername := user.GetUsername()
@@ -617,14 +1723,15 @@ func sendUserQueueSyncEvent(user *client.Client, sPlayback *playback.Playback) e
 	return nil
 }
 
-// queueItemIndex receives a list of QueueItems and an id.
+// queueItemIndex receives a list of QueueItems and an id, matching either
+// a QueueItem's UUID or its item id.
 // Returns index of QueueItem matching the given id, or a bool false.
 //
 // Breadth-first search variant of this implementation:
 // https://play.golang.org/p/48WvSd0UaB
 func queueItemIndex(id string, items []queue.QueueItem) (int, bool, error) {
 	for idx, qItem := range items {
-		if qItem.UUID() == id {
+		if queue.QueueItemMatches(qItem, id) {
 			return idx, true, nil
 		}
 	}
@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+func TestQueueListPrivateQueues(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		private bool
+		// lister is the client listing the queue
+		lister    string
+		expectErr bool
+	}{
+		{
+			name:    "room queue in a public room",
+			command: "/queue list room",
+			lister:  "bob",
+		},
+		{
+			name:      "room queue listed by a user",
+			command:   "/queue list room",
+			private:   true,
+			lister:    "bob",
+			expectErr: true,
+		},
+		{
+			name:      "all queues listed by a user",
+			command:   "/queue list all",
+			private:   true,
+			lister:    "bob",
+			expectErr: true,
+		},
+		{
+			name:    "room queue listed by an admin",
+			command: "/queue list room",
+			private: true,
+			lister:  "alice",
+		},
+		{
+			name:    "detailed play order in a public room",
+			command: "/queue list detailed",
+			lister:  "bob",
+		},
+		{
+			name:      "detailed play order listed by a user",
+			command:   "/queue list detailed",
+			private:   true,
+			lister:    "bob",
+			expectErr: true,
+		},
+		{
+			name:    "detailed play order listed by an admin",
+			command: "/queue list detailed",
+			private: true,
+			lister:  "alice",
+		},
+		{
+			name:    "own queue listed by a user",
+			command: "/queue list mine",
+			private: true,
+			lister:  "bob",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "private")
+			admin, _ := room.join("alice")
+			bob, _ := room.join("bob")
+			room.bind(rbac.ADMIN_ROLE, admin)
+			room.bind(rbac.USER_ROLE, bob)
+			room.enqueue(admin, "http://example.com/alice.mp4")
+			room.enqueue(bob, "http://example.com/bob.mp4")
+			room.playback.SetPrivateQueues(tc.private)
+
+			lister := bob
+			if tc.lister == "alice" {
+				lister = admin
+			}
+
+			result, err := room.exec(lister, tc.command)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got result %q", result)
+				}
+				if !strings.Contains(err.Error(), "queues are private") {
+					t.Fatalf("expected a private queues error, got %q", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(result, "bob.mp4") {
+				t.Fatalf("expected the listing to include %q's stream, got %q", "bob", result)
+			}
+		})
+	}
+}
+
+func TestQueueRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		// requests are the amount of streams each user requests, in turn
+		requests map[string]int
+		// expectRejected is the amount of requests rejected for each user
+		expectRejected map[string]int
+	}{
+		{
+			name:     "below the user limit",
+			requests: map[string]int{"bob": playback.MaxUserQueueRequests},
+		},
+		{
+			name:           "past the user limit",
+			requests:       map[string]int{"bob": playback.MaxUserQueueRequests + 2},
+			expectRejected: map[string]int{"bob": 2},
+		},
+		{
+			name:           "user limit is counted per user",
+			requests:       map[string]int{"bob": playback.MaxUserQueueRequests + 1, "carol": playback.MaxUserQueueRequests},
+			expectRejected: map[string]int{"bob": 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "moderated")
+			admin, adminConn := room.join("alice")
+			room.bind(rbac.ADMIN_ROLE, admin)
+
+			users := map[string]*fakeConn{}
+			for _, name := range []string{"bob", "carol"} {
+				u, conn := room.join(name)
+				room.bind(rbac.USER_ROLE, u)
+				users[name] = conn
+			}
+			room.playback.SetModerated(true)
+
+			for name, count := range tc.requests {
+				u, err := room.clientHandler.GetClient("id-" + name)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				rejected := 0
+				for i := 0; i < count; i++ {
+					if _, err := room.exec(u, fmt.Sprintf("/queue add http://example.com/%s%v.mp4", name, i)); err != nil {
+						rejected++
+					}
+				}
+				if rejected != tc.expectRejected[name] {
+					t.Fatalf("expected %v of %q's requests to be rejected, got %v", tc.expectRejected[name], name, rejected)
+				}
+			}
+
+			if requests := adminConn.Events("info_queuerequests"); len(requests) == 0 {
+				t.Fatalf("expected admins to be sent info_queuerequests")
+			}
+			for name, conn := range users {
+				if requests := conn.Events("info_queuerequests"); len(requests) > 0 {
+					t.Fatalf("expected %q not to be sent info_queuerequests, got %v events", name, len(requests))
+				}
+			}
+		})
+	}
+}
+
+// queueStreams registers streams with the given urls and pushes them to
+// the user's queue, referencing the user as the client that queued them
+func queueStreams(t *testing.T, room *testRoom, user *client.Client, urls ...string) {
+	userQueue, exists, err := playbackutil.GetUserQueue(user, room.playback.GetQueue())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !exists {
+		userQueue = queue.NewAggregatableQueue(user.UUID())
+		if err := room.playback.GetQueue().Push(userQueue); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	for _, u := range urls {
+		s, err := room.streamHandler.NewStream(u)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		s.Metadata().SetLabelledRef(room.playback.UUID(), user)
+		if _, err := room.playback.PushToQueue(userQueue, s); err != nil {
+			t.Fatalf("unable to queue %q: %v", u, err)
+		}
+	}
+}
+
+func TestQueueMigrateCurrentStream(t *testing.T) {
+	tests := []struct {
+		name string
+		// queued are the streams queued by each user, in order
+		queued  [][2]string
+		advance bool
+		// expectStartedBy is the user attributed with the
+		// current stream once alice's queue is migrated
+		expectStartedBy string
+		expectQueue     []string
+	}{
+		{
+			name:            "head item of the migrated queue is playing",
+			queued:          [][2]string{{"alice", "http://example.com/x.mp4"}, {"alice", "http://example.com/y.mp4"}},
+			advance:         true,
+			expectStartedBy: "bob",
+			expectQueue:     []string{"http://example.com/y.mp4"},
+		},
+		{
+			name:            "another user's stream is playing",
+			queued:          [][2]string{{"carol", "http://example.com/c.mp4"}, {"alice", "http://example.com/x.mp4"}, {"alice", "http://example.com/y.mp4"}},
+			advance:         true,
+			expectStartedBy: "carol",
+			expectQueue:     []string{"http://example.com/x.mp4", "http://example.com/y.mp4"},
+		},
+		{
+			name:        "nothing is playing",
+			queued:      [][2]string{{"alice", "http://example.com/x.mp4"}, {"alice", "http://example.com/y.mp4"}},
+			expectQueue: []string{"http://example.com/x.mp4", "http://example.com/y.mp4"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "migrate")
+			users := map[string]*client.Client{}
+			for _, name := range []string{"alice", "bob", "carol"} {
+				users[name], _ = room.join(name)
+			}
+			for _, q := range tc.queued {
+				queueStreams(t, room, users[q[0]], q[1])
+			}
+			if tc.advance {
+				if _, err := room.playback.AdvanceQueue(nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			bob := users["bob"]
+			if _, err := room.exec(bob, "/queue migrate id-alice"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if current, exists := room.playback.GetStream(); exists {
+				if startedBy := room.playback.StartedBy(); startedBy != tc.expectStartedBy {
+					t.Fatalf("expected the current stream to be started by %q, got %q", tc.expectStartedBy, startedBy)
+				}
+				ref, _ := current.Metadata().GetLabelledRef(room.playback.UUID())
+				if expect := "id-" + tc.expectStartedBy; ref == nil || ref.UUID() != expect {
+					t.Fatalf("expected the current stream to reference %q", expect)
+				}
+			}
+
+			if _, exists, _ := playbackutil.GetQueueForId("id-alice", room.playback.GetQueue()); exists {
+				t.Fatalf("expected the migrated queue to be removed")
+			}
+			bobQueue, exists, err := playbackutil.GetUserQueue(bob, room.playback.GetQueue())
+			if err != nil || !exists {
+				t.Fatalf("expected %q to own a queue, got error %v", "bob", err)
+			}
+			if order := itemUrls(bobQueue.List()); !reflect.DeepEqual(order, tc.expectQueue) {
+				t.Fatalf("expected %q's queue to be %v, got %v", "bob", tc.expectQueue, order)
+			}
+
+			// migrated streams may not be queued again by any user
+			noop := func([]byte, bool, error) {}
+			for _, name := range []string{"bob", "carol"} {
+				if _, err := room.playback.GetOrCreateStreamFromUrl(tc.expectQueue[0], users[name], room.streamHandler, noop); err == nil {
+					t.Fatalf("expected %q to be unable to queue migrated stream %q again", name, tc.expectQueue[0])
+				}
+			}
+		})
+	}
+}
+
+func TestClaimRestoredQueue(t *testing.T) {
+	queued := map[string][]string{
+		"alice": {"http://example.com/a.mp4", "http://example.com/b.mp4"},
+		"bob":   {"http://example.com/c.mp4"},
+	}
+
+	tests := []struct {
+		name string
+		// creator is the client that creates the room again after a restart
+		creator string
+		// joiners are the clients that join the room after it is restored
+		joiners []string
+	}{
+		{
+			name:    "creator had a saved queue",
+			creator: "bob",
+			joiners: []string{"alice"},
+		},
+		{
+			name:    "creator had no saved queue",
+			creator: "carol",
+			joiners: []string{"alice", "bob"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "restore")
+			for _, name := range []string{"alice", "bob"} {
+				user, _ := room.join(name)
+				queueStreams(t, room, user, queued[name]...)
+			}
+
+			data, err := room.playback.Snapshot()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// the restored room has new handlers, as after a
+			// restart, and every client reconnects with a new id
+			restored := newTestRoom(t, "restore")
+			for _, urls := range queued {
+				for _, u := range urls {
+					if _, err := restored.streamHandler.NewStream(u); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+			}
+			if err := restored.playbackHandler.RestoreFromSnapshot(data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			snapshot, exists := restored.playbackHandler.TakeSnapshot("restore")
+			if !exists {
+				t.Fatalf("expected a snapshot to be pending for the room")
+			}
+
+			users := map[string]*client.Client{}
+			users[tc.creator], _ = restored.reconnect(tc.creator)
+			if skipped := restored.playback.RestoreSnapshot(snapshot, users[tc.creator], restored.streamHandler); skipped != 0 {
+				t.Fatalf("expected no streams to be skipped, got %v", skipped)
+			}
+
+			// only the creator's queue is restored before anyone rejoins
+			expectQueues := 0
+			if _, exists := queued[tc.creator]; exists {
+				expectQueues = 1
+			}
+			if size := restored.playback.GetQueue().Size(); size != expectQueues {
+				t.Fatalf("expected %v restored queue(s) before anyone rejoins, got %v", expectQueues, size)
+			}
+
+			conns := map[string]*fakeConn{}
+			for _, name := range tc.joiners {
+				users[name], conns[name] = restored.reconnect(name)
+				ClaimRestoredQueue(users[name], restored.playback, restored.streamHandler)
+			}
+
+			for name, urls := range queued {
+				userQueue, exists, err := playbackutil.GetUserQueue(users[name], restored.playback.GetQueue())
+				if err != nil || !exists {
+					t.Fatalf("expected %q to own a queue, got error %v", name, err)
+				}
+				if order := itemUrls(userQueue.List()); !reflect.DeepEqual(order, urls) {
+					t.Fatalf("expected %q's queue to be %v, got %v", name, urls, order)
+				}
+				for _, item := range userQueue.List() {
+					ref, _ := item.(stream.Stream).Metadata().GetLabelledRef(restored.playback.UUID())
+					if ref == nil || ref.UUID() != users[name].UUID() {
+						t.Fatalf("expected stream %q to reference %q", item.UUID(), name)
+					}
+				}
+			}
+
+			for name, conn := range conns {
+				if syncs := len(conn.Events("queuesync")); syncs == 0 {
+					t.Fatalf("expected %q to receive a queue sync after claiming their queue", name)
+				}
+
+				// restored queues may only be claimed once
+				if claimed := restored.playback.ClaimRestoredQueue(users[name], restored.streamHandler); claimed != 0 {
+					t.Fatalf("expected %q's restored queue to be claimed once, got %v more streams", name, claimed)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueFullError(t *testing.T) {
+	room := newConfiguredTestRoom(t, "queuefull", func(h playback.PlaybackHandler) {
+		h.SetMaxRoomQueueItems(2)
+	})
+	user, _ := room.join("alice")
+	room.enqueue(user, "a", "b")
+
+	userQueue, _, err := playbackutil.GetUserQueue(user, room.playback.GetQueue())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, pushErr := room.playback.PushToQueue(userQueue, stream.NewRemoteVideoStream("c"))
+	if pushErr != playback.ErrRoomQueueFull {
+		t.Fatalf("expected %v, got %v", playback.ErrRoomQueueFull, pushErr)
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		expectCode string
+	}{
+		{
+			name:       "room queue full",
+			err:        pushErr,
+			expectCode: CMD_ERR_QUEUE_FULL,
+		},
+		{
+			name:       "user queue full",
+			err:        queue.ErrMaxQueueSizeExceeded,
+			expectCode: CMD_ERR_QUEUE_FULL,
+		},
+		{
+			name:       "any other error",
+			err:        fmt.Errorf("error: unable to queue"),
+			expectCode: CMD_ERR_UNKNOWN,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := ErrorCode(queueFullError(room.playback, tc.err)); code != tc.expectCode {
+				t.Fatalf("expected error code %q, got %q", tc.expectCode, code)
+			}
+		})
+	}
+}
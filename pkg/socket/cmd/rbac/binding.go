@@ -1,5 +1,10 @@
 package rbac
 
+import (
+	"sync"
+	"time"
+)
+
 // RoleBinding links an rbac Role to a set of Subjects
 type RoleBinding interface {
 	// AddSubject appends a new Subject to a list of Subjects bound
@@ -12,9 +17,16 @@ type RoleBinding interface {
 	// Returns true if a Subject exists in the list of bound Subjects,
 	// or false if the user is not found, or cannot be removed.
 	RemoveSubject(Subject) bool
+	// SetSubjectExpiry receives a bound Subject and a time after which
+	// its binding is no longer valid. A zero time removes the expiry.
+	// Returns a boolean (false) if the Subject is not bound.
+	SetSubjectExpiry(Subject, time.Time) bool
+	// SubjectExpiry returns the time after which a bound Subject's binding
+	// is no longer valid, or a boolean (false) if the binding does not expire.
+	SubjectExpiry(Subject) (time.Time, bool)
 	// Role returns the role bound by the roleBinding
 	Role() Role
-	// Subjects returns the Subjects bound to the roleBinding
+	// Subjects returns a copy of the Subjects bound to the roleBinding
 	Subjects() []Subject
 }
 
@@ -22,9 +34,16 @@ type RoleBinding interface {
 type RoleBindingSpec struct {
 	roleRef  Role
 	subjects []Subject
+	expiries map[string]time.Time
+
+	// mux guards subjects and expiries
+	mux sync.RWMutex
 }
 
 func (b *RoleBindingSpec) AddSubject(s Subject) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
 	for _, sub := range b.subjects {
 		if sub.UUID() == s.UUID() {
 			return false
@@ -36,6 +55,9 @@ func (b *RoleBindingSpec) AddSubject(s Subject) bool {
 }
 
 func (b *RoleBindingSpec) RemoveSubject(s Subject) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
 	idxToRemove := -1
 
 	for idx, subject := range b.subjects {
@@ -50,15 +72,49 @@ func (b *RoleBindingSpec) RemoveSubject(s Subject) bool {
 	}
 
 	b.subjects = append(b.subjects[0:idxToRemove], b.subjects[idxToRemove+1:len(b.subjects)]...)
+	delete(b.expiries, s.UUID())
 	return true
 }
 
+func (b *RoleBindingSpec) SetSubjectExpiry(s Subject, t time.Time) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	for _, subject := range b.subjects {
+		if subject.UUID() != s.UUID() {
+			continue
+		}
+
+		if t.IsZero() {
+			delete(b.expiries, s.UUID())
+		} else {
+			b.expiries[s.UUID()] = t
+		}
+		return true
+	}
+
+	return false
+}
+
+func (b *RoleBindingSpec) SubjectExpiry(s Subject) (time.Time, bool) {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	t, exists := b.expiries[s.UUID()]
+	return t, exists
+}
+
 func (b *RoleBindingSpec) Role() Role {
 	return b.roleRef
 }
 
 func (b *RoleBindingSpec) Subjects() []Subject {
-	return b.subjects
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	subjects := make([]Subject, len(b.subjects))
+	copy(subjects, b.subjects)
+	return subjects
 }
 
 // NewRoleBinding receives a Role and a slice of Subjects to
@@ -67,5 +123,6 @@ func NewRoleBinding(role Role, subjects []Subject) RoleBinding {
 	return &RoleBindingSpec{
 		roleRef:  role,
 		subjects: subjects,
+		expiries: make(map[string]time.Time),
 	}
 }
@@ -1,6 +1,10 @@
 package rbac
 
-import "strings"
+import (
+	"strings"
+	"sync"
+	"time"
+)
 
 // Authorizer authorizes a Subject to perform an action based
 // on Rules defined by Roles bound to that Subject
@@ -12,11 +16,18 @@ type Authorizer interface {
 	// Bind receives a Role and a set of Subjects and creates
 	// a link between the Subjects and the Role.
 	Bind(Role, ...Subject) bool
+	// BindWithTTL receives a Role, a time-to-live, and a set of Subjects
+	// and creates a link between the Subjects and the Role that expires
+	// once the ttl elapses. A zero ttl creates a permanent link.
+	BindWithTTL(Role, time.Duration, ...Subject) bool
 	// Bindings returns the role-bindings aggregated by the Authorizer
 	Bindings() []RoleBinding
 	// Role returns a composed Role by a given name.
 	// Returns a boolean (false) if the role does not exist.
 	Role(string) (Role, bool)
+	// RemoveExpired removes every subject whose binding has expired
+	// and returns the removed bindings.
+	RemoveExpired() []ExpiredBinding
 	// Verify verifies that a given subject has access to the
 	// resources defined by the given Rule.
 	// Returns a boolean (true) if the Rule given is contained
@@ -24,15 +35,27 @@ type Authorizer interface {
 	Verify(Subject, Rule) bool
 }
 
+// ExpiredBinding describes a Subject whose binding to a Role has expired
+type ExpiredBinding struct {
+	Role    Role
+	Subject Subject
+}
+
 // AuthorizerSpec is a RoleHandler that provides several
 // convenience methods for managing and restricting
 // command access based on a given role.
 type AuthorizerSpec struct {
 	rolesByName           map[string]Role
 	roleBindingByRoleName map[string]RoleBinding
+
+	// mux guards rolesByName and roleBindingByRoleName
+	mux sync.RWMutex
 }
 
 func (a *AuthorizerSpec) AddRole(r Role) bool {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
 	if _, exists := a.rolesByName[r.Name()]; !exists {
 		a.rolesByName[r.Name()] = r
 		return true
@@ -42,6 +65,16 @@ func (a *AuthorizerSpec) AddRole(r Role) bool {
 }
 
 func (a *AuthorizerSpec) Bind(r Role, subjects ...Subject) bool {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	a.bind(r, subjects...)
+	return true
+}
+
+// bind implements Bind without locking the authorizer,
+// and returns the binding for the given role.
+func (a *AuthorizerSpec) bind(r Role, subjects ...Subject) RoleBinding {
 	binding, exists := a.roleBindingByRoleName[r.Name()]
 	if !exists {
 		binding = NewRoleBinding(r, subjects)
@@ -51,10 +84,59 @@ func (a *AuthorizerSpec) Bind(r Role, subjects ...Subject) bool {
 	for _, s := range subjects {
 		binding.AddSubject(s)
 	}
+	return binding
+}
+
+func (a *AuthorizerSpec) BindWithTTL(r Role, ttl time.Duration, subjects ...Subject) bool {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	binding := a.bind(r, subjects...)
+	if ttl <= 0 {
+		return true
+	}
+
+	expiry := time.Now().Add(ttl)
+	for _, s := range subjects {
+		binding.SetSubjectExpiry(s, expiry)
+	}
 	return true
 }
 
+func (a *AuthorizerSpec) RemoveExpired() []ExpiredBinding {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	expired := []ExpiredBinding{}
+	now := time.Now()
+
+	for _, binding := range a.roleBindingByRoleName {
+		// collect every expired subject before removing
+		// any, as removing a subject shifts the rest
+		toRemove := []Subject{}
+		for _, s := range binding.Subjects() {
+			if expiry, expires := binding.SubjectExpiry(s); expires && !now.Before(expiry) {
+				toRemove = append(toRemove, s)
+			}
+		}
+
+		for _, s := range toRemove {
+			if binding.RemoveSubject(s) {
+				expired = append(expired, ExpiredBinding{
+					Role:    binding.Role(),
+					Subject: s,
+				})
+			}
+		}
+	}
+
+	return expired
+}
+
 func (a *AuthorizerSpec) Bindings() []RoleBinding {
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
 	bindings := []RoleBinding{}
 
 	for _, b := range a.roleBindingByRoleName {
@@ -65,6 +147,9 @@ func (a *AuthorizerSpec) Bindings() []RoleBinding {
 }
 
 func (a *AuthorizerSpec) Role(name string) (Role, bool) {
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
 	if role, exists := a.rolesByName[name]; exists {
 		return role, true
 	}
@@ -73,6 +158,9 @@ func (a *AuthorizerSpec) Role(name string) (Role, bool) {
 }
 
 func (a *AuthorizerSpec) Verify(s Subject, r Rule) bool {
+	a.mux.RLock()
+	defer a.mux.RUnlock()
+
 	subjectRoles := []Role{}
 
 	// calculate which roles the subject is bound to
@@ -85,6 +173,11 @@ func (a *AuthorizerSpec) Verify(s Subject, r Rule) bool {
 			}
 		}
 
+		// ignore bindings that have expired, but have not been removed yet
+		if expiry, expires := binding.SubjectExpiry(s); found && expires && !time.Now().Before(expiry) {
+			found = false
+		}
+
 		// given subject is bound to the role in the current binding
 		if found {
 			subjectRoles = append(subjectRoles, binding.Role())
@@ -113,17 +206,77 @@ func NewAuthorizer() Authorizer {
 
 // RuleByAction receives an action and returns the rule
 // corresponding to that action, or false if no rule is found.
+// If more than one rule matches the action, the rule whose
+// matching action is the most specific is returned (see
+// actionSpecificity), so that a narrower rule, such as one for
+// "history/clear", takes precedence over a broader one for
+// "history/*". Rules that match equally specifically are
+// resolved in favor of the rule whose name sorts first.
 func RuleByAction(bindings []RoleBinding, action string) (Rule, bool) {
+	var match Rule
+	matchSpecificity := -1
+
 	for _, binding := range bindings {
 		for _, rule := range binding.Role().Rules() {
-			for _, a := range rule.Actions() {
-				if verifyAction(a, action) {
-					return rule, true
-				}
+			_, specificity := mostSpecificAction(rule.Actions(), action)
+			if specificity < 0 || specificity < matchSpecificity {
+				continue
+			}
+			if specificity == matchSpecificity && rule.Name() >= match.Name() {
+				continue
 			}
+
+			match = rule
+			matchSpecificity = specificity
 		}
 	}
-	return nil, false
+	return match, match != nil
+}
+
+// MatchedAction receives a rule and an action and returns the
+// most specific action pattern in the rule that matches the given
+// action, or false if no pattern in the rule matches it.
+func MatchedAction(rule Rule, action string) (string, bool) {
+	matched, specificity := mostSpecificAction(rule.Actions(), action)
+	return matched, specificity >= 0
+}
+
+// mostSpecificAction returns the most specific of the given action
+// patterns that matches the requested action, along with its
+// specificity, or a specificity of -1 if no pattern matches.
+func mostSpecificAction(patterns []string, requestedAction string) (string, int) {
+	match := ""
+	matchSpecificity := -1
+	for _, a := range patterns {
+		if !verifyAction(a, requestedAction) {
+			continue
+		}
+		if specificity := actionSpecificity(a, requestedAction); specificity > matchSpecificity {
+			match = a
+			matchSpecificity = specificity
+		}
+	}
+	return match, matchSpecificity
+}
+
+// actionSpecificity ranks an action pattern matching the requested action
+// by its amount of non-wildcard segments. A pattern covering every segment
+// of the requested action, either by naming it exactly or through a trailing
+// wildcard, ranks above a pattern only matching a prefix of it.
+func actionSpecificity(existingAction, requestedAction string) int {
+	segs := strings.Split(existingAction, "/")
+	specificity := 0
+	for _, seg := range segs {
+		if seg == "*" {
+			return specificity*2 + 1
+		}
+		specificity++
+	}
+
+	if len(segs) == len(strings.Split(requestedAction, "/")) {
+		return specificity*2 + 1
+	}
+	return specificity * 2
 }
 
 func verifyAction(existingAction, requestedAction string) bool {
@@ -0,0 +1,304 @@
+package rbac
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRuleByAction(t *testing.T) {
+	viewer := NewRole("viewer", []Rule{
+		NewRule("history", []string{"history", "history/*"}),
+		NewRule("volume", []string{"volume"}),
+	})
+	admin := NewRole("admin", []Rule{
+		NewRule("historyClear", []string{"history/clear"}),
+		NewRule("volumeDefault", []string{"volume/default", "volume/default/*"}),
+		NewRule("queueAll", []string{"queue/*"}),
+		NewRule("queueAdd", []string{"queue/*"}),
+	})
+	bindings := []RoleBinding{
+		NewRoleBinding(viewer, []Subject{}),
+		NewRoleBinding(admin, []Subject{}),
+	}
+
+	tests := []struct {
+		name          string
+		action        string
+		expectRule    string
+		expectPattern string
+	}{
+		{
+			name:          "exact match",
+			action:        "history",
+			expectRule:    "history",
+			expectPattern: "history",
+		},
+		{
+			name:          "wildcard match",
+			action:        "history/10",
+			expectRule:    "history",
+			expectPattern: "history/*",
+		},
+		{
+			name:          "narrower rule wins over wildcard",
+			action:        "history/clear",
+			expectRule:    "historyClear",
+			expectPattern: "history/clear",
+		},
+		{
+			name:          "narrower rule wins over prefix",
+			action:        "volume/default/50",
+			expectRule:    "volumeDefault",
+			expectPattern: "volume/default/*",
+		},
+		{
+			name:          "prefix match",
+			action:        "volume/50",
+			expectRule:    "volume",
+			expectPattern: "volume",
+		},
+		{
+			name:          "equally specific rules resolve by name",
+			action:        "queue/add",
+			expectRule:    "queueAdd",
+			expectPattern: "queue/*",
+		},
+		{
+			name:   "no match",
+			action: "stream",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// bindings are iterated in either order by callers
+			for _, b := range [][]RoleBinding{bindings, {bindings[1], bindings[0]}} {
+				rule, exists := RuleByAction(b, tc.action)
+				if len(tc.expectRule) == 0 {
+					if exists {
+						t.Fatalf("expected no rule to match %q, got %q", tc.action, rule.Name())
+					}
+					continue
+				}
+				if !exists {
+					t.Fatalf("expected rule %q to match %q, got none", tc.expectRule, tc.action)
+				}
+				if rule.Name() != tc.expectRule {
+					t.Fatalf("expected rule %q to match %q, got %q", tc.expectRule, tc.action, rule.Name())
+				}
+
+				pattern, matched := MatchedAction(rule, tc.action)
+				if !matched || pattern != tc.expectPattern {
+					t.Fatalf("expected rule %q to match %q through %q, got %q", tc.expectRule, tc.action, tc.expectPattern, pattern)
+				}
+			}
+		})
+	}
+}
+
+// testSubject is a Subject identified by its value
+type testSubject string
+
+func (s testSubject) UUID() string { return string(s) }
+
+func TestVerifyExpiry(t *testing.T) {
+	streamControl := NewRule("streamControl", []string{"stream"})
+
+	tests := []struct {
+		name string
+		// expiry is the time, relative to now, after which the subject's
+		// binding is no longer valid, or 0 if the binding never expires
+		expiry      time.Duration
+		cleared     bool
+		expectValid bool
+	}{
+		{
+			name:        "permanent binding",
+			expectValid: true,
+		},
+		{
+			name:        "before expiry",
+			expiry:      time.Minute,
+			expectValid: true,
+		},
+		{
+			name:   "after expiry",
+			expiry: -time.Second,
+		},
+		{
+			name:        "expiry removed",
+			expiry:      -time.Second,
+			cleared:     true,
+			expectValid: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := testSubject("subject")
+			role := NewRole(DJ_ROLE, []Rule{streamControl})
+
+			authorizer := NewAuthorizer()
+			authorizer.AddRole(role)
+			authorizer.Bind(role, subject)
+
+			binding := authorizer.Bindings()[0]
+			if tc.expiry != 0 && !binding.SetSubjectExpiry(subject, time.Now().Add(tc.expiry)) {
+				t.Fatalf("expected the subject to be bound")
+			}
+			if tc.cleared {
+				binding.SetSubjectExpiry(subject, time.Time{})
+			}
+
+			if valid := authorizer.Verify(subject, streamControl); valid != tc.expectValid {
+				t.Fatalf("expected verification to be %v, got %v", tc.expectValid, valid)
+			}
+			if authorizer.Verify(testSubject("other"), streamControl) {
+				t.Fatalf("expected an unbound subject to fail verification")
+			}
+		})
+	}
+}
+
+func TestBindWithTTL(t *testing.T) {
+	streamControl := NewRule("streamControl", []string{"stream"})
+
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		wait        time.Duration
+		expectValid bool
+		expectSwept bool
+	}{
+		{
+			name:        "zero ttl never expires",
+			wait:        10 * time.Millisecond,
+			expectValid: true,
+		},
+		{
+			name:        "before expiry",
+			ttl:         time.Minute,
+			expectValid: true,
+		},
+		{
+			name:        "after expiry",
+			ttl:         time.Millisecond,
+			wait:        10 * time.Millisecond,
+			expectSwept: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			subject := testSubject("subject")
+			role := NewRole(DJ_ROLE, []Rule{streamControl})
+
+			authorizer := NewAuthorizer()
+			authorizer.AddRole(role)
+			authorizer.BindWithTTL(role, tc.ttl, subject)
+			time.Sleep(tc.wait)
+
+			if valid := authorizer.Verify(subject, streamControl); valid != tc.expectValid {
+				t.Fatalf("expected verification to be %v, got %v", tc.expectValid, valid)
+			}
+
+			expired := authorizer.RemoveExpired()
+			if swept := len(expired) == 1 && expired[0].Subject.UUID() == subject.UUID(); swept != tc.expectSwept {
+				t.Fatalf("expected the binding to be removed: %v, got removed bindings %v", tc.expectSwept, expired)
+			}
+			if bound := len(authorizer.Bindings()[0].Subjects()) == 1; bound == tc.expectSwept {
+				t.Fatalf("expected the subject to remain bound: %v, got %v", !tc.expectSwept, bound)
+			}
+		})
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		// expiries are the expiry of each bound subject, relative
+		// to now, or 0 for subjects whose binding never expires
+		expiries      []time.Duration
+		expectRemoved []string
+		expectBound   []string
+	}{
+		{
+			name:          "adjacent expired subjects",
+			expiries:      []time.Duration{-time.Second, -time.Second, time.Minute, 0},
+			expectRemoved: []string{"subject-0", "subject-1"},
+			expectBound:   []string{"subject-2", "subject-3"},
+		},
+		{
+			name:          "every subject expired",
+			expiries:      []time.Duration{-time.Second, -time.Second, -time.Second},
+			expectRemoved: []string{"subject-0", "subject-1", "subject-2"},
+			expectBound:   []string{},
+		},
+		{
+			name:          "no subject expired",
+			expiries:      []time.Duration{0, time.Minute},
+			expectRemoved: []string{},
+			expectBound:   []string{"subject-0", "subject-1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			role := NewRole(DJ_ROLE, []Rule{})
+			authorizer := NewAuthorizer()
+			authorizer.AddRole(role)
+
+			for i, expiry := range tc.expiries {
+				subject := testSubject(fmt.Sprintf("subject-%v", i))
+				authorizer.Bind(role, subject)
+				if expiry != 0 {
+					authorizer.Bindings()[0].SetSubjectExpiry(subject, time.Now().Add(expiry))
+				}
+			}
+
+			removed := []string{}
+			for _, b := range authorizer.RemoveExpired() {
+				removed = append(removed, b.Subject.UUID())
+			}
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tc.expectRemoved) {
+				t.Fatalf("expected removed subjects %v, got %v", tc.expectRemoved, removed)
+			}
+
+			bound := []string{}
+			for _, s := range authorizer.Bindings()[0].Subjects() {
+				bound = append(bound, s.UUID())
+			}
+			if !reflect.DeepEqual(bound, tc.expectBound) {
+				t.Fatalf("expected bound subjects %v, got %v", tc.expectBound, bound)
+			}
+		})
+	}
+}
+
+func TestAuthorizerConcurrentAccess(t *testing.T) {
+	streamControl := NewRule("streamControl", []string{"stream"})
+	role := NewRole(DJ_ROLE, []Rule{streamControl})
+	authorizer := NewAuthorizer()
+	authorizer.AddRole(role)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				subject := testSubject(fmt.Sprintf("subject-%v-%v", i, j))
+				authorizer.BindWithTTL(role, time.Nanosecond, subject)
+				authorizer.Bind(role, subject)
+				authorizer.Verify(subject, streamControl)
+				authorizer.RemoveExpired()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
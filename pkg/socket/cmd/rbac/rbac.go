@@ -11,6 +11,8 @@ const (
 	VIEWER_ROLE = "viewer"
 	USER_ROLE   = "user"
 	ADMIN_ROLE  = "admin"
+	// DJ_ROLE is granted temporarily to let a subject control stream playback
+	DJ_ROLE = "dj"
 )
 
 type AuthCookieDataNs struct {
@@ -0,0 +1,57 @@
+package rbac
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	BindingSweepInterval time.Duration = 10 * time.Second // amount of time to wait between sweeps for expired bindings
+)
+
+// ExpiredBindingCallback is called for every binding removed by a BindingSweeper
+type ExpiredBindingCallback func(ExpiredBinding)
+
+// BindingSweeper is an Authorizer's Garbage Collector.
+// Removes expired subject bindings every BindingSweepInterval.
+// Expired bindings are ignored by Authorizer.Verify in the meantime.
+type BindingSweeper struct {
+	onExpired ExpiredBindingCallback
+	stopChan  chan bool
+}
+
+func (s *BindingSweeper) Stop() {
+	s.stopChan <- true
+}
+
+func (s *BindingSweeper) Init(authorizer Authorizer) {
+	go sweep(s, authorizer, s.stopChan)
+}
+
+func sweep(sweeper *BindingSweeper, authorizer Authorizer, stop chan bool) {
+	for {
+		for _, b := range authorizer.RemoveExpired() {
+			log.Printf("INF RBAC SWEEPER binding for subject %q to role %q expired. Revoking...\n", b.Subject.UUID(), b.Role.Name())
+			if sweeper.onExpired != nil {
+				sweeper.onExpired(b)
+			}
+		}
+
+		select {
+		case <-stop:
+			log.Printf("INF RBAC SWEEPER BindingSweeper terminated.\n")
+			return
+		default:
+		}
+		time.Sleep(BindingSweepInterval)
+	}
+}
+
+// NewBindingSweeper receives a callback called for every
+// expired binding removed, and returns a new BindingSweeper.
+func NewBindingSweeper(onExpired ExpiredBindingCallback) *BindingSweeper {
+	return &BindingSweeper{
+		onExpired: onExpired,
+		stopChan:  make(chan bool, 1),
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+)
+
+// errReorderSourceNotFound is returned when the item to re-order
+// is not found in the queue, once any pending re-orders are applied
+var errReorderSourceNotFound = errors.New("source item not found")
+
+var reorders = &reorderBatcher{
+	pending: make(map[string]*pendingReorder),
+}
+
+// reorderMove is a request to move a queue item to a new index
+type reorderMove struct {
+	item    queue.QueueItem
+	destIdx int
+}
+
+// pendingReorder holds the re-orders requested by a user within
+// the batcher's window that have yet to be applied
+type pendingReorder struct {
+	// moves are the moves yet to be applied to each queue, in the order requested
+	moves map[queue.ReorderableQueue][]reorderMove
+	// userQueue is true if any of the coalesced re-orders
+	// changed the order of the user's own queue
+	userQueue bool
+}
+
+// reorderBatcher coalesces rapid re-orders from the same user, such as those
+// sent by drag-to-reorder interfaces. The first re-order requested by a user
+// is applied and broadcast right away. Further re-orders requested by the user
+// within the room's reorder window are applied to the queue in a single re-order,
+// followed by a single broadcast, once the window elapses.
+type reorderBatcher struct {
+	mux     sync.Mutex
+	pending map[string]*pendingReorder
+}
+
+// Order receives a user re-ordering the given queue, the room's queue or
+// the user's own queue, by moving the item matched by source to destIdx.
+// The move is validated against the queue's order once every re-order
+// pending for the user is applied. If userQueue is true, the user is also
+// sent the new order of their own queue. Caller must hold the cmd lock.
+func (b *reorderBatcher) Order(user *client.Client, sPlayback *playback.Playback, q queue.ReorderableQueue, userQueue bool, source func(queue.QueueItem) bool, destIdx int) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	pending, batched := b.pending[user.UUID()]
+	var moves []reorderMove
+	if batched {
+		moves = pending.moves[q]
+	}
+
+	order := applyMoves(q.List(), moves)
+	sourceIdx := -1
+	for idx, item := range order {
+		if source(item) {
+			sourceIdx = idx
+			break
+		}
+	}
+	if sourceIdx < 0 {
+		return errReorderSourceNotFound
+	}
+	if _, err := calculateQueueOrder(sourceIdx, destIdx, len(order)); err != nil {
+		return err
+	}
+
+	move := reorderMove{
+		item:    order[sourceIdx],
+		destIdx: destIdx,
+	}
+	if batched {
+		pending.moves[q] = append(moves, move)
+		pending.userQueue = pending.userQueue || userQueue
+		return nil
+	}
+
+	if err := applyOrder(q, applyMoves(q.List(), []reorderMove{move})); err != nil {
+		return err
+	}
+	if err := broadcastReorder(user, sPlayback, userQueue); err != nil {
+		return err
+	}
+	window := sPlayback.ReorderBroadcastWindow()
+	if window <= 0 {
+		return nil
+	}
+
+	// batch any further re-orders from the user until the window elapses
+	b.pending[user.UUID()] = &pendingReorder{
+		moves: make(map[queue.ReorderableQueue][]reorderMove),
+	}
+	time.AfterFunc(window, func() {
+		b.flush(user, sPlayback)
+	})
+	return nil
+}
+
+// flush applies the re-orders pending for the given user and broadcasts the
+// resulting order, if any re-orders were requested since the window opened
+func (b *reorderBatcher) flush(user *client.Client, sPlayback *playback.Playback) {
+	// wait for any re-order in progress to be applied
+	mux.Lock()
+	defer mux.Unlock()
+
+	b.mux.Lock()
+	pending, exists := b.pending[user.UUID()]
+	delete(b.pending, user.UUID())
+	b.mux.Unlock()
+
+	if !exists || len(pending.moves) == 0 {
+		return
+	}
+
+	for q, moves := range pending.moves {
+		if err := applyOrder(q, applyMoves(q.List(), moves)); err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to apply queue re-orders by client %q: %v", user.UUID(), err)
+		}
+	}
+
+	if err := broadcastReorder(user, sPlayback, pending.userQueue); err != nil {
+		log.Printf("ERR SOCKET CLIENT unable to broadcast queue re-order by client %q: %v", user.UUID(), err)
+	}
+}
+
+// applyMoves returns the order of the given items once each move is applied
+// in turn. Moves of items that are no longer in the list are skipped.
+func applyMoves(items []queue.QueueItem, moves []reorderMove) []queue.QueueItem {
+	order := append([]queue.QueueItem{}, items...)
+	for _, m := range moves {
+		sourceIdx := -1
+		for idx, item := range order {
+			if queueItemId(item) == queueItemId(m.item) {
+				sourceIdx = idx
+				break
+			}
+		}
+		if sourceIdx < 0 {
+			continue
+		}
+
+		newOrder, err := calculateQueueOrder(sourceIdx, m.destIdx, len(order))
+		if err != nil {
+			continue
+		}
+
+		// items left out of the new order keep their order after it
+		moved := make([]queue.QueueItem, 0, len(order))
+		seen := make(map[int]bool)
+		for _, idx := range newOrder {
+			moved = append(moved, order[idx])
+			seen[idx] = true
+		}
+		for idx, item := range order {
+			if !seen[idx] {
+				moved = append(moved, item)
+			}
+		}
+		order = moved
+	}
+	return order
+}
+
+// applyOrder re-orders the given queue to match the order of the given items
+// in a single re-order. Items added to the queue since keep their place after
+// the ordered items.
+func applyOrder(q queue.ReorderableQueue, order []queue.QueueItem) error {
+	indices := make(map[string]int)
+	for idx, item := range q.List() {
+		indices[queueItemId(item)] = idx
+	}
+
+	newOrder := make([]int, 0, len(order))
+	for _, item := range order {
+		if idx, exists := indices[queueItemId(item)]; exists {
+			newOrder = append(newOrder, idx)
+		}
+	}
+	return q.Reorder(newOrder)
+}
+
+func broadcastReorder(user *client.Client, sPlayback *playback.Playback, userQueue bool) error {
+	if userQueue {
+		if err := sendUserQueueSyncEvent(user, sPlayback); err != nil {
+			return err
+		}
+	}
+	return sendQueueReorderedEvent(user, sPlayback)
+}
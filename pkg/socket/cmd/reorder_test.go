@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
+)
+
+func TestReorderBatching(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   time.Duration
+		commands []string
+		// expectImmediate is the order of the user's queue
+		// once the commands are run, before the window elapses
+		expectImmediate []string
+		expect          []string
+		expectSyncs     int
+	}{
+		{
+			name:            "single re-order is applied right away",
+			window:          50 * time.Millisecond,
+			commands:        []string{"/queue order mine d 0"},
+			expectImmediate: []string{"d", "a", "b", "c"},
+			expect:          []string{"d", "a", "b", "c"},
+			expectSyncs:     1,
+		},
+		{
+			name:            "rapid re-orders are applied together",
+			window:          50 * time.Millisecond,
+			commands:        []string{"/queue order mine d 0", "/queue order mine a 3", "/queue order mine b 0"},
+			expectImmediate: []string{"d", "a", "b", "c"},
+			expect:          []string{"b", "d", "c", "a"},
+			expectSyncs:     2,
+		},
+		{
+			name:            "re-order back to the original order",
+			window:          50 * time.Millisecond,
+			commands:        []string{"/queue order mine a 2", "/queue order mine a 0"},
+			expectImmediate: []string{"b", "c", "a", "d"},
+			expect:          []string{"a", "b", "c", "d"},
+			expectSyncs:     2,
+		},
+		{
+			name:            "no window",
+			commands:        []string{"/queue order mine d 0", "/queue order mine a 3", "/queue order mine b 0"},
+			expectImmediate: []string{"b", "d", "c", "a"},
+			expect:          []string{"b", "d", "c", "a"},
+			expectSyncs:     3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newConfiguredTestRoom(t, "reorder", func(h playback.PlaybackHandler) {
+				h.SetReorderBroadcastWindow(tc.window)
+			})
+			user, conn := room.join("alice")
+			room.enqueue(user, "a", "b", "c", "d")
+			conn.Reset()
+
+			for _, command := range tc.commands {
+				if _, err := room.exec(user, command); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			// batched re-orders are applied while holding mux
+			mux.Lock()
+			userQueue, _, err := playbackutil.GetUserQueue(user, room.playback.GetQueue())
+			var order []string
+			if err == nil {
+				order = itemUrls(userQueue.List())
+			}
+			mux.Unlock()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(order, tc.expectImmediate) {
+				t.Fatalf("expected the queue order %v before the window elapses, got %v", tc.expectImmediate, order)
+			}
+
+			time.Sleep(tc.window + 50*time.Millisecond)
+			mux.Lock()
+			order = itemUrls(userQueue.List())
+			mux.Unlock()
+			if !reflect.DeepEqual(order, tc.expect) {
+				t.Fatalf("expected the queue order %v, got %v", tc.expect, order)
+			}
+			if syncs := len(conn.Events("queuesync")); syncs != tc.expectSyncs {
+				t.Fatalf("expected %v queue syncs, got %v", tc.expectSyncs, syncs)
+			}
+		})
+	}
+}
+
+func TestReorderBatchingInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{
+			name:    "unknown item",
+			command: "/queue order mine e 0",
+		},
+		{
+			name:    "item already at the index once pending re-orders are applied",
+			command: "/queue order mine a 3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newConfiguredTestRoom(t, "reorder", func(h playback.PlaybackHandler) {
+				h.SetReorderBroadcastWindow(time.Minute)
+			})
+			user, _ := room.join("alice")
+			room.enqueue(user, "a", "b", "c", "d")
+			defer reorders.flush(user, room.playback)
+
+			// open a window, then request a pending re-order
+			for _, command := range []string{"/queue order mine d 0", "/queue order mine a 3"} {
+				if _, err := room.exec(user, command); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if result, err := room.exec(user, tc.command); err == nil {
+				t.Fatalf("expected an error, got result %q", result)
+			}
+		})
+	}
+}
+
+// itemUrls returns the stream urls of the given queued items
+func itemUrls(items []queue.QueueItem) []string {
+	urls := []string{}
+	for _, item := range items {
+		urls = append(urls, item.UUID())
+	}
+	return urls
+}
+
+func TestPlaybackOrderMove(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		current   int
+		sourceIdx int
+		position  int
+		expect    []int
+	}{
+		{
+			name:      "move to position 1",
+			size:      3,
+			current:   1,
+			sourceIdx: 0,
+			position:  1,
+			expect:    []int{0, 1, 2},
+		},
+		{
+			name:      "move to the last position",
+			size:      3,
+			current:   1,
+			sourceIdx: 1,
+			position:  3,
+			expect:    []int{2, 0, 1},
+		},
+		{
+			name:      "move to a middle position",
+			size:      4,
+			current:   2,
+			sourceIdx: 0,
+			position:  2,
+			expect:    []int{2, 0, 3, 1},
+		},
+		{
+			name:      "current index wrapped past the end",
+			size:      3,
+			current:   3,
+			sourceIdx: 2,
+			position:  1,
+			expect:    []int{2, 0, 1},
+		},
+		{
+			name:      "current index wrapped past the end, moved to the last position",
+			size:      3,
+			current:   3,
+			sourceIdx: 0,
+			position:  3,
+			expect:    []int{1, 2, 0},
+		},
+		{
+			name:      "single queue",
+			size:      1,
+			sourceIdx: 0,
+			position:  1,
+			expect:    []int{0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if order := playbackOrderMove(tc.size, tc.current, tc.sourceIdx, tc.position); !reflect.DeepEqual(order, tc.expect) {
+				t.Fatalf("expected the order %v, got %v", tc.expect, order)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
@@ -16,8 +19,11 @@ type RoleCmd struct {
 
 const (
 	ROLE_NAME        = "role"
-	ROLE_DESCRIPTION = "add, replace, or remove roles for a subject (requires rbac to be enabled)"
-	ROLE_USAGE       = "Usage: /" + ROLE_NAME + " &lt;add | set | remove&gt; &lt;role&gt; &lt;subject&gt;"
+	ROLE_DESCRIPTION = "add, replace, or remove roles for a subject, or test which rule authorizes an action (requires rbac to be enabled)"
+	ROLE_USAGE       = "Usage: /" + ROLE_NAME + " &lt;add | set | remove&gt; &lt;role&gt; &lt;subject&gt; | grant stream &lt;subject&gt; &lt;minutes&gt; | test &lt;action&gt;"
+
+	// ROLE_GRANT_MAX_MINUTES is the maximum amount of time a role may be granted for
+	ROLE_GRANT_MAX_MINUTES = 24 * 60
 )
 
 func (h *RoleCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
@@ -28,6 +34,55 @@ func (h *RoleCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *
 		return h.usage, nil
 	}
 
+	if args[0] == "test" {
+		if len(args) < 2 {
+			return h.usage, nil
+		}
+
+		authorizer := cmdHandler.Authorizer()
+		if authorizer == nil {
+			return "", fmt.Errorf("authorizer not enabled")
+		}
+
+		return testAction(authorizer, user, strings.Join(args[1:], "/")), nil
+	}
+
+	if args[0] == "grant" {
+		if len(args) < 4 {
+			return h.usage, nil
+		}
+		if args[1] != "stream" {
+			return "", fmt.Errorf("error: only control of the stream (\"stream\") may be granted")
+		}
+
+		minutes, err := strconv.Atoi(args[3])
+		if err != nil || minutes <= 0 || minutes > ROLE_GRANT_MAX_MINUTES {
+			return "", fmt.Errorf("error: the grant duration must be between 1 and %v minutes", ROLE_GRANT_MAX_MINUTES)
+		}
+
+		authorizer := cmdHandler.Authorizer()
+		if authorizer == nil {
+			return "", fmt.Errorf("authorizer not enabled")
+		}
+
+		role, exists := authorizer.Role(rbac.DJ_ROLE)
+		if !exists {
+			return "", fmt.Errorf("error: role %q not found", rbac.DJ_ROLE)
+		}
+
+		subject, exists := findSubjectByName(user, clientHandler, args[2])
+		if !exists {
+			return "", fmt.Errorf("error: unable to find subject %q in your namespace", args[2])
+		}
+
+		duration := time.Duration(minutes) * time.Minute
+		if err := grantRole(authorizer, role, subject, duration); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("subject %q may control the stream for the next %v", args[2], duration), nil
+	}
+
 	if len(args) < 3 {
 		return h.usage, nil
 	}
@@ -226,3 +281,127 @@ func addRole(authorizer rbac.Authorizer, role rbac.Role, subject *client.Client)
 	})
 	return nil
 }
+
+// grantRole binds a subject to a role until the given duration has elapsed,
+// after which the binding is revoked by the authorizer's BindingSweeper.
+// Granting a role to a subject that is already temporarily bound to it
+// extends its binding.
+func grantRole(authorizer rbac.Authorizer, role rbac.Role, subject *client.Client, duration time.Duration) error {
+	binding, bound := roleBinding(authorizer, role, subject)
+	if bound {
+		if _, expires := binding.SubjectExpiry(subject); !expires {
+			return fmt.Errorf("error: subject %q is already bound to role %q", subject.GetUsernameOrId(), role.Name())
+		}
+	}
+
+	authorizer.BindWithTTL(role, duration, subject)
+	if !bound {
+		subject.BroadcastSystemMessageTo(fmt.Sprintf("You have been assigned to the %q role", role.Name()))
+		subject.BroadcastAll("info_userlistupdated", &client.Response{
+			Id: subject.UUID(),
+		})
+	}
+	subject.BroadcastSystemMessageTo(fmt.Sprintf("Your %q role expires in %v", role.Name(), duration))
+	return nil
+}
+
+// NotifyExpiredBinding returns a callback that notifies the subject
+// of an expired binding, and its room, that the binding was revoked.
+func NotifyExpiredBinding(clientHandler client.SocketClientHandler) rbac.ExpiredBindingCallback {
+	return func(b rbac.ExpiredBinding) {
+		subject, err := clientHandler.GetClient(b.Subject.UUID())
+		if err != nil {
+			return
+		}
+
+		subject.BroadcastSystemMessageTo(fmt.Sprintf("Your %q role has expired", b.Role.Name()))
+		subject.BroadcastAll("info_userlistupdated", &client.Response{
+			Id: subject.UUID(),
+		})
+		subject.BroadcastAuthRequestTo("cookie")
+	}
+}
+
+// roleBinding returns the binding for the given role,
+// and whether the given subject is bound by it.
+func roleBinding(authorizer rbac.Authorizer, role rbac.Role, subject *client.Client) (rbac.RoleBinding, bool) {
+	for _, b := range authorizer.Bindings() {
+		if b.Role().Name() != role.Name() {
+			continue
+		}
+
+		for _, s := range b.Subjects() {
+			if s.UUID() == subject.UUID() {
+				return b, true
+			}
+		}
+		return b, false
+	}
+
+	return nil, false
+}
+
+// findSubjectByName returns the client in the user's
+// namespace with the given username, if one exists.
+func findSubjectByName(user *client.Client, clientHandler client.SocketClientHandler, name string) (*client.Client, bool) {
+	for _, c := range user.Connections() {
+		cl, err := clientHandler.GetClient(c.UUID())
+		if err != nil {
+			continue
+		}
+
+		if uName, hasName := cl.GetUsername(); hasName && uName == name {
+			return cl, true
+		}
+	}
+
+	return nil, false
+}
+
+// testAction reports whether the given subject is authorized to perform an action,
+// along with the rule and action pattern that matched it, and the subject's roles
+// that contain that rule.
+func testAction(authorizer rbac.Authorizer, subject *client.Client, action string) string {
+	output := fmt.Sprintf("Action %q:<br />", action)
+
+	rule, exists := rbac.RuleByAction(authorizer.Bindings(), action)
+	if !exists {
+		output += "<br /><span class='text-hl-name'>allowed</span>: false"
+		output += "<br /><span class='text-hl-name'>rule</span>: [none]"
+		return output
+	}
+
+	matched, _ := rbac.MatchedAction(rule, action)
+
+	roles := []string{}
+	for _, b := range authorizer.Bindings() {
+		bound := false
+		for _, s := range b.Subjects() {
+			if s.UUID() == subject.UUID() {
+				bound = true
+				break
+			}
+		}
+		if !bound {
+			continue
+		}
+
+		for _, r := range b.Role().Rules() {
+			if r.Name() == rule.Name() {
+				roles = append(roles, b.Role().Name())
+				break
+			}
+		}
+	}
+
+	viaRoles := "[none]"
+	if len(roles) > 0 {
+		viaRoles = strings.Join(roles, ", ")
+	}
+
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>allowed</span>: %v", authorizer.Verify(subject.Connection(), rule))
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>rule</span>: %s", rule.Name())
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>matched action</span>: %s", matched)
+	output += fmt.Sprintf("<br /><span class='text-hl-name'>via roles</span>: %s", viaRoles)
+	return output
+}
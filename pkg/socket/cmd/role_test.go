@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+)
+
+func TestGrantRoleSweep(t *testing.T) {
+	tests := []struct {
+		name         string
+		duration     time.Duration
+		expectRevoke bool
+	}{
+		{
+			name:         "expired grant",
+			duration:     time.Millisecond,
+			expectRevoke: true,
+		},
+		{
+			name:     "active grant",
+			duration: time.Minute,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "grant")
+			dj, djConn := room.join("alice")
+			_, roomConn := room.join("bob")
+
+			role := rbac.NewRole(rbac.DJ_ROLE, []rbac.Rule{})
+			authorizer := rbac.NewAuthorizer()
+			authorizer.AddRole(role)
+
+			if err := grantRole(authorizer, role, dj, tc.duration); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, bound := roleBinding(authorizer, role, dj); !bound {
+				t.Fatalf("expected %q to be bound to role %q", "alice", role.Name())
+			}
+			if _, expires := authorizer.Bindings()[0].SubjectExpiry(dj); !expires {
+				t.Fatalf("expected the granted binding to expire")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			djConn.Reset()
+			roomConn.Reset()
+
+			sweeper := rbac.NewBindingSweeper(NotifyExpiredBinding(room.clientHandler))
+			sweeper.Init(authorizer)
+			defer sweeper.Stop()
+
+			// the sweeper removes expired bindings as soon as it starts
+			deadline := time.Now().Add(time.Second)
+			for len(roomConn.Events("info_userlistupdated")) == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			revoked := len(roomConn.Events("info_userlistupdated")) > 0
+			if revoked != tc.expectRevoke {
+				t.Fatalf("expected the room to be sent info_userlistupdated: %v, got %v", tc.expectRevoke, revoked)
+			}
+			if _, bound := roleBinding(authorizer, role, dj); bound == tc.expectRevoke {
+				t.Fatalf("expected %q to remain bound: %v, got %v", "alice", !tc.expectRevoke, bound)
+			}
+			if !tc.expectRevoke {
+				return
+			}
+
+			notified := false
+			for _, m := range djConn.Events("chatmessage") {
+				if strings.Contains(m.Message, "has expired") {
+					notified = true
+				}
+			}
+			if !notified {
+				t.Fatalf("expected %q to be told their role has expired", "alice")
+			}
+		})
+	}
+}
+
+func TestDefaultRolesRuleByAction(t *testing.T) {
+	authorizer := rbac.NewAuthorizer()
+	AddDefaultRoles(authorizer)
+
+	bindings := []rbac.RoleBinding{}
+	for _, name := range []string{rbac.VIEWER_ROLE, rbac.USER_ROLE, rbac.DJ_ROLE, rbac.ADMIN_ROLE} {
+		role, exists := authorizer.Role(name)
+		if !exists {
+			t.Fatalf("role %q not found", name)
+		}
+		bindings = append(bindings, rbac.NewRoleBinding(role, []rbac.Subject{}))
+	}
+
+	// actions granted through a single rule resolve to that
+	// rule, as they did before overlapping rules were ranked
+	for _, b := range bindings {
+		for _, rule := range b.Role().Rules() {
+			for _, pattern := range rule.Actions() {
+				action := strings.Replace(pattern, "*", "arg", -1)
+
+				matching := map[string]bool{}
+				for _, other := range bindings {
+					for _, r := range other.Role().Rules() {
+						if _, matched := rbac.MatchedAction(r, action); matched {
+							matching[r.Name()] = true
+						}
+					}
+				}
+				if len(matching) > 1 {
+					continue
+				}
+
+				resolved, exists := rbac.RuleByAction(bindings, action)
+				if !exists || resolved.Name() != rule.Name() {
+					t.Fatalf("expected action %q to resolve to rule %q", action, rule.Name())
+				}
+			}
+		}
+	}
+
+	// actions granted through overlapping rules resolve
+	// to the rule granting the most specific action
+	tests := []struct {
+		action     string
+		expectRule string
+	}{
+		{
+			action:     "queue/add/url",
+			expectRule: "add streams to the queue",
+		},
+		{
+			action:     "queue/list/room",
+			expectRule: "list items in the queue",
+		},
+		{
+			action:     "queue/list/user/alice",
+			expectRule: "list the items in another user's queue",
+		},
+		{
+			action:     "volume/50",
+			expectRule: "update your volume",
+		},
+		{
+			action:     "volume/default/50",
+			expectRule: "update the room's default volume",
+		},
+		{
+			action:     "history/10",
+			expectRule: "list the streams recently played in the room",
+		},
+		{
+			action:     "history/clear",
+			expectRule: "clear the room's play history",
+		},
+		{
+			action:     "clear/me",
+			expectRule: "clear your own chat window",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.action, func(t *testing.T) {
+			rule, exists := rbac.RuleByAction(bindings, tc.action)
+			if !exists {
+				t.Fatalf("expected rule %q to match %q, got none", tc.expectRule, tc.action)
+			}
+			if rule.Name() != tc.expectRule {
+				t.Fatalf("expected rule %q to match %q, got %q", tc.expectRule, tc.action, rule.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,671 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+	sockutil "github.com/juanvallejo/streaming-server/pkg/socket/util"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+	"github.com/juanvallejo/streaming-server/pkg/validation"
+)
+
+type RoomCmd struct {
+	*Command
+}
+
+const (
+	ROOM_NAME        = "room"
+	ROOM_DESCRIPTION = "displays information about the current room (invite, admins), or sets its welcome message, stream filters, max play time, load timeout, auto-advance behavior, and whether other rooms may mirror it, or resets its playback"
+	ROOM_USAGE       = "Usage: /" + ROOM_NAME + " &lt;invite | admins | welcome [message|off] | block [remove] &lt;domain|videoId&gt; | allow [remove] &lt;domain|videoId&gt; | filters | report | log [count] | reset | reapstatus | keepalive | maxplay [minutes|off] | loadtimeout [seconds|off] | userlimit [items|off] | advanceneedsadmin [on|off] | autoload [on|off] | privatequeues [on|off] | moderate [on|off] | noseekahead [on|off] | onempty [stop|loop|home] | mirroring [on|off]&gt;"
+
+	// ROOM_LOG_DEFAULT_COUNT is the amount of commands listed by "log" by default
+	ROOM_LOG_DEFAULT_COUNT = 10
+)
+
+var (
+	room_aliases = []string{}
+)
+
+func (h *RoomCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) == 0 {
+		return h.usage, nil
+	}
+
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to use this command")
+	}
+
+	switch args[0] {
+	case "invite":
+		req := user.Connection().Request()
+		if req == nil || len(req.Host) == 0 {
+			return "", fmt.Errorf("error: unable to determine the server's host for your connection")
+		}
+
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+
+		inviteUrl := &url.URL{
+			Scheme: scheme,
+			Host:   req.Host,
+			Path:   sockutil.ROOM_URL_SEGMENT + userRoom.Name(),
+		}
+
+		link := inviteUrl.String()
+		return fmt.Sprintf("Invite others to this room with the following link:<br /><a href=%q target='_blank'>%s</a>", link, link), nil
+	case "admins":
+		authorizer := cmdHandler.Authorizer()
+		if authorizer == nil {
+			return "", fmt.Errorf("error: this server does not have role-based access control enabled")
+		}
+
+		admins := []string{}
+		for _, b := range authorizer.Bindings() {
+			if b.Role().Name() != rbac.ADMIN_ROLE {
+				continue
+			}
+
+			for _, subject := range b.Subjects() {
+				c, err := clientHandler.GetClient(subject.UUID())
+				if err != nil {
+					continue
+				}
+				if ns, exists := c.Namespace(); !exists || ns.Name() != userRoom.Name() {
+					continue
+				}
+				admins = append(admins, c.GetUsernameOrId())
+			}
+		}
+
+		if len(admins) > 0 {
+			output := "Room admins:<br />"
+			for _, name := range admins {
+				output += fmt.Sprintf("<br /><span class='text-hl-name'>%s</span>", html.EscapeString(name))
+			}
+			return output, nil
+		}
+
+		output := "This room currently has no admins."
+		if sPlayback, exists := playbackHandler.PlaybackByNamespace(userRoom); exists {
+			output += fmt.Sprintf(" A new admin will be picked %s.", adminPickerETA(sPlayback))
+		}
+		return output, nil
+	case "welcome":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			msg, exists := sPlayback.WelcomeMessage()
+			if !exists {
+				return "This room has no welcome message set.", nil
+			}
+			return fmt.Sprintf("This room's welcome message is: %s", msg), nil
+		}
+
+		if len(args) == 2 && args[1] == "off" {
+			sPlayback.SetWelcomeMessage("")
+			return "Clearing the room's welcome message...", nil
+		}
+
+		msg := strings.TrimSpace(strings.Join(args[1:], " "))
+		if err := validation.ValidateWelcomeMessage(msg); err != nil {
+			return "", err
+		}
+
+		sPlayback.SetWelcomeMessage(html.EscapeString(msg))
+		return "Setting the room's welcome message...", nil
+	case "block", "allow":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			return h.usage, nil
+		}
+
+		list := "blocklist"
+		if args[0] == "allow" {
+			list = "allowlist"
+		}
+
+		filter := sPlayback.Filter()
+		if args[1] == "remove" {
+			if len(args) < 3 {
+				return h.usage, nil
+			}
+
+			var removed bool
+			if args[0] == "block" {
+				removed = filter.Unblock(args[2])
+			} else {
+				removed = filter.Unallow(args[2])
+			}
+			if !removed {
+				return "", fmt.Errorf("error: %q is not in the room's %s", args[2], list)
+			}
+
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has removed %q from the room's %s", user.GetUsernameOrId(), args[2], list))
+			return fmt.Sprintf("removing %q from the room's %s...", args[2], list), nil
+		}
+
+		if args[0] == "block" {
+			filter.Block(args[1])
+		} else {
+			filter.Allow(args[1])
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has added %q to the room's %s", user.GetUsernameOrId(), args[1], list))
+		return fmt.Sprintf("adding %q to the room's %s...", args[1], list), nil
+	case "maxplay":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			maxPlayTime, exists := sPlayback.MaxPlayTime()
+			if !exists {
+				return "This room has no maximum play time set.", nil
+			}
+			return fmt.Sprintf("Streams in this room are skipped after playing for %v.", time.Duration(maxPlayTime)*time.Second), nil
+		}
+
+		if args[1] == "off" || args[1] == "0" {
+			sPlayback.SetMaxPlayTime(0)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has removed the room's maximum play time", user.GetUsernameOrId()))
+			return "Removing the room's maximum play time...", nil
+		}
+
+		minutes, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || minutes <= 0 {
+			return "", fmt.Errorf("error: the maximum play time must be a positive amount of minutes")
+		}
+
+		maxPlayTime := time.Duration(minutes * float64(time.Minute)).Round(time.Second)
+		if maxPlayTime < time.Second {
+			return "", fmt.Errorf("error: the maximum play time must be at least one second")
+		}
+
+		sPlayback.SetMaxPlayTime(int(maxPlayTime.Seconds()))
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room's maximum play time to %v", user.GetUsernameOrId(), maxPlayTime))
+		return fmt.Sprintf("Streams will now be skipped after playing for %v.", maxPlayTime), nil
+	case "loadtimeout":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			timeout, exists := sPlayback.LoadTimeout()
+			if !exists {
+				return "This room has no load timeout set.", nil
+			}
+			return fmt.Sprintf("Streams in this room are skipped if they fail to start within %v.", timeout), nil
+		}
+
+		if args[1] == "off" || args[1] == "0" {
+			sPlayback.SetLoadTimeout(0)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has removed the room's load timeout", user.GetUsernameOrId()))
+			return "Removing the room's load timeout...", nil
+		}
+
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil || seconds <= 0 {
+			return "", fmt.Errorf("error: the load timeout must be a positive amount of seconds")
+		}
+
+		timeout := time.Duration(seconds) * time.Second
+		sPlayback.SetLoadTimeout(timeout)
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room's load timeout to %v", user.GetUsernameOrId(), timeout))
+		return fmt.Sprintf("Streams will now be skipped if they fail to start within %v.", timeout), nil
+	case "userlimit":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			limit, exists := sPlayback.UserQueueLimit()
+			if !exists {
+				return "This room has no per-user queue limit set.", nil
+			}
+			return fmt.Sprintf("Users in this room may queue up to %v items each.", limit), nil
+		}
+
+		if args[1] == "off" || args[1] == "0" {
+			sPlayback.SetUserQueueLimit(0)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has removed the room's per-user queue limit", user.GetUsernameOrId()))
+			return "Removing the room's per-user queue limit...", nil
+		}
+
+		limit, err := strconv.Atoi(args[1])
+		if err != nil || limit <= 0 {
+			return "", fmt.Errorf("error: the per-user queue limit must be a positive amount of items")
+		}
+		if err := sPlayback.SetUserQueueLimit(limit); err != nil {
+			return "", err
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has limited each user's queue to %v items", user.GetUsernameOrId(), limit))
+		return fmt.Sprintf("Users may now queue up to %v items each.", limit), nil
+	case "advanceneedsadmin":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			state := "off"
+			if sPlayback.AdvanceNeedsAdmin() {
+				state = "on"
+			}
+			return fmt.Sprintf("Pausing auto-advance while no admin is present is %s for this room.", state), nil
+		}
+
+		if cmdHandler.Authorizer() == nil {
+			return "", fmt.Errorf("error: this server does not have role-based access control enabled")
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetAdvanceNeedsAdmin(true)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has paused auto-advancing the queue while no admin is present", user.GetUsernameOrId()))
+			return "The queue will only auto-advance while an admin is present in the room.", nil
+		case "off":
+			sPlayback.SetAdvanceNeedsAdmin(false)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has allowed the queue to auto-advance while no admin is present", user.GetUsernameOrId()))
+			return "The queue will auto-advance regardless of admin presence.", nil
+		}
+		return h.usage, nil
+	case "autoload":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			state := "off"
+			if sPlayback.Autoload() {
+				state = "on"
+			}
+			return fmt.Sprintf("Loading the current stream for new joiners is %s for this room.", state), nil
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetAutoload(true)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has enabled loading the current stream for new joiners", user.GetUsernameOrId()))
+			return "New joiners will load the current stream when they join the room.", nil
+		case "off":
+			sPlayback.SetAutoload(false)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has disabled loading the current stream for new joiners", user.GetUsernameOrId()))
+			return "New joiners will no longer load the current stream when they join the room.", nil
+		}
+		return h.usage, nil
+	case "privatequeues":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			state := "off"
+			if sPlayback.PrivateQueues() {
+				state = "on"
+			}
+			return fmt.Sprintf("Private queues are %s for this room.", state), nil
+		}
+
+		if cmdHandler.Authorizer() == nil {
+			return "", fmt.Errorf("error: this server does not have role-based access control enabled")
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetPrivateQueues(true)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has made queues private. Only admins can list another user's queue", user.GetUsernameOrId()))
+			return "Only admins can now list another user's queue.", nil
+		case "off":
+			sPlayback.SetPrivateQueues(false)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has made queues public. Anyone can list another user's queue", user.GetUsernameOrId()))
+			return "Anyone can now list another user's queue.", nil
+		}
+		return h.usage, nil
+	case "moderate":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			state := "off"
+			if sPlayback.Moderated() {
+				state = "on"
+			}
+			return fmt.Sprintf("Moderation is %s for this room.", state), nil
+		}
+
+		if cmdHandler.Authorizer() == nil {
+			return "", fmt.Errorf("error: this server does not have role-based access control enabled")
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetModerated(true)
+			sendQueueRequestsEvent(cmdHandler, user, clientHandler, sPlayback)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has turned on moderation. Streams added to the queue must be approved by an admin", user.GetUsernameOrId()))
+			return "Streams added to the queue by non-admins must now be approved.", nil
+		case "off":
+			sPlayback.SetModerated(false)
+			sendQueueRequestsEvent(cmdHandler, user, clientHandler, sPlayback)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has turned off moderation. Streams are added to the queue directly", user.GetUsernameOrId()))
+			return "Streams are now added to the queue directly. Pending requests can still be reviewed with \"/queue requests\".", nil
+		}
+		return h.usage, nil
+	case "noseekahead":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			state := "off"
+			if sPlayback.NoSeekAhead() {
+				state = "on"
+			}
+			return fmt.Sprintf("No-seek-ahead mode is %s for this room.", state), nil
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetNoSeekAhead(true)
+		case "off":
+			sPlayback.SetNoSeekAhead(false)
+		default:
+			return h.usage, nil
+		}
+
+		res := &client.Response{
+			Id:   user.UUID(),
+			From: user.GetUsernameOrId(),
+		}
+		if err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra); err != nil {
+			return "", err
+		}
+		user.BroadcastAll("streamsync", res)
+
+		if sPlayback.NoSeekAhead() {
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has turned on no-seek-ahead mode. Seeking ahead of the room is disabled", user.GetUsernameOrId()))
+			return "Clients seeking ahead of the room will now be snapped back to it.", nil
+		}
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has turned off no-seek-ahead mode", user.GetUsernameOrId()))
+		return "Clients may now seek ahead of the room.", nil
+	case "onempty":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			return fmt.Sprintf("Once its queue runs out, this room will %s.", onEmptyDescription(sPlayback.OnEmpty())), nil
+		}
+
+		if err := sPlayback.SetOnEmpty(args[1]); err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room to %s once its queue runs out", user.GetUsernameOrId(), onEmptyDescription(sPlayback.OnEmpty())))
+		return fmt.Sprintf("Once its queue runs out, this room will now %s.", onEmptyDescription(sPlayback.OnEmpty())), nil
+	case "mirroring":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		if len(args) < 2 {
+			if sPlayback.MirroringAllowed() {
+				return "Other rooms may mirror this room.", nil
+			}
+			return "Other rooms may not mirror this room.", nil
+		}
+
+		switch args[1] {
+		case "on":
+			sPlayback.SetMirroringAllowed(true)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has allowed other rooms to mirror this room", user.GetUsernameOrId()))
+			return "Other rooms may now mirror this room with \"/stream mirror " + userRoom.Name() + "\".", nil
+		case "off":
+			sPlayback.SetMirroringAllowed(false)
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has stopped other rooms from mirroring this room", user.GetUsernameOrId()))
+			return "Other rooms may no longer mirror this room. Rooms already mirroring it have stopped.", nil
+		}
+		return h.usage, nil
+	case "report":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		report := sPlayback.Report()
+
+		// send the full report to the requesting client, so that it can be exported
+		res := &client.Response{
+			Id:   user.UUID(),
+			From: user.GetUsernameOrId(),
+		}
+		if err := sockutil.SerializeIntoResponse(report, &res.Extra); err != nil {
+			return "", err
+		}
+		user.BroadcastTo("info_roomreport", res)
+
+		output := "Room report:<br />"
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>streams played</span>: %v", len(report.Streams))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>total watch time</span>: %v", time.Duration(report.WatchTime)*time.Second)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>peak users</span>: %v", report.PeakUsers)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>chat messages</span>: %v", report.ChatMessages)
+
+		names := []string{}
+		for name := range report.QueueContributions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		output += "<br /><span class='text-hl-name'>queue contributions</span>:"
+		if len(names) == 0 {
+			output += " none"
+		}
+		for _, name := range names {
+			output += fmt.Sprintf("<br />&nbsp;&nbsp;%s: %v", html.EscapeString(name), report.QueueContributions[name])
+		}
+		return output, nil
+	case "log":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		count := ROOM_LOG_DEFAULT_COUNT
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return "", fmt.Errorf("error: the amount of commands to list must be a positive number")
+			}
+			count = n
+		}
+
+		entries := sPlayback.CommandLog()
+		if len(entries) == 0 {
+			return "No commands have been run in this room yet.", nil
+		}
+		if count > len(entries) {
+			count = len(entries)
+		}
+
+		output := fmt.Sprintf("Last %v commands run in this room:<br />", count)
+		for _, entry := range entries[:count] {
+			output += fmt.Sprintf("<br />[%s] <span class='text-hl-name'>%s</span>: /%s", entry.RanAt.Format("15:04:05"), html.EscapeString(entry.User), html.EscapeString(entry.Command))
+			if len(entry.Error) > 0 {
+				output += " (" + html.EscapeString(entry.Error) + ")"
+			}
+		}
+		return output, nil
+	case "reset":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		// the room's settings, roles, and connected
+		// users are kept; only its playback is reset
+		if err := sPlayback.ClearQueue(); err != nil {
+			log.Printf("ERR SOCKET CLIENT errors occurred while clearing the queue of room %q during a reset: %v", userRoom.Name(), err)
+		}
+		sPlayback.UnsetStream()
+		sPlayback.Stop()
+		sPlayback.Reset()
+		sPlayback.ClearHistory()
+
+		res := &client.Response{
+			Id:   user.UUID(),
+			From: user.GetUsernameOrId(),
+		}
+		if err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra); err != nil {
+			return "", err
+		}
+		user.BroadcastAll("streamsync", res)
+
+		if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+			return "", err
+		}
+		for _, conn := range user.Connections() {
+			c, err := clientHandler.GetClient(conn.UUID())
+			if err != nil {
+				continue
+			}
+			if err := sendUserQueueSyncEvent(c, sPlayback); err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to emit user-queue-sync event to client %q after resetting room %q: %v", c.UUID(), userRoom.Name(), err)
+			}
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has reset the room", user.GetUsernameOrId()))
+		return "Resetting the room: its stream, queue, and play history have been cleared.", nil
+	case "reapstatus":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		reapable := playbackHandler.IsReapable(sPlayback)
+		since, lifetime := sPlayback.ReapWindow()
+		elapsed := time.Now().Sub(since)
+
+		output := "Room reap status:<br />"
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>reapable</span>: %v", reapable)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>last updated</span>: %v ago", time.Now().Sub(sPlayback.GetLastUpdated()).Round(time.Second))
+		if emptiedAt, isEmpty := sPlayback.EmptySince(); isEmpty {
+			output += fmt.Sprintf("<br /><span class='text-hl-name'>empty since</span>: %v ago", time.Now().Sub(emptiedAt).Round(time.Second))
+		}
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>reaped after</span>: %v", lifetime)
+		if !reapable {
+			output += "<br /><br />This room has connected participants and will not be reaped."
+			return output, nil
+		}
+
+		remaining := lifetime - elapsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		output += fmt.Sprintf("<br /><br />This room will be reaped in about %v, once the reaper next runs (every minute).", remaining.Round(time.Second))
+		return output, nil
+	case "keepalive":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		sPlayback.SetLastUpdated(time.Now())
+		_, lifetime := sPlayback.ReapWindow()
+		return fmt.Sprintf("room reaping postponed: this room will not be reaped for at least %v once it becomes idle", lifetime), nil
+	case "filters":
+		sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+		if !sPlaybackExists {
+			return "", ErrNoPlayback
+		}
+
+		blocked := sPlayback.Filter().Blocked()
+		allowed := sPlayback.Filter().Allowed()
+
+		output := "Room filters:<br />"
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>blocked</span>: %s", filterListString(blocked))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>allowed</span>: %s", filterListString(allowed))
+		if len(allowed) > 0 {
+			output += "<br />Only streams matching an allowed domain or video may be played."
+		}
+		return output, nil
+	}
+
+	return h.usage, nil
+}
+
+// adminPickerETA returns a human-readable estimate of when the room's
+// admin-picker will elect a new admin, based on the last admin departure.
+func adminPickerETA(p *playback.Playback) string {
+	departure := p.LastAdminDepartureTime()
+	if departure.IsZero() {
+		return "within the next minute"
+	}
+
+	remaining := playback.SelectionTimePeriod - time.Now().Sub(departure)
+	if remaining <= 0 {
+		return "within the next minute"
+	}
+
+	// the admin-picker checks for candidates once a minute
+	return fmt.Sprintf("in about %v", (remaining + time.Minute).Round(time.Minute))
+}
+
+// filterListString returns a comma delimited list
+// of filter entries, or "[none]" for an empty list
+func filterListString(entries []string) string {
+	if len(entries) == 0 {
+		return "[none]"
+	}
+	return strings.Join(entries, ", ")
+}
+
+// onEmptyDescription describes an end-of-queue behavior
+func onEmptyDescription(behavior string) string {
+	switch behavior {
+	case playback.ON_EMPTY_LOOP:
+		return "play its most recent streams again"
+	case playback.ON_EMPTY_HOME:
+		return "stop and show an idle screen"
+	}
+	return "stop"
+}
+
+func NewCmdRoom() SocketCommand {
+	return &RoomCmd{
+		&Command{
+			name:        ROOM_NAME,
+			description: ROOM_DESCRIPTION,
+			usage:       ROOM_USAGE,
+
+			aliases: room_aliases,
+		},
+	}
+}
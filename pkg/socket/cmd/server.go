@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+type ServerCmd struct {
+	*Command
+}
+
+const (
+	SERVER_NAME        = "server"
+	SERVER_DESCRIPTION = "displays information about the server (status|uptime)"
+	SERVER_USAGE       = "Usage: /" + SERVER_NAME + " &lt;status|uptime&gt;"
+)
+
+var (
+	server_aliases = []string{}
+
+	// serverStartedAt is the time the server process started
+	serverStartedAt = time.Now()
+)
+
+func (h *ServerCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *client.Client, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) (string, error) {
+	if len(args) == 0 {
+		return h.usage, nil
+	}
+
+	switch args[0] {
+	case "status":
+		output := "Server status:<br />"
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>rooms</span>: %v", len(playbackHandler.Playbacks()))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>connections</span>: %v", clientHandler.GetClientSize())
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>streams</span>: %v", streamHandler.GetSize())
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>goroutines</span>: %v", runtime.NumGoroutine())
+		return output, nil
+	case "uptime":
+		churn := playback.Churn()
+
+		output := "Server uptime:<br />"
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>uptime</span>: %v", time.Since(serverStartedAt).Truncate(time.Second))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>started</span>: %s", serverStartedAt.Format(time.RFC1123))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>rooms created</span>: %v", churn.Created)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>rooms reaped</span>: %v", churn.Reaped)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>active rooms</span>: %v", len(playbackHandler.Playbacks()))
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>active connections</span>: %v", clientHandler.GetClientSize())
+		return output, nil
+	}
+
+	return h.usage, nil
+}
+
+func NewCmdServer() SocketCommand {
+	return &ServerCmd{
+		&Command{
+			name:        SERVER_NAME,
+			description: SERVER_DESCRIPTION,
+			usage:       SERVER_USAGE,
+
+			aliases: server_aliases,
+		},
+	}
+}
@@ -2,14 +2,19 @@ package cmd
 
 import (
 	"fmt"
+	"html"
 	"log"
 	"strconv"
+	"time"
 
 	"encoding/json"
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/playback/queue"
+	playbackutil "github.com/juanvallejo/streaming-server/pkg/playback/util"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/util"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 	sockutil "github.com/juanvallejo/streaming-server/pkg/socket/util"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
@@ -20,10 +25,13 @@ type StreamCmd struct {
 
 const (
 	STREAM_NAME        = "stream"
-	STREAM_DESCRIPTION = "controls stream playback (info|pause|play|stop|set|seek|skip)'"
-	STREAM_USAGE       = "Usage: /" + STREAM_NAME + " (info|pause|play|stop|skip|seek &lt;seconds&gt;|set &lt;url&gt;)"
+	STREAM_DESCRIPTION = "controls stream playback (info|state|meta|pause|play|stop|prepare|set|seek|skip|resync|mode|history|previous|hold|resume|mirror|playuser)'"
+	STREAM_USAGE       = "Usage: /" + STREAM_NAME + " (info|state|pause|play|stop|skip|resync|hold|resume|seek &lt;seconds&gt;|prepare &lt;url&gt;|inspect &lt;url&gt;|set &lt;url|prepared&gt;|mode [embed|direct]|history [play &lt;index&gt;]|previous|mirror [room|off]|playuser &lt;username&gt;)"
 )
 
+// STREAM_PREPARED_ARG loads the room's prepared stream when given to "set"
+const STREAM_PREPARED_ARG = "prepared"
+
 var (
 	stream_aliases = []string{}
 )
@@ -41,13 +49,13 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 	userRoom, hasRoom := user.Namespace()
 	if !hasRoom {
 		log.Printf("ERR SOCKET CLIENT client with id %q (%s) attempted to control stream playback with no room assigned", user.UUID(), username)
-		return "", fmt.Errorf("error: you must be in a stream to control stream playback.")
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a stream to control stream playback.")
 	}
 
 	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
 	if !sPlaybackExists {
 		log.Printf("ERR SOCKET CLIENT unable to associate client %q (%s) in room %q with any stream playback objects", user.UUID(), username, userRoom)
-		return "", fmt.Errorf("error: no stream playback is currently loaded for your room")
+		return "", ErrNoPlayback
 	}
 
 	// used as flag to allow "play" to assume "skip" behavior when no
@@ -69,6 +77,38 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 
 		output := "Stream info:<br />" + unpackMap(m, "")
 		return output, nil
+	case "state":
+		return playbackStateSummary(sPlayback), nil
+	case "meta":
+		s, exists := sPlayback.GetStream()
+		if !exists {
+			return "", fmt.Errorf("error: no stream is currently loaded")
+		}
+
+		meta := s.Metadata()
+		output := "Stream metadata:<br />"
+		output += "<br /><span class='text-hl-name'>url</span>: " + html.EscapeString(s.GetStreamURL())
+		output += "<br /><span class='text-hl-name'>kind</span>: " + s.GetKind()
+		output += "<br /><span class='text-hl-name'>creation source</span>: " + html.EscapeString(meta.GetCreationSource().GetSourceName())
+		output += "<br /><span class='text-hl-name'>last updated</span>: " + meta.GetLastUpdated().Format(time.RFC1123)
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>parent refs</span>: %v", len(meta.GetParentRefs()))
+
+		keys := meta.LabelledRefKeys()
+		output += fmt.Sprintf("<br /><span class='text-hl-name'>labelled refs</span>: %v", len(keys))
+		for _, key := range keys {
+			ref, ok := meta.GetLabelledRef(key)
+			if !ok {
+				continue
+			}
+
+			// only expose a ref's identity; never its underlying connection
+			refName := ref.UUID()
+			if c, isClient := ref.(*client.Client); isClient {
+				refName = fmt.Sprintf("%s (%s)", c.GetSourceName(), c.UUID())
+			}
+			output += "<br />&nbsp;&nbsp;" + html.EscapeString(key) + ": " + html.EscapeString(refName)
+		}
+		return output, nil
 	case "play":
 		// if a stream has not been set, fallthrough - allow "play"
 		// to behave like "skip". If a stream has been set, allow
@@ -98,30 +138,43 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		fallthrough
 	case "skip":
 		// skip the currently-playing stream and replace it with the next item in the queue
-		queue := sPlayback.GetQueue()
-		queueItem, err := queue.Next()
+		onLoad := StreamLoadBroadcaster(user, username, sPlayback)
+		if playStreamOnSkip {
+			broadcast := onLoad
+			onLoad = func(s stream.Stream) {
+				sPlayback.Play()
+				broadcast(s)
+			}
+		}
+
+		nextStream, err := sPlayback.AdvanceQueue(onLoad)
 		if err != nil {
 			return "", fmt.Errorf("error: %v", err)
 		}
 
-		nextStream, ok := queueItem.(stream.Stream)
-		if !ok {
-			return "", fmt.Errorf("error: expected next queue item to implement stream.Stream")
+		streamIdentifier := nextStream.GetName()
+		if len(streamIdentifier) == 0 {
+			streamIdentifier = nextStream.GetStreamURL()
 		}
 
-		sPlayback.SetStream(nextStream)
-		sPlayback.Reset()
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has attempted to load the next item in the queue: %q", username, streamIdentifier))
+		return fmt.Sprintf("attempting to load the next item in the queue: %q", streamIdentifier), nil
+	case "playuser":
+		if len(args) < 2 {
+			return h.usage, nil
+		}
 
-		if playStreamOnSkip {
-			sPlayback.Play()
+		// shared queues play streams in the order they were added
+		if sPlayback.QueueMode() == queue.SHARED_MODE {
+			return "", fmt.Errorf("error: the room's queue is shared and plays streams in the order they were added. Use \"/%s mode %s\" to play a user's streams next", QUEUE_NAME, queue.ROUND_ROBIN_MODE)
 		}
 
-		res := &client.Response{
-			Id:   user.UUID(),
-			From: username,
+		target, exists := findSubjectByName(user, clientHandler, args[1])
+		if !exists {
+			return "", fmt.Errorf("error: no user named %q was found in your room", args[1])
 		}
 
-		err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+		nextStream, err := playUserNext(sPlayback, target, StreamLoadBroadcaster(user, username, sPlayback))
 		if err != nil {
 			return "", err
 		}
@@ -131,9 +184,15 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			streamIdentifier = nextStream.GetStreamURL()
 		}
 
-		user.BroadcastAll("streamload", res)
-		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has attempted to load the next item in the queue: %q", username, streamIdentifier))
-		return fmt.Sprintf("attempting to load the next item in the queue: %q", streamIdentifier), nil
+		if err := sendQueueSyncEvent(user, sPlayback); err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to send queue-sync event after playing %q's stream: %v", args[1], err)
+		}
+		if err := sendUserQueueSyncEvent(target, sPlayback); err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to send user-queue-sync event to client with id %q: %v", target.UUID(), err)
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has played the next stream in %q's queue: %q", username, args[1], streamIdentifier))
+		return fmt.Sprintf("playing the next stream in %q's queue: %q", args[1], streamIdentifier), nil
 	case "load":
 		fallthrough
 	case "set":
@@ -143,28 +202,217 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			return "", err
 		}
 
-		s, err := sPlayback.GetOrCreateStreamFromUrl(url, user, streamHandler, func(data []byte, created bool, err error) {})
+		onLoad := StreamLoadBroadcaster(user, username, sPlayback)
+
+		var s stream.Stream
+		if url == STREAM_PREPARED_ARG {
+			prepared, exists := sPlayback.LoadPreparedStream(onLoad)
+			if !exists {
+				return "", fmt.Errorf("error: no stream has been prepared. Use \"/%s prepare &lt;url&gt;\" first", STREAM_NAME)
+			}
+			s = prepared
+			url = s.GetStreamURL()
+		} else {
+			url = sPlayback.ResolveStreamUrl(url)
+
+			s, err = sPlayback.GetOrCreateStreamFromUrl(url, user, streamHandler, func(data []byte, created bool, err error) {})
+			if err != nil {
+				return "", streamCapacityError(user, sPlayback, err)
+			}
+
+			sPlayback.LoadStream(s, onLoad)
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has attempted to load a %s stream: %q", username, s.GetKind(), url))
+
+		return fmt.Sprintf("attempting to load %q", args[1]), nil
+	case "prepare":
+		url, err := getStreamUrlFromArgs(args)
 		if err != nil {
 			return "", err
 		}
+		url = sPlayback.ResolveStreamUrl(url)
 
-		sPlayback.SetStream(s)
-		sPlayback.Reset()
+		// metadata for existing streams has already been fetched, and
+		// their callback is called before GetOrCreateStreamFromUrl returns
+		s, err := sPlayback.GetOrCreateStreamFromUrl(url, user, streamHandler, func(data []byte, created bool, err error) {
+			if !created {
+				return
+			}
 
-		res := &client.Response{
-			Id:   user.UUID(),
-			From: username,
+			prepared, exists := sPlayback.PreparedStream()
+			if !exists || prepared.GetStreamURL() != url {
+				return
+			}
+			if err != nil {
+				sPlayback.ClearPreparedStream()
+				user.BroadcastSystemMessageTo(fmt.Sprintf("error: unable to prepare %q: %v", url, err))
+				return
+			}
+			user.BroadcastSystemMessageTo(preparedStreamSummary(prepared))
+		})
+		if err != nil {
+			return "", streamCapacityError(user, sPlayback, err)
 		}
+		fetching := s.GetDuration() <= 0 && len(s.GetName()) == 0
 
-		err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+		sPlayback.SetPreparedStream(s)
+		if fetching {
+			return fmt.Sprintf("preparing %q, fetching its metadata...", url), nil
+		}
+		return preparedStreamSummary(s), nil
+	case "inspect":
+		url, err := getStreamUrlFromArgs(args)
 		if err != nil {
 			return "", err
 		}
+		url = sPlayback.ResolveStreamUrl(url)
 
-		user.BroadcastAll("streamload", res)
-		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has attempted to load a %s stream: %q", username, s.GetKind(), url))
+		// resolve the stream without registering it, and
+		// report back once its metadata has been fetched
+		go func() {
+			inspection, err := stream.Inspect(url, stream.InspectTimeout)
+			if err != nil {
+				user.BroadcastSystemMessageTo(fmt.Sprintf("error: unable to resolve %q: %v", url, err))
+				return
+			}
+			user.BroadcastSystemMessageTo(streamInspectionSummary(inspection))
+		}()
+		return fmt.Sprintf("inspecting %q...", url), nil
+	case "mirror":
+		if len(args) < 2 {
+			if source, mirroring := sPlayback.Mirroring(); mirroring {
+				return fmt.Sprintf("This room is mirroring room %q.", source.UUID()), nil
+			}
+			return "This room is not mirroring any room.", nil
+		}
 
-		return fmt.Sprintf("attempting to load %q", args[1]), nil
+		if args[1] == "off" {
+			sourceName, mirroring := sPlayback.StopMirroring()
+			if !mirroring {
+				return "", fmt.Errorf("error: this room is not mirroring any room")
+			}
+
+			sendMirrorSync(user, userRoom.Name(), sPlayback, "streamsync")
+			user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has stopped mirroring room %q", username, sourceName))
+			return fmt.Sprintf("This room is no longer mirroring room %q.", sourceName), nil
+		}
+
+		var source *playback.Playback
+		for _, p := range playbackHandler.Playbacks() {
+			if p.UUID() == args[1] {
+				source = p
+				break
+			}
+		}
+		if source == nil {
+			return "", fmt.Errorf("error: unable to find a room named %q", args[1])
+		}
+
+		callback := mirrorCallback(user, userRoom.Name(), sPlayback)
+		if err := sPlayback.Mirror(source, callback); err != nil {
+			return "", fmt.Errorf("error: %v", err)
+		}
+
+		// catch up with the source room right away
+		callback(source)
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set this room to mirror room %q", username, source.UUID()))
+		return fmt.Sprintf("This room now mirrors room %q. Use \"/stream mirror off\" to stop.", source.UUID()), nil
+	case "history":
+		if len(args) < 2 {
+			history := sPlayback.History()
+			if len(history) == 0 {
+				return "no streams have been played in this room yet", nil
+			}
+
+			output := "Play history:<br />"
+			for idx, entry := range history {
+				output += fmt.Sprintf("<br /><span class='text-hl-name'>%v</span>: %s (started by %s)", idx, entry.Name(), entry.StartedBy)
+			}
+			return output, nil
+		}
+
+		if args[1] != "play" || len(args) < 3 {
+			return h.usage, nil
+		}
+
+		idx, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "", fmt.Errorf("error: history index must be an integer")
+		}
+
+		entry, exists := sPlayback.HistoryEntry(idx)
+		if !exists {
+			return "", fmt.Errorf("error: no stream found at history index %v", idx)
+		}
+
+		err = replayHistoryEntry(user, sPlayback, streamHandler, entry)
+		if err != nil {
+			return "", err
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has replayed %q from the room's history", username, entry.Name()))
+		return fmt.Sprintf("replaying %q from the room's history...", entry.Name()), nil
+	case "previous":
+		// the most recent history entry is the current stream,
+		// unless the stream was unset since it was played
+		skipped := 0
+		if s, streamExists := sPlayback.GetStream(); streamExists {
+			if latest, exists := sPlayback.HistoryEntry(0); exists && latest.Url == s.GetStreamURL() {
+				skipped = 1
+			}
+		}
+
+		entry, exists := sPlayback.HistoryEntry(skipped)
+		if !exists {
+			return "", fmt.Errorf("error: there is no previously played stream in this room's history")
+		}
+
+		err := replayHistoryEntry(user, sPlayback, streamHandler, entry)
+		if err != nil {
+			return "", err
+		}
+
+		// drop the skipped-over entry so that repeated calls keep stepping back
+		sPlayback.RewindHistory(skipped)
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has gone back to the previous stream: %q", username, entry.Name()))
+		return fmt.Sprintf("going back to the previous stream: %q...", entry.Name()), nil
+	case "mode":
+		if len(args) < 2 {
+			return fmt.Sprintf("the current playback mode for this room is %q", sPlayback.Mode()), nil
+		}
+
+		mode := args[1]
+		if mode != playback.PLAYBACK_MODE_EMBED && mode != playback.PLAYBACK_MODE_DIRECT {
+			return "", fmt.Errorf("error: unsupported playback mode %q. Must be one of (%s|%s)", mode, playback.PLAYBACK_MODE_EMBED, playback.PLAYBACK_MODE_DIRECT)
+		}
+
+		// a resolution error leaves the requested mode in place for
+		// future streams, but the current stream falls back to "embed".
+		// Clients are notified of the fallback once the stream is loaded.
+		resolveErr := sPlayback.SetMode(mode)
+		if resolveErr != nil {
+			log.Printf("WRN SOCKET CLIENT unable to resolve direct url for the current stream in room %q: %v", userRoom.Name(), resolveErr)
+		}
+
+		if _, streamExists := sPlayback.GetStream(); streamExists {
+			res := &client.Response{
+				Id:   user.UUID(),
+				From: username,
+			}
+
+			err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+			if err != nil {
+				return "", err
+			}
+
+			BroadcastStreamLoad(user, sPlayback, res)
+		}
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room's playback mode to %q", username, mode))
+		return fmt.Sprintf("setting the room's playback mode to %q...", mode), nil
 	}
 
 	// require stream data to have been loaded before proceeding with cases below
@@ -226,18 +474,28 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			}
 		}
 
-		message := "setting the stream playback to"
+		message := fmt.Sprintf("setting the stream playback to %vs", newTime)
+		seekTime := newTime
 
 		if len(modifier) > 0 {
 			if modifier == "+" {
-				message = "advancing the stream playback by"
-				sPlayback.SetTime(sPlayback.GetTime() + newTime)
+				message = fmt.Sprintf("advancing the stream playback by %vs", newTime)
+				seekTime = sPlayback.GetTime() + newTime
 			} else {
-				message = "rewinding the stream playback by"
-				sPlayback.SetTime(sPlayback.GetTime() - newTime)
+				message = fmt.Sprintf("rewinding the stream playback by %vs", newTime)
+				seekTime = sPlayback.GetTime() - newTime
 			}
-		} else {
-			sPlayback.SetTime(newTime)
+		}
+
+		// do not allow rewinding past the beginning of the stream
+		if seekTime < 0 {
+			seekTime = 0
+			message = "rewinding the stream playback to 0s"
+		}
+
+		err = sPlayback.SetTime(seekTime)
+		if err != nil {
+			return "", fmt.Errorf("error: unable to seek the stream: %v", err)
 		}
 
 		res := &client.Response{
@@ -251,12 +509,373 @@ func (h *StreamCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		}
 
 		user.BroadcastAll("streamsync", res)
-		return fmt.Sprintf("%s %vs for all clients.", message, newTime), nil
+		return fmt.Sprintf("%s for all clients.", message), nil
+	case "hold":
+		// stop advancing the room's timer without
+		// pausing playback for any of the clients
+		if sPlayback.IsHeld() {
+			return "", fmt.Errorf("error: the room's timer is already being held")
+		}
+
+		sPlayback.Hold()
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q is holding the room's timer at %vs", username, sPlayback.GetTime()))
+		return fmt.Sprintf("holding the room's timer at %vs. Use /stream resume to continue...", sPlayback.GetTime()), nil
+	case "resume":
+		if !sPlayback.IsHeld() {
+			return "", fmt.Errorf("error: the room's timer is not being held")
+		}
+
+		sPlayback.Resume()
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has resumed the room's timer", username))
+		return fmt.Sprintf("resuming the room's timer from %vs...", sPlayback.GetTime()), nil
+	case "resync":
+		// re-send the current playback and subtitles state to every client
+		// in the room, resetting any client-side subtitles offset.
+		res := &client.Response{
+			Id:   user.UUID(),
+			From: username,
+		}
+
+		err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+		if err != nil {
+			return "", err
+		}
+
+		user.BroadcastAll("streamsync", res)
+
+		subtitlesInfo := map[string]interface{}{
+			"on": false,
+		}
+		if subtitlesPath, hasSubtitles := sPlayback.Subtitles(); hasSubtitles {
+			subtitlesInfo = map[string]interface{}{
+				"path":   subtitlesPath,
+				"offset": 0,
+				"on":     true,
+			}
+		}
+
+		user.BroadcastAll("info_subtitles", &client.Response{
+			Id:    user.UUID(),
+			From:  username,
+			Extra: subtitlesInfo,
+		})
+
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has re-synced the stream and subtitles for all clients", username))
+		return "re-syncing stream and subtitles for all clients...", nil
 	}
 
 	return h.usage, nil
 }
 
+// BroadcastStreamLoad emits a "streamload" event to every client in the user's
+// room and, if enabled, expects each participant to acknowledge the stream.
+func BroadcastStreamLoad(user *client.Client, sPlayback *playback.Playback, res *client.Response) error {
+	if err := user.BroadcastAll("streamload", res); err != nil {
+		return err
+	}
+
+	// let the room know why a stream it prefers to play directly is embedded
+	if err := sPlayback.DirectUrlError(); err != nil {
+		user.BroadcastSystemMessageAll(fmt.Sprintf("unable to play the current stream directly (%v). Falling back to embedded playback...", err))
+	}
+
+	expectStreamLoadedAcks(sPlayback, connection.Participants(user.Connections()), res)
+
+	// loading a stream usually pops it off of the queue
+	BroadcastQueueRuntime(user, sPlayback)
+	return nil
+}
+
+// StreamLoadBroadcaster returns a StreamLoadCallback that broadcasts the room's
+// playback status as a "streamload" event from the given user, under the given name.
+func StreamLoadBroadcaster(user *client.Client, from string, sPlayback *playback.Playback) playback.StreamLoadCallback {
+	return func(s stream.Stream) {
+		res := &client.Response{
+			Id:   user.UUID(),
+			From: from,
+		}
+
+		err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to serialize playback status after loading %q: %v", s.GetStreamURL(), err)
+			return
+		}
+
+		BroadcastStreamLoad(user, sPlayback, res)
+	}
+}
+
+// playUserNext loads the first stream in the given user's queue. The user's
+// queue is moved to the current round-robin position before advancing, so
+// that the users whose turn it would have been still play next afterwards.
+// See Playback.AdvanceQueue for onLoad.
+func playUserNext(sPlayback *playback.Playback, target *client.Client, onLoad playback.StreamLoadCallback) (stream.Stream, error) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	roomQueue := sPlayback.GetQueue()
+	userQueue, exists, err := playbackutil.GetUserQueue(target, roomQueue)
+	if err != nil {
+		return nil, fmt.Errorf("error: %v", err)
+	}
+	if !exists || userQueue.Size() == 0 {
+		return nil, fmt.Errorf("error: %q has no streams queued", target.GetUsernameOrId())
+	}
+
+	sourceIdx := -1
+	for idx, item := range roomQueue.List() {
+		if item.UUID() == userQueue.UUID() {
+			sourceIdx = idx
+			break
+		}
+	}
+
+	destIdx := roomQueue.CurrentIndex()
+	if destIdx >= roomQueue.Size() {
+		destIdx = 0
+	}
+
+	newOrder, err := calculateQueueOrder(sourceIdx, destIdx, roomQueue.Size())
+	if err != nil {
+		return nil, fmt.Errorf("error: %v", err)
+	}
+	if err := roomQueue.ReorderWithCurrentIndex(newOrder, destIdx); err != nil {
+		return nil, fmt.Errorf("error: unable to re-order queue: %v", err)
+	}
+
+	nextStream, err := sPlayback.AdvanceQueue(onLoad)
+	if err != nil {
+		return nil, fmt.Errorf("error: %v", err)
+	}
+	return nextStream, nil
+}
+
+// mirrorCallback returns a callback that has the given room load the streams
+// loaded by the room it mirrors, and follow its timer.
+func mirrorCallback(user *client.Client, room string, sPlayback *playback.Playback) playback.MirrorCallback {
+	return func(source *playback.Playback) {
+		s, exists := source.GetStream()
+		if !exists {
+			return
+		}
+
+		if curr, hasStream := sPlayback.GetStream(); !hasStream || curr.GetStreamURL() != s.GetStreamURL() {
+			sPlayback.LoadStream(s, func(stream.Stream) {
+				sPlayback.UpdateStartedBy(source.StartedBy())
+				followMirrorTimer(sPlayback, source)
+				sendMirrorSync(user, room, sPlayback, "streamload")
+			})
+			return
+		}
+
+		followMirrorTimer(sPlayback, source)
+		sendMirrorSync(user, room, sPlayback, "streamsync")
+	}
+}
+
+// followMirrorTimer has the given room's timer follow the timer of the room it mirrors
+func followMirrorTimer(sPlayback *playback.Playback, source *playback.Playback) {
+	switch source.TimerState() {
+	case playback.TIMER_PLAY:
+		sPlayback.SetTime(source.GetTime())
+		sPlayback.Play()
+	case playback.TIMER_PAUSE:
+		sPlayback.SetTime(source.GetTime())
+		sPlayback.Pause()
+	case playback.TIMER_STOP:
+		sPlayback.Stop()
+	}
+}
+
+// sendMirrorSync emits the given event, containing the room's
+// playback status, to every client in a mirroring room.
+func sendMirrorSync(user *client.Client, room string, sPlayback *playback.Playback, evt string) {
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: "system",
+	}
+
+	if err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra); err != nil {
+		log.Printf("ERR SOCKET CLIENT unable to serialize playback status for mirroring room %q: %v", room, err)
+		return
+	}
+
+	user.BroadcastToNamespace(room, evt, res)
+}
+
+// SendStreamLoad emits a "streamload" event to the given user only and,
+// if enabled, expects the user to acknowledge the stream.
+func SendStreamLoad(user *client.Client, sPlayback *playback.Playback, res *client.Response) {
+	user.BroadcastTo("streamload", res)
+
+	if !user.IsObserver() {
+		expectStreamLoadedAcks(sPlayback, []connection.Connection{user.Connection()}, res)
+	}
+}
+
+// expectStreamLoadedAcks waits on a "streamloaded" ack for the current stream
+// from each of the given connections, re-sending the "streamload" event with
+// an up-to-date playback status to connections that do not ack in time.
+func expectStreamLoadedAcks(sPlayback *playback.Playback, conns []connection.Connection, res *client.Response) {
+	if !sPlayback.StreamLoadAcks() {
+		return
+	}
+
+	s, exists := sPlayback.GetStream()
+	if !exists {
+		return
+	}
+
+	for _, conn := range conns {
+		conn := conn
+		sPlayback.ExpectStreamLoadedAck(conn.UUID(), s.GetStreamURL(), func() {
+			resent := &client.Response{
+				Id:   res.Id,
+				From: res.From,
+			}
+
+			err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &resent.Extra)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to serialize playback status to re-send streamload: %v", err)
+				return
+			}
+
+			client.BroadcastToConnection(conn, "streamload", resent)
+		})
+	}
+}
+
+// playbackStateSummary describes the room's playback
+// state, current stream and position as a sentence
+func playbackStateSummary(sPlayback *playback.Playback) string {
+	s, exists := sPlayback.GetStream()
+	if !exists {
+		if sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED {
+			return "Nothing has been played in this room yet."
+		}
+		return "No stream is currently loaded."
+	}
+
+	name := s.GetName()
+	if len(name) == 0 {
+		name = s.GetStreamURL()
+	}
+
+	position := formatPlayTime(time.Duration(sPlayback.GetTime()) * time.Second)
+	if s.GetDuration() > 0 {
+		position += " of " + formatPlayTime(time.Duration(s.GetDuration()*float64(time.Second)))
+	}
+
+	var summary string
+	switch {
+	case sPlayback.State() == playback.PLAYBACK_STATE_ENDED:
+		summary = fmt.Sprintf("Finished playing %q", name)
+	case sPlayback.TimerState() == playback.TIMER_PLAY:
+		summary = fmt.Sprintf("Playing %q at %s", name, position)
+	case sPlayback.TimerState() == playback.TIMER_PAUSE:
+		summary = fmt.Sprintf("Paused %q at %s", name, position)
+	case sPlayback.State() == playback.PLAYBACK_STATE_NOT_STARTED:
+		summary = fmt.Sprintf("Loaded %q, but it has not started playing", name)
+	default:
+		summary = fmt.Sprintf("Stopped %q at %s", name, position)
+	}
+
+	if startedBy := sPlayback.StartedBy(); len(startedBy) > 0 {
+		summary += fmt.Sprintf(" (started by %s)", startedBy)
+	}
+	return html.EscapeString(summary + ".")
+}
+
+// streamCapacityError receives an error returned while creating a stream and,
+// if the server has reached its maximum number of streams, sends the user an
+// "info_capacity" event so that clients can stop offering to queue streams.
+// A friendlier error is returned in place of the stream handler's error.
+func streamCapacityError(user *client.Client, sPlayback *playback.Playback, err error) error {
+	if err != stream.ErrMaxStreamsExceeded {
+		return err
+	}
+
+	log.Printf("WRN SOCKET CLIENT server at stream capacity: refused a new stream for client %q in room %q", user.UUID(), sPlayback.UUID())
+
+	message := "The server is at capacity and cannot load new streams right now. Try again in a few minutes."
+	user.BroadcastTo("info_capacity", &client.Response{
+		Id:       user.UUID(),
+		IsSystem: true,
+		Message:  message,
+	})
+	return fmt.Errorf("error: %s", message)
+}
+
+// preparedStreamSummary describes a prepared stream for confirmation before it is loaded
+func preparedStreamSummary(s stream.Stream) string {
+	duration := "unknown"
+	if s.GetDuration() > 0 {
+		duration = formatPlayTime(time.Duration(s.GetDuration() * float64(time.Second)))
+	}
+
+	name := s.GetName()
+	if len(name) == 0 {
+		name = s.GetStreamURL()
+	}
+
+	output := "Prepared stream:<br />"
+	output += "<br /><span class='text-hl-name'>title</span>: " + html.EscapeString(name)
+	output += "<br /><span class='text-hl-name'>url</span>: " + html.EscapeString(s.GetStreamURL())
+	output += "<br /><span class='text-hl-name'>duration</span>: " + duration
+	output += fmt.Sprintf("<br /><br />Use \"/%s set %s\" to load it.", STREAM_NAME, STREAM_PREPARED_ARG)
+	return output
+}
+
+func streamInspectionSummary(inspection *stream.Inspection) string {
+	s := inspection.Stream
+	duration := "unknown"
+	if s.GetDuration() > 0 {
+		duration = formatPlayTime(time.Duration(s.GetDuration() * float64(time.Second)))
+	}
+
+	output := "Stream inspection:<br />"
+	output += "<br /><span class='text-hl-name'>url</span>: " + html.EscapeString(inspection.Url)
+	output += "<br /><span class='text-hl-name'>kind</span>: " + html.EscapeString(inspection.Kind)
+	output += "<br /><span class='text-hl-name'>title</span>: " + html.EscapeString(s.GetName())
+	output += "<br /><span class='text-hl-name'>duration</span>: " + duration
+	if len(inspection.FetchError) > 0 {
+		output += "<br /><span class='text-hl-name'>metadata error</span>: " + html.EscapeString(inspection.FetchError)
+	}
+	return output
+}
+
+// replayHistoryEntry loads and plays the stream from the given history entry,
+// recreating it through the stream handler if it has since been reaped.
+func replayHistoryEntry(user *client.Client, sPlayback *playback.Playback, streamHandler stream.StreamHandler, entry *playback.HistoryEntry) error {
+	s, err := sPlayback.GetOrCreateStreamFromUrl(entry.Url, user, streamHandler, func(data []byte, created bool, err error) {})
+	if err == stream.ErrMaxStreamsExceeded {
+		return streamCapacityError(user, sPlayback, err)
+	}
+	if err != nil {
+		return fmt.Errorf("error: unable to replay %q from the room's history: %v", entry.Name(), err)
+	}
+
+	sPlayback.LoadStream(s, StreamLoadBroadcaster(user, user.GetUsernameOrId(), sPlayback))
+
+	err = sPlayback.Play()
+	if err != nil {
+		return err
+	}
+
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+	}
+
+	err = sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+	if err != nil {
+		return err
+	}
+
+	user.BroadcastAll("streamsync", res)
+	return nil
+}
+
 func NewCmdStream() SocketCommand {
 	return &StreamCmd{
 		&Command{
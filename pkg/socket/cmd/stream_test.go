@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+)
+
+func TestStreamSeek(t *testing.T) {
+	tests := []struct {
+		name          string
+		startAt       int
+		args          string
+		expectErr     bool
+		expectTime    int
+		expectMessage string
+	}{
+		{
+			name:          "seek to a time",
+			startAt:       10,
+			args:          "40",
+			expectTime:    40,
+			expectMessage: "setting the stream playback to 40s",
+		},
+		{
+			name:          "advance",
+			startAt:       10,
+			args:          "+5",
+			expectTime:    15,
+			expectMessage: "advancing the stream playback by 5s",
+		},
+		{
+			name:          "rewind",
+			startAt:       10,
+			args:          "-4",
+			expectTime:    6,
+			expectMessage: "rewinding the stream playback by 4s",
+		},
+		{
+			name:          "rewind past the beginning",
+			startAt:       10,
+			args:          "-30",
+			expectTime:    0,
+			expectMessage: "rewinding the stream playback to 0s",
+		},
+		{
+			name:          "human-readable time",
+			startAt:       10,
+			args:          "1m5s",
+			expectTime:    65,
+			expectMessage: "setting the stream playback to 65s",
+		},
+		{
+			name:       "invalid time",
+			startAt:    10,
+			args:       "soon",
+			expectErr:  true,
+			expectTime: 10,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "seek")
+			user, conn := room.join("alice")
+			room.setStream("http://example.com/video.mp4")
+			if err := room.playback.SetTime(tc.startAt); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := room.exec(user, "/stream seek "+tc.args)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got result %q", result)
+				}
+				if syncs := conn.Events("streamsync"); len(syncs) > 0 {
+					t.Fatalf("expected no streamsync after a failed seek, got %v", len(syncs))
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasPrefix(result, tc.expectMessage) {
+					t.Fatalf("expected result to start with %q, got %q", tc.expectMessage, result)
+				}
+
+				syncs := conn.Events("streamsync")
+				if len(syncs) != 1 {
+					t.Fatalf("expected one streamsync, got %v", len(syncs))
+				}
+				timer, _ := syncs[0].Extra["playback"].(map[string]interface{})
+				if synced, _ := timer["time"].(float64); int(synced) != tc.expectTime {
+					t.Fatalf("expected streamsync to report %vs, got %v", tc.expectTime, timer["time"])
+				}
+			}
+
+			if got := room.playback.GetTime(); got != tc.expectTime {
+				t.Fatalf("expected the timer at %vs, got %vs", tc.expectTime, got)
+			}
+		})
+	}
+}
+
+func TestStreamSkipConcurrent(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued int
+		skips  int
+	}{
+		{
+			name:   "one skip",
+			queued: 3,
+			skips:  1,
+		},
+		{
+			name:   "as many skips as queued streams",
+			queued: 8,
+			skips:  8,
+		},
+		{
+			name:   "more skips than queued streams",
+			queued: 4,
+			skips:  10,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "skip")
+			user, conn := room.join("alice")
+
+			urls := []string{}
+			for i := 0; i < tc.queued; i++ {
+				urls = append(urls, fmt.Sprintf("http://example.com/%v.mp4", i))
+			}
+			room.enqueue(user, urls...)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tc.skips)
+			for i := 0; i < tc.skips; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := room.exec(user, "/stream skip"); err != nil {
+						errs <- err
+					}
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			advanced := tc.skips
+			if advanced > tc.queued {
+				advanced = tc.queued
+			}
+			if failed := len(errs); failed != tc.skips-advanced {
+				t.Fatalf("expected %v skips to fail with an empty queue, got %v", tc.skips-advanced, failed)
+			}
+			if remaining := room.playback.RoomQueueSize(); remaining != tc.queued-advanced {
+				t.Fatalf("expected %v streams left in the queue, got %v", tc.queued-advanced, remaining)
+			}
+
+			// each skip advanced exactly one stream, and announced
+			// it before the next skip could load another one
+			loads := conn.Events("streamload")
+			if len(loads) != advanced {
+				t.Fatalf("expected %v streamload events, got %v", advanced, len(loads))
+			}
+			for i, load := range loads {
+				if got := streamUrl(load); got != urls[i] {
+					t.Fatalf("expected streamload #%v to announce %q, got %q", i+1, urls[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamMirror(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		// allowed determines whether the source room allows mirroring
+		allowed bool
+		// mirrored is true if the source room already mirrors the room
+		mirrored  bool
+		expectErr bool
+		// expectCode is the code of the expected error, if any
+		expectCode string
+	}{
+		{
+			name:    "admin mirrors a room allowing it",
+			role:    rbac.ADMIN_ROLE,
+			allowed: true,
+		},
+		{
+			name:       "non-admin cannot mirror",
+			role:       rbac.USER_ROLE,
+			allowed:    true,
+			expectErr:  true,
+			expectCode: CMD_ERR_UNAUTHORIZED,
+		},
+		{
+			name:      "source room does not allow mirroring",
+			role:      rbac.ADMIN_ROLE,
+			expectErr: true,
+		},
+		{
+			name:      "mirroring a room that mirrors the room is a cycle",
+			role:      rbac.ADMIN_ROLE,
+			allowed:   true,
+			mirrored:  true,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "follower")
+			user, _ := room.join("alice")
+			room.bind(tc.role, user)
+
+			source := room.sibling("source")
+			source.playback.SetMirroringAllowed(tc.allowed)
+			if tc.mirrored {
+				room.playback.SetMirroringAllowed(true)
+				if err := source.playback.Mirror(room.playback, func(*playback.Playback) {}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			result, err := room.exec(user, "/stream mirror source")
+			mirrored, mirroring := room.playback.Mirroring()
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got result %q", result)
+				}
+				if len(tc.expectCode) > 0 && ErrorCode(err) != tc.expectCode {
+					t.Fatalf("expected error code %q, got %q", tc.expectCode, ErrorCode(err))
+				}
+				if mirroring {
+					t.Fatalf("expected the room not to mirror any room, got %q", mirrored.UUID())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !mirroring || mirrored != source.playback {
+				t.Fatalf("expected the room to mirror room %q", source.name)
+			}
+		})
+	}
+}
+
+func TestRoomMirroringOffDetachesFollowers(t *testing.T) {
+	room := newTestRoom(t, "follower")
+	user, _ := room.join("alice")
+	room.bind(rbac.ADMIN_ROLE, user)
+
+	source := room.sibling("source")
+	sourceAdmin, _ := source.join("bob")
+	source.bind(rbac.ADMIN_ROLE, sourceAdmin)
+
+	if _, err := source.exec(sourceAdmin, "/room mirroring on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := room.exec(user, "/stream mirror source"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, mirroring := room.playback.Mirroring(); !mirroring {
+		t.Fatalf("expected the room to mirror room %q", source.name)
+	}
+
+	if _, err := source.exec(sourceAdmin, "/room mirroring off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mirrored, mirroring := room.playback.Mirroring(); mirroring {
+		t.Fatalf("expected the room to stop mirroring once mirroring is disabled, got %q", mirrored.UUID())
+	}
+	if _, err := room.exec(user, "/stream mirror source"); err == nil {
+		t.Fatalf("expected an error mirroring a room that does not allow it")
+	}
+}
+
+func TestStreamPrevious(t *testing.T) {
+	tests := []struct {
+		name string
+		// played are the urls of the streams played in the room, in order
+		played []string
+		// unset determines whether the current stream
+		// is unset before going back to the previous one
+		unset         bool
+		expectErr     bool
+		expectStream  string
+		expectHistory []string
+	}{
+		{
+			name:          "current stream loaded",
+			played:        []string{"http://example.com/a.mp4", "http://example.com/b.mp4", "http://example.com/c.mp4"},
+			expectStream:  "http://example.com/b.mp4",
+			expectHistory: []string{"http://example.com/b.mp4", "http://example.com/a.mp4"},
+		},
+		{
+			name:      "only the current stream played",
+			played:    []string{"http://example.com/a.mp4"},
+			expectErr: true,
+		},
+		{
+			name:          "current stream unset",
+			played:        []string{"http://example.com/a.mp4", "http://example.com/b.mp4"},
+			unset:         true,
+			expectStream:  "http://example.com/b.mp4",
+			expectHistory: []string{"http://example.com/b.mp4", "http://example.com/a.mp4"},
+		},
+		{
+			name:          "single stream played and unset",
+			played:        []string{"http://example.com/a.mp4"},
+			unset:         true,
+			expectStream:  "http://example.com/a.mp4",
+			expectHistory: []string{"http://example.com/a.mp4"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "previous")
+			user, _ := room.join("alice")
+
+			for _, u := range tc.played {
+				s, err := room.streamHandler.NewStream(u)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				room.playback.SetStream(s)
+			}
+			if tc.unset {
+				room.playback.UnsetStream()
+			}
+
+			result, err := room.exec(user, "/stream previous")
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got result %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			s, exists := room.playback.GetStream()
+			if !exists || s.GetStreamURL() != tc.expectStream {
+				t.Fatalf("expected the current stream to be %q, got %v", tc.expectStream, s)
+			}
+
+			history := []string{}
+			for _, entry := range room.playback.History() {
+				history = append(history, entry.Url)
+			}
+			if strings.Join(history, ",") != strings.Join(tc.expectHistory, ",") {
+				t.Fatalf("expected history %v, got %v", tc.expectHistory, history)
+			}
+		})
+	}
+}
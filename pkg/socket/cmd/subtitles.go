@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
 	"path"
 	"strings"
@@ -15,8 +16,6 @@ import (
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
 
-// TODO: save subtitles state in playback state sent to the client
-// also allow for subtitles to be specified from a URL
 type SubtitlesCmd struct {
 	*Command
 }
@@ -24,7 +23,7 @@ type SubtitlesCmd struct {
 const (
 	SUBTITLES_NAME        = "subtitles"
 	SUBTITLES_DESCRIPTION = "controls stream subtitles for every client"
-	SUBTITLES_USAGE       = "Usage: /" + SUBTITLES_NAME + " &lt;(off|path/to/subtitles.srt)&gt;"
+	SUBTITLES_USAGE       = "Usage: /" + SUBTITLES_NAME + " &lt;(off|path/to/subtitles.srt|https://host/subtitles.vtt)&gt;"
 
 	SUBTITLES_FILE_ROOT = "/webclient/src/static/subtitles/"
 )
@@ -42,7 +41,13 @@ func (h *SubtitlesCmd) Execute(cmdHandler SocketCommandHandler, args []string, u
 	userRoom, hasRoom := user.Namespace()
 	if !hasRoom {
 		log.Printf("SOCKET CLIENT ERR client with id %q attempted to control stream playback with no room assigned", user.UUID())
-		return "", fmt.Errorf("error: you must be in a stream to control stream playback")
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a stream to control stream playback")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		log.Printf("SOCKET CLIENT ERR unable to associate client %q (%s) in room %q with any stream playback objects", user.UUID(), username, userRoom.Name())
+		return "", ErrNoPlayback
 	}
 
 	currentDir := util.GetCurrentDirectory()
@@ -55,6 +60,7 @@ func (h *SubtitlesCmd) Execute(cmdHandler SocketCommandHandler, args []string, u
 			return "", fmt.Errorf("error: no subtitles filepath specified")
 		}
 	} else if args[0] == "off" {
+		sPlayback.ClearSubtitles()
 		user.BroadcastAll("info_subtitles", &client.Response{
 			Id:   user.UUID(),
 			From: username,
@@ -65,6 +71,15 @@ func (h *SubtitlesCmd) Execute(cmdHandler SocketCommandHandler, args []string, u
 
 		user.BroadcastSystemMessageAll(fmt.Sprintf("%q has requested to remove subtitles from the stream", username))
 		return "attempting to remove subtitles from the stream...", nil
+	} else if remoteUrl, isRemote := remoteSubtitlesUrl(args[0]); isRemote {
+		// remote subtitles are loaded by clients directly
+		if strings.ToLower(path.Ext(remoteUrl.Path)) != ".vtt" {
+			return "", fmt.Errorf("error: only WebVTT (.vtt) subtitles can be loaded from a url")
+		}
+
+		log.Printf("SOCKET CLIENT INFO attempting to load remote subtitles %q\n", remoteUrl.String())
+		broadcastSubtitles(user, username, sPlayback, remoteUrl.String())
+		return "attempting to add subtitles to the stream...", nil
 	} else {
 		subtitlesFilepath = path.Join(subtitlesRootDir, args[0])
 	}
@@ -82,17 +97,36 @@ func (h *SubtitlesCmd) Execute(cmdHandler SocketCommandHandler, args []string, u
 		return "", fmt.Errorf("error: unable to parse client-relative subtitles URL")
 	}
 
+	clientSubtitlesPath := path.Join("/", clientRelativeSubtitlesFilepath[1])
+	broadcastSubtitles(user, username, sPlayback, clientSubtitlesPath)
+	return "attempting to add subtitles to the stream...", nil
+}
+
+// broadcastSubtitles sets the given subtitles path for the
+// room and has every client in the room load it
+func broadcastSubtitles(user *client.Client, username string, sPlayback *playback.Playback, subtitlesPath string) {
+	sPlayback.SetSubtitles(subtitlesPath)
+
 	user.BroadcastAll("info_subtitles", &client.Response{
 		Id:   user.UUID(),
 		From: username,
 		Extra: map[string]interface{}{
-			"path": path.Join("/", clientRelativeSubtitlesFilepath[1]),
+			"path": subtitlesPath,
 			"on":   true,
 		},
 	})
 
 	user.BroadcastSystemMessageAll(fmt.Sprintf("%q has requested to add subtitles to the stream", username))
-	return "attempting to add subtitles to the stream...", nil
+}
+
+// remoteSubtitlesUrl returns the parsed url, and a boolean (true),
+// if the given subtitles location is an http(s) url
+func remoteSubtitlesUrl(location string) (*url.URL, bool) {
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, false
+	}
+	return u, true
 }
 
 // findSubtitlesFilepathGivenCurrentNamespace lists all valid subtitle files in the SUBTITLES_FILE_ROOT
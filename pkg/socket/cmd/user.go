@@ -5,6 +5,7 @@ import (
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
 	"github.com/juanvallejo/streaming-server/pkg/socket/util"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
@@ -16,7 +17,7 @@ type UserCmd struct {
 const (
 	USER_NAME        = "user"
 	USER_DESCRIPTION = "controls user settings"
-	USER_USAGE       = "Usage: /" + USER_NAME + " (name &lt;username&gt;|list)"
+	USER_USAGE       = "Usage: /" + USER_NAME + " (name &lt;username&gt;|acks [on|off]|inlinemedia [on|off]|list)"
 )
 
 var (
@@ -33,13 +34,57 @@ func (h *UserCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *
 			return h.usage, nil
 		}
 
-		err := util.UpdateClientUsername(user, args[1], clientHandler)
+		username, err := util.UpdateClientUsername(user, args[1], clientHandler)
 		if err != nil {
 			return "", err
 		}
 
-		return fmt.Sprintf("attempting to update username to %q", args[1]), nil
+		if userRoom, exists := user.Namespace(); exists {
+			if sPlayback, exists := playbackHandler.PlaybackByNamespace(userRoom); exists {
+				ClaimRestoredQueue(user, sPlayback, streamHandler)
+			}
+		}
+
+		return fmt.Sprintf("attempting to update username to %q", username), nil
+
+	}
 
+	if args[0] == "acks" {
+		if len(args) < 2 {
+			if user.CommandAcks() {
+				return "command acknowledgements are on: you are notified when a command succeeds without output", nil
+			}
+			return "command acknowledgements are off: commands that succeed without output are silent", nil
+		}
+
+		switch args[1] {
+		case "on":
+			user.SetCommandAcks(true)
+		case "off":
+			user.SetCommandAcks(false)
+		default:
+			return h.usage, nil
+		}
+		return fmt.Sprintf("turned command acknowledgements %s", args[1]), nil
+	}
+
+	if args[0] == "inlinemedia" {
+		if len(args) < 2 {
+			if user.InlineMedia() {
+				return "inline media is on: image links in your messages are shown as images", nil
+			}
+			return "inline media is off: image links in your messages are left as links", nil
+		}
+
+		switch args[1] {
+		case "on":
+			user.SetInlineMedia(true)
+		case "off":
+			user.SetInlineMedia(false)
+		default:
+			return h.usage, nil
+		}
+		return fmt.Sprintf("turned inline media %s", args[1]), nil
 	}
 
 	_, exists := user.Namespace()
@@ -51,7 +96,7 @@ func (h *UserCmd) Execute(cmdHandler SocketCommandHandler, args []string, user *
 		userName, userHasName := user.GetUsername()
 
 		output := "All users in the current room:<br />"
-		for _, conn := range user.Connections() {
+		for _, conn := range connection.Participants(user.Connections()) {
 			c, err := clientHandler.GetClient(conn.UUID())
 			if err != nil {
 				continue
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserListObservers(t *testing.T) {
+	tests := []struct {
+		name         string
+		participants []string
+		observers    []string
+		// lister is the client listing users
+		lister string
+	}{
+		{
+			name:         "participants only",
+			participants: []string{"alice", "bob"},
+			lister:       "alice",
+		},
+		{
+			name:         "participants and observers",
+			participants: []string{"alice", "bob"},
+			observers:    []string{"carol"},
+			lister:       "alice",
+		},
+		{
+			name:         "listed by an observer",
+			participants: []string{"alice"},
+			observers:    []string{"bob", "carol"},
+			lister:       "carol",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			room := newTestRoom(t, "observers")
+			for _, name := range tc.participants {
+				room.join(name)
+			}
+			for _, name := range tc.observers {
+				room.observe(name)
+			}
+
+			lister, err := room.clientHandler.GetClient("id-" + tc.lister)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := room.exec(lister, "/user list")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, name := range tc.participants {
+				if !strings.Contains(result, name) {
+					t.Fatalf("expected participant %q to be listed, got %q", name, result)
+				}
+			}
+			for _, name := range tc.observers {
+				if strings.Contains(result, name) {
+					t.Fatalf("expected observer %q not to be listed, got %q", name, result)
+				}
+			}
+		})
+	}
+}
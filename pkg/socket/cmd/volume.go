@@ -7,6 +7,7 @@ import (
 
 	"github.com/juanvallejo/streaming-server/pkg/playback"
 	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	sockutil "github.com/juanvallejo/streaming-server/pkg/socket/util"
 	"github.com/juanvallejo/streaming-server/pkg/stream"
 )
 
@@ -16,8 +17,11 @@ type VolumeCmd struct {
 
 const (
 	VOLUME_NAME        = "volume"
-	VOLUME_DESCRIPTION = "increase, decrease, or set a volume value"
-	VOLUME_USAGE       = "Usage: /" + VOLUME_NAME
+	VOLUME_DESCRIPTION = "increase, decrease, get, or set a volume value, or set the room's default volume"
+	VOLUME_USAGE       = "Usage: /" + VOLUME_NAME + " &lt;[+|-]value&gt; | get | default [value|off]"
+
+	// maximum volume value a room default may be set to
+	VOLUME_DEFAULT_MAX = 100
 )
 
 var (
@@ -29,6 +33,23 @@ func (h *VolumeCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 		return h.usage, nil
 	}
 
+	if args[0] == "default" {
+		return setDefaultVolume(args[1:], user, playbackHandler)
+	}
+
+	// the room's volume is tracked alongside its playback, if any
+	var sPlayback *playback.Playback
+	if userRoom, hasRoom := user.Namespace(); hasRoom {
+		sPlayback, _ = playbackHandler.PlaybackByNamespace(userRoom)
+	}
+
+	if args[0] == "get" {
+		if sPlayback == nil {
+			return "", ErrNoPlayback
+		}
+		return fmt.Sprintf("The current volume is %v.", sPlayback.Volume()), nil
+	}
+
 	rawVol := args[0]
 	modifier := string(rawVol[0])
 	if modifier == "+" || modifier == "-" {
@@ -51,15 +72,80 @@ func (h *VolumeCmd) Execute(cmdHandler SocketCommandHandler, args []string, user
 			newVol,
 		})
 
+		if sPlayback != nil {
+			if modifier == "+" {
+				sPlayback.SetVolume(sPlayback.Volume() + newVol)
+			} else {
+				sPlayback.SetVolume(sPlayback.Volume() - newVol)
+			}
+		}
+
 		return "Modifying volume...", nil
 	}
 
 	user.BroadcastChatActionTo("setVolume", []interface{}{
 		newVol,
 	})
+
+	if sPlayback != nil {
+		sPlayback.SetVolume(newVol)
+	}
 	return fmt.Sprintf("Setting volume to %v...", newVol), nil
 }
 
+// setDefaultVolume reports or updates the default volume for the user's room.
+// New clients joining the room adopt the room's default volume.
+func setDefaultVolume(args []string, user *client.Client, playbackHandler playback.PlaybackHandler) (string, error) {
+	userRoom, hasRoom := user.Namespace()
+	if !hasRoom {
+		return "", NewCmdError(CMD_ERR_NOT_IN_ROOM, "error: you must be in a room to set its default volume")
+	}
+
+	sPlayback, sPlaybackExists := playbackHandler.PlaybackByNamespace(userRoom)
+	if !sPlaybackExists {
+		return "", ErrNoPlayback
+	}
+
+	if len(args) == 0 {
+		vol, exists := sPlayback.DefaultVolume()
+		if !exists {
+			return "This room has no default volume set.", nil
+		}
+		return fmt.Sprintf("This room's default volume is %v.", vol), nil
+	}
+
+	newVol := -1
+	if args[0] != "off" {
+		vol, err := strconv.Atoi(args[0])
+		if err != nil || vol < 0 || vol > VOLUME_DEFAULT_MAX {
+			return "", fmt.Errorf("error: the default volume must be an integer between 0 and %v", VOLUME_DEFAULT_MAX)
+		}
+		newVol = vol
+	}
+
+	sPlayback.SetDefaultVolume(newVol)
+
+	res := &client.Response{
+		Id:   user.UUID(),
+		From: user.GetUsernameOrId(),
+	}
+
+	err := sockutil.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+	if err != nil {
+		return "", err
+	}
+
+	user.BroadcastAll("streamsync", res)
+
+	if newVol < 0 {
+		user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has cleared the room's default volume", user.GetUsernameOrId()))
+		return "Clearing the room's default volume...", nil
+	}
+
+	user.BroadcastSystemMessageFrom(fmt.Sprintf("%q has set the room's default volume to %v", user.GetUsernameOrId(), newVol))
+	return fmt.Sprintf("Setting the room's default volume to %v...", newVol), nil
+}
+
 func NewCmdVolume() SocketCommand {
 	return &VolumeCmd{
 		&Command{
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gorilla/websocket"
 
+	"github.com/juanvallejo/streaming-server/pkg/api/endpoint/query"
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection/util"
 )
 
@@ -78,6 +79,14 @@ func NewConnectionMetadata() ConnectionMetadata {
 	}
 }
 
+// CompressionThreshold is the minimum size, in bytes, of an
+// outgoing message for it to be sent compressed
+var CompressionThreshold = 1024
+
+// DEFAULT_PING_INTERVAL is the amount of time between pings sent to each
+// connection, unless set otherwise through ConnectionHandler.SetPingInterval.
+const DEFAULT_PING_INTERVAL = 30 * time.Second
+
 type SocketEventCallback func(MessageDataCodec)
 
 type Connection interface {
@@ -87,6 +96,10 @@ type Connection interface {
 	// BroadcastFrom behaves like Broadcast, except the connection id provided
 	// is skipped from any effects or mutations taken by the handler's method.
 	BroadcastFrom(string, string, []byte)
+	// Close closes the underlying socket connection
+	Close() error
+	// IsClosed returns true if the underlying socket connection has been closed
+	IsClosed() bool
 	// Metadata returns ConnectionMetadata for the current connection
 	Metadata() ConnectionMetadata
 	// Connections returns socket connections that are in the same namespace as the connection
@@ -113,6 +126,9 @@ type Connection interface {
 	ResponseWriter() http.ResponseWriter
 	// Request returns the saved http.Request for this connection
 	Request() *http.Request
+	// IsObserver returns true if the connection receives broadcasts for its
+	// namespace, but is not counted as one of the namespace's participants.
+	IsObserver() bool
 	// Send receives an array of bytes to send to the connection
 	Send([]byte)
 	// WriteMessage sends a text message as an array of bytes to the connection
@@ -130,6 +146,8 @@ type SocketConn struct {
 	httpReq    *http.Request
 	nsHandler  NamespaceHandler
 	ns         string
+	observer   bool
+	closed     bool
 
 	mutex sync.Mutex
 }
@@ -200,6 +218,19 @@ func (c *SocketConn) Namespace() (Namespace, bool) {
 	return c.nsHandler.NamespaceByName(c.ns)
 }
 
+func (c *SocketConn) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closed = true
+	return c.Conn.Close()
+}
+
+func (c *SocketConn) IsClosed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.closed
+}
+
 func (c *SocketConn) ReadMessage() (int, []byte, error) {
 	return c.Conn.ReadMessage()
 }
@@ -207,9 +238,43 @@ func (c *SocketConn) ReadMessage() (int, []byte, error) {
 func (c *SocketConn) WriteMessage(messageType int, data []byte) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+
+	// only compress messages large enough to benefit from it.
+	// This is a no-op if compression was not negotiated.
+	c.Conn.EnableWriteCompression(len(data) >= CompressionThreshold)
 	return c.Conn.WriteMessage(messageType, data)
 }
 
+// keepAlive pings the connection every interval until the returned channel
+// is closed. Reads from the connection fail once a pong has not been received
+// for two intervals, so that half-open connections are disconnected.
+func (c *SocketConn) keepAlive(interval time.Duration) chan struct{} {
+	wait := 2 * interval
+	c.Conn.SetReadDeadline(time.Now().Add(wait))
+	c.Conn.SetPongHandler(func(string) error {
+		return c.Conn.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+					log.Printf("WRN WS HANDLE unable to ping connection with id %q: %v", c.UUID(), err)
+					return
+				}
+			}
+		}
+	}()
+	return stop
+}
+
 func (c *SocketConn) ResponseWriter() http.ResponseWriter {
 	return c.respWriter
 }
@@ -218,6 +283,21 @@ func (c *SocketConn) Request() *http.Request {
 	return c.httpReq
 }
 
+func (c *SocketConn) IsObserver() bool {
+	return c.observer
+}
+
+// Participants returns the given connections that are not observers
+func Participants(conns []Connection) []Connection {
+	participants := []Connection{}
+	for _, c := range conns {
+		if !c.IsObserver() {
+			participants = append(participants, c)
+		}
+	}
+	return participants
+}
+
 func NewConnection(nsHandler NamespaceHandler, ws *websocket.Conn, w http.ResponseWriter, r *http.Request) Connection {
 	// generate a uuid for this connection, or panic
 	uuid, err := util.GenerateUUID()
@@ -238,5 +318,6 @@ func NewConnectionWithUUID(uuid string, nsHandler NamespaceHandler, ws *websocke
 		connId:     uuid,
 		callbacks:  make(map[string][]SocketEventCallback),
 		nsHandler:  nsHandler,
+		observer:   r != nil && r.URL.Query().Get(query.OBSERVER_KEY) == "true",
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
@@ -12,6 +13,12 @@ import (
 
 // ConnectionHandler provides methods for managing multiple socket connections
 type ConnectionHandler interface {
+	// AddObserver receives a namespace name and an Observer
+	// and registers the Observer with the namespace handler
+	AddObserver(string, Observer)
+	// RemoveObserver receives a namespace name and an Observer
+	// and de-registers the Observer from the namespace handler
+	RemoveObserver(string, Observer)
 	// Authorizer returns an RBAC authorizer or nil
 	Authorizer() rbac.Authorizer
 	// NewConnection instantiates a new Connection
@@ -31,12 +38,26 @@ type ConnectionHandler interface {
 	// Handle receives a Connection and creates a goroutine
 	// to parse and handle callbacks for incoming messages
 	Handle(Connection)
+	// SetPingInterval receives the amount of time between pings sent to
+	// each connection. A connection that does not respond to a ping before
+	// the next two are sent is considered dead, and is disconnected.
+	// A value <= 0 disables pings.
+	SetPingInterval(time.Duration)
 }
 
 // ConnHandler implements Handler
 type ConnHandler struct {
-	nsHandler NamespaceHandler
-	connsById map[string]Connection
+	nsHandler    NamespaceHandler
+	connsById    map[string]Connection
+	pingInterval time.Duration
+}
+
+func (h *ConnHandler) AddObserver(ns string, o Observer) {
+	h.nsHandler.AddObserver(ns, o)
+}
+
+func (h *ConnHandler) RemoveObserver(ns string, o Observer) {
+	h.nsHandler.RemoveObserver(ns, o)
 }
 
 func (h *ConnHandler) Authorizer() rbac.Authorizer {
@@ -75,13 +96,18 @@ func (h *ConnHandler) NamespaceByName(ns string) (Namespace, bool) {
 }
 
 func (h *ConnHandler) Handle(conn Connection) {
-	go HandleConnection(h, conn)
+	go HandleConnection(h, conn, h.pingInterval)
+}
+
+func (h *ConnHandler) SetPingInterval(interval time.Duration) {
+	h.pingInterval = interval
 }
 
 func NewHandler(nsHandler NamespaceHandler) ConnectionHandler {
 	return &ConnHandler{
-		connsById: make(map[string]Connection),
-		nsHandler: nsHandler,
+		connsById:    make(map[string]Connection),
+		nsHandler:    nsHandler,
+		pingInterval: DEFAULT_PING_INTERVAL,
 	}
 }
 
@@ -102,7 +128,14 @@ func NewHandlerWithRBAC(authorizer rbac.Authorizer, nsHandler NamespaceHandler)
 	}
 }
 
-func HandleConnection(handler ConnectionHandler, conn Connection) {
+func HandleConnection(handler ConnectionHandler, conn Connection, pingInterval time.Duration) {
+	// half-open connections never fail a read on their own; pinging
+	// them fails their next read, handling them as disconnections.
+	if socketConn, ok := conn.(*SocketConn); ok && pingInterval > 0 {
+		stop := socketConn.keepAlive(pingInterval)
+		defer close(stop)
+	}
+
 	for {
 		var connClosed bool
 
@@ -117,6 +150,9 @@ func HandleConnection(handler ConnectionHandler, conn Connection) {
 		}
 
 		if mType == websocket.CloseMessage || mType == websocket.CloseGoingAway || connClosed {
+			// mark the connection as closed before handling its disconnection
+			// so that its client record is recognized as stale in the meantime
+			conn.Close()
 			conn.Emit("disconnection", NewMessageData())
 			handler.DeleteConnection(conn)
 			break
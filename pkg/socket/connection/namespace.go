@@ -3,6 +3,7 @@ package connection
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/juanvallejo/streaming-server/pkg/socket/connection/util"
 )
@@ -89,6 +90,13 @@ func NewNamespace(name string) Namespace {
 // Namespace provides convenience methods for
 // handling segments of registered Connections
 type NamespaceHandler interface {
+	// AddObserver receives a namespace name and an Observer and
+	// registers the Observer to receive every message broadcast
+	// to the specified namespace.
+	AddObserver(string, Observer)
+	// RemoveObserver receives a namespace name and an Observer and
+	// stops broadcasting messages in the namespace to the Observer.
+	RemoveObserver(string, Observer)
 	// AddToNamespace receives a namespace name and a Connection and adds the
 	// Connection to the specified namespace. If the specified namespace does
 	// not exist, a new one is created.
@@ -118,6 +126,55 @@ type NamespaceHandler interface {
 // NamespaceHandlerSpec implements Namespace
 type NamespaceHandlerSpec struct {
 	nsByName map[string]Namespace
+
+	// observers are keyed by namespace name, then by observer id
+	observers   map[string]map[string]Observer
+	observerMux sync.RWMutex
+}
+
+func (h *NamespaceHandlerSpec) AddObserver(ns string, o Observer) {
+	h.observerMux.Lock()
+	defer h.observerMux.Unlock()
+
+	if _, exists := h.observers[ns]; !exists {
+		h.observers[ns] = make(map[string]Observer)
+	}
+
+	h.observers[ns][o.UUID()] = o
+}
+
+func (h *NamespaceHandlerSpec) RemoveObserver(ns string, o Observer) {
+	h.observerMux.Lock()
+	defer h.observerMux.Unlock()
+
+	observers, exists := h.observers[ns]
+	if !exists {
+		return
+	}
+
+	delete(observers, o.UUID())
+	if len(observers) == 0 {
+		delete(h.observers, ns)
+	}
+}
+
+// notifyObservers relays a broadcast message to every Observer
+// registered for the given namespace. Observers are notified
+// outside of the observer lock, so that a slow observer does not
+// hold up registering or removing observers for other broadcasts.
+func (h *NamespaceHandlerSpec) notifyObservers(ns, eventName string, data []byte) {
+	h.observerMux.RLock()
+	observers := make([]Observer, 0, len(h.observers[ns]))
+	for _, o := range h.observers[ns] {
+		observers = append(observers, o)
+	}
+	h.observerMux.RUnlock()
+
+	for _, o := range observers {
+		if err := o.Notify(eventName, data); err != nil {
+			log.Printf("WRN SOCKET CONN NAMESPACE unable to notify observer (%q) in namespace (%q): %v", o.UUID(), ns, err)
+		}
+	}
 }
 
 func (h *NamespaceHandlerSpec) AddToNamespace(ns string, conn Connection) {
@@ -178,6 +235,8 @@ func (h *NamespaceHandlerSpec) Broadcast(messageType int, ns, eventName string,
 	for _, c := range namespace.Connections() {
 		c.WriteMessage(messageType, data)
 	}
+
+	h.notifyObservers(ns, eventName, data)
 }
 
 func (h *NamespaceHandlerSpec) BroadcastFrom(messageType int, connId, ns, eventName string, data []byte) {
@@ -192,10 +251,13 @@ func (h *NamespaceHandlerSpec) BroadcastFrom(messageType int, connId, ns, eventN
 		}
 		c.WriteMessage(messageType, data)
 	}
+
+	h.notifyObservers(ns, eventName, data)
 }
 
 func NewNamespaceHandler() NamespaceHandler {
 	return &NamespaceHandlerSpec{
-		nsByName: make(map[string]Namespace),
+		nsByName:  make(map[string]Namespace),
+		observers: make(map[string]map[string]Observer),
 	}
 }
@@ -0,0 +1,49 @@
+package connection
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection/util"
+)
+
+// Observer receives messages broadcast to a namespace
+// without participating in it as a Connection.
+type Observer interface {
+	// Notify receives an event name and its serialized message.
+	// Returns an error if the Observer is no longer able to
+	// receive messages.
+	Notify(string, []byte) error
+	// UUID returns the unique identifier for the Observer
+	UUID() string
+}
+
+// ObserverSpec implements Observer
+type ObserverSpec struct {
+	id       string
+	callback ObserverCallback
+}
+
+// ObserverCallback is called with every event name and
+// serialized message received by an Observer.
+type ObserverCallback func(string, []byte) error
+
+func (o *ObserverSpec) Notify(eventName string, data []byte) error {
+	return o.callback(eventName, data)
+}
+
+func (o *ObserverSpec) UUID() string {
+	return o.id
+}
+
+func NewObserver(callback ObserverCallback) Observer {
+	id, err := util.GenerateUUID()
+	if err != nil {
+		log.Panic(fmt.Sprintf("unable to generate observer uuid: %v", err))
+	}
+
+	return &ObserverSpec{
+		id:       id,
+		callback: callback,
+	}
+}
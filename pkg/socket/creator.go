@@ -0,0 +1,89 @@
+package socket
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/juanvallejo/streaming-server/pkg/playback"
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+)
+
+// SetPromoteReturningCreator receives a boolean determining whether a room's
+// creator is bound to the admin role again when rejoining the room with the
+// creator token issued to them on room creation.
+func (h *Handler) SetPromoteReturningCreator(promote bool) {
+	h.promoteReturningCreator = promote
+}
+
+// SetCreatorReturnPolicy receives the policy determining what happens to
+// admins elected while a room's creator was away once the creator returns.
+// Returns an error if the policy is not a known creator return policy.
+func (h *Handler) SetCreatorReturnPolicy(policy string) error {
+	if err := playback.ValidCreatorReturnPolicy(policy); err != nil {
+		return err
+	}
+
+	h.creatorReturnPolicy = policy
+	return nil
+}
+
+// promoteCreator binds the given returning room creator to the admin role.
+// Under the CreatorReturnDemote policy, every other admin in the room is
+// unbound from the admin role and left with the user role.
+func (h *Handler) promoteCreator(creator *client.Client) error {
+	authorizer := h.CommandHandler.Authorizer()
+	if authorizer == nil {
+		return fmt.Errorf("rbac is not enabled")
+	}
+
+	adminRole, exists := authorizer.Role(rbac.ADMIN_ROLE)
+	if !exists {
+		return fmt.Errorf("role %q not found", rbac.ADMIN_ROLE)
+	}
+
+	ns, exists := creator.Namespace()
+	if !exists {
+		return fmt.Errorf("client %q is not bound to a room", creator.UUID())
+	}
+
+	if h.creatorReturnPolicy == playback.CreatorReturnDemote {
+		userRole, userRoleExists := authorizer.Role(rbac.USER_ROLE)
+		for _, conn := range ns.Connections() {
+			if conn.UUID() == creator.UUID() {
+				continue
+			}
+
+			for _, b := range authorizer.Bindings() {
+				if b.Role().Name() != rbac.ADMIN_ROLE || !b.RemoveSubject(conn) {
+					continue
+				}
+
+				if userRoleExists {
+					authorizer.Bind(userRole, conn)
+				}
+
+				log.Printf("INF SOCKET CLIENT unbound interim admin %q from room %q: the room's creator has returned", conn.UUID(), ns.Name())
+				if c, err := h.clientHandler.GetClient(conn.UUID()); err == nil {
+					c.BroadcastSystemMessageTo("The creator of this room has returned. You are no longer an admin.")
+					c.BroadcastAll("info_userlistupdated", &client.Response{
+						Id: c.UUID(),
+					})
+					c.BroadcastAuthRequestTo("cookie")
+				}
+			}
+		}
+	}
+
+	if !authorizer.Bind(adminRole, creator) {
+		return nil
+	}
+
+	log.Printf("INF SOCKET CLIENT bound returning creator %q to rbac role %q in room %q", creator.UUID(), rbac.ADMIN_ROLE, ns.Name())
+	creator.BroadcastAuthRequestTo("cookie")
+	creator.BroadcastSystemMessageTo("Welcome back. You have been restored as the admin of the room you created.")
+	creator.BroadcastAll("info_userlistupdated", &client.Response{
+		Id: creator.UUID(),
+	})
+	return nil
+}
@@ -3,6 +3,7 @@ package socket
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
 	"regexp"
@@ -26,7 +27,16 @@ type Handler struct {
 	PlaybackHandler playback.PlaybackHandler
 	StreamHandler   stream.StreamHandler
 
-	server *socketserver.Server
+	nsHandler     connection.NamespaceHandler
+	server        *socketserver.Server
+	roomCreations *roomCreationLimiter
+	chatLimits    *messageRateLimiter
+	commandLimits *messageRateLimiter
+
+	// options set once the handler is created
+	requireUsername         bool
+	promoteReturningCreator bool
+	creatorReturnPolicy     string
 }
 
 const (
@@ -43,36 +53,69 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 	conn.On("disconnection", func(data connection.MessageDataCodec) {
 		log.Printf("INF DCONN SOCKET client with id %q has disconnected\n", conn.UUID())
 
-		if c, err := h.clientHandler.GetClient(conn.UUID()); err == nil {
-			userName, exists := c.GetUsername()
-			if !exists {
-				userName = c.UUID()
-			}
+		// a client's record is removed early if its username was reclaimed by
+		// a client reconnecting from the same host. Its connection is still
+		// cleaned up below, but other clients are not told that it left.
+		c, err := h.clientHandler.GetClient(conn.UUID())
+		reclaimed := err != nil
+		if reclaimed {
+			c = client.NewClient(conn)
+		}
+
+		userName, exists := c.GetUsername()
+		if !exists {
+			userName = c.UUID()
+		}
+		if !conn.IsObserver() && !reclaimed {
 			c.BroadcastFrom("info_clientleft", &client.Response{
 				Id:   conn.UUID(),
 				From: userName,
 			})
+		}
 
-			ns, exists := c.Namespace()
-			if exists {
-				sPlayback, sPlaybackExists := h.PlaybackHandler.PlaybackByNamespace(ns)
-				if sPlaybackExists {
-					// update room's last updated time to give buffer
-					// between last client leaving and room reaping.
-					sPlayback.SetLastUpdated(time.Now())
+		ns, exists := c.Namespace()
+		if exists {
+			sPlayback, sPlaybackExists := h.PlaybackHandler.PlaybackByNamespace(ns)
+			if sPlaybackExists {
+				// update room's last updated time to give buffer
+				// between last client leaving and room reaping.
+				sPlayback.SetLastUpdated(time.Now())
+
+				// if this was the room's last participant, start the
+				// shorter grace period for empty rooms instead.
+				remaining := 0
+				for _, nsConn := range connection.Participants(ns.Connections()) {
+					if nsConn.UUID() != conn.UUID() {
+						remaining++
+					}
+				}
+				if remaining == 0 && !conn.IsObserver() {
+					sPlayback.MarkEmpty(time.Now())
 				}
 
-				// remove user from authorizer role-bindings
-				authorizer := h.CommandHandler.Authorizer()
+				sPlayback.ClearStreamLoadedAcks(conn.UUID())
+				sPlayback.Unmute(conn.UUID())
+			}
+
+			// remove user from authorizer role-bindings
+			authorizer := h.CommandHandler.Authorizer()
+			if sPlaybackExists {
 				sPlayback.HandleDisconnection(c.Connection(), authorizer, h.clientHandler)
-				if authorizer != nil {
-					for _, b := range authorizer.Bindings() {
-						b.RemoveSubject(c.Connection())
-					}
+			}
+			if authorizer != nil {
+				for _, b := range authorizer.Bindings() {
+					b.RemoveSubject(c.Connection())
 				}
 			}
 		}
 
+		if reclaimed {
+			h.chatLimits.Forget(conn.UUID())
+			h.commandLimits.Forget(conn.UUID())
+			return
+		}
+
+		util.ReleaseClientUsername(c, h.clientHandler)
 		if err := h.DeregisterClient(conn); err != nil {
 			log.Printf("ERR SOCKET %v", err)
 		}
@@ -105,12 +148,19 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 			return
 		}
 
-		err = util.UpdateClientUsername(c, username, h.clientHandler)
+		_, err = util.UpdateClientUsername(c, username, h.clientHandler)
 		if err != nil {
 			log.Printf("ERR SOCKET CLIENT %v. Broadcasting as \"info_clienterror\" event", err)
 			c.BroadcastErrorTo(err)
 			return
 		}
+
+		// hand the client any queue restored for its username
+		if ns, exists := c.Namespace(); exists {
+			if sPlayback, exists := h.PlaybackHandler.PlaybackByNamespace(ns); exists {
+				cmd.ClaimRestoredQueue(c, sPlayback, h.StreamHandler)
+			}
+		}
 	})
 
 	// this event is received when a client is requesting to broadcast a chat message
@@ -139,6 +189,19 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 			return
 		}
 
+		// commands are limited separately from chat messages
+		limiter := h.chatLimits
+		if isCommand {
+			limiter = h.commandLimits
+		}
+		if allowed, warn := limiter.Allow(conn.UUID(), time.Now()); !allowed {
+			log.Printf("INF SOCKET CLIENT dropping message from client with id %q: rate limit exceeded", conn.UUID())
+			if warn {
+				c.BroadcastSystemMessageTo("You are sending messages too quickly. Messages are being dropped; please slow down.")
+			}
+			return
+		}
+
 		if isCommand {
 			cmdSegments := strings.Split(command, " ")
 			cmdArgs := []string{}
@@ -146,26 +209,67 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 				cmdArgs = cmdSegments[1:]
 			}
 
+			if h.isAnonymous(c) && h.commandRequiresUsername(cmdSegments[0]) {
+				c.BroadcastErrorCodeTo(cmd.ErrorCode(ErrUsernameRequired), ErrUsernameRequired)
+				return
+			}
+
 			log.Printf("INF SOCKET CLIENT interpreting chat message as user command %q for client id (%q) with name %q", command, conn.UUID(), username)
 			result, err := h.CommandHandler.ExecuteCommand(cmdSegments[0], cmdArgs, c, h.clientHandler, h.PlaybackHandler, h.StreamHandler)
+			if sPlayback, playbackErr := h.getPlaybackFromClient(c); playbackErr == nil {
+				sPlayback.LogCommand(c.GetUsernameOrId(), command, err)
+			}
 			if err != nil {
 				log.Printf("ERR SOCKET CLIENT unable to execute command with id %q: %v", command, err)
-				c.BroadcastSystemMessageTo(err.Error())
+				c.BroadcastErrorCodeTo(cmd.ErrorCode(err), err)
 				return
 			}
 
 			if len(result) > 0 {
 				c.BroadcastSystemMessageTo(result)
+				return
+			}
+
+			// acknowledge commands that succeed silently, so that
+			// clients can tell them apart from unresponsive commands
+			c.BroadcastTo("info_commandok", &client.Response{
+				Id: c.UUID(),
+				Extra: map[string]interface{}{
+					"command": cmdSegments[0],
+				},
+			})
+			if c.CommandAcks() {
+				c.BroadcastSystemMessageTo(fmt.Sprintf("/%s: done", html.EscapeString(cmdSegments[0])))
 			}
 			return
 		}
 
-		images, err := h.ParseMessageMedia(messageData)
-		if err != nil {
-			log.Printf("ERR SOCKET CLIENT unable to parse client chat message media: %v", err)
+		if h.isAnonymous(c) {
+			c.BroadcastSystemMessageTo(ErrUsernameRequired.Error())
+			return
+		}
+
+		// muted clients still receive the room's chat,
+		// but their own messages are dropped silently
+		sPlayback, playbackErr := h.getPlaybackFromClient(c)
+		if playbackErr == nil && sPlayback.IsMuted(c.UUID()) {
+			log.Printf("INF SOCKET CLIENT dropping chat message from muted client with id %q", c.UUID())
 			return
 		}
 
+		timestamps := h.ParseMessageTimestamps(messageData)
+
+		// leave image urls in the message as-is for
+		// senders that have turned off inline media
+		images := []string{}
+		if c.InlineMedia() {
+			images, err = h.ParseMessageMedia(messageData)
+			if err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to parse client chat message media: %v", err)
+				return
+			}
+		}
+
 		res := &client.Response{
 			Id:    c.UUID(),
 			From:  "system",
@@ -177,6 +281,11 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 			res.Extra["images"] = images
 		}
 
+		// let clients offer to queue streams linked at a timestamp
+		if len(timestamps) > 0 {
+			res.Extra["actionableMedia"] = timestamps
+		}
+
 		b, err := data.Serialize()
 		if err != nil {
 			log.Printf("ERR SOCKET CLIENT unable to serialize client chat message data: %v", err)
@@ -190,6 +299,9 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 		}
 
 		c.BroadcastAll("chatmessage", res)
+		if playbackErr == nil {
+			sPlayback.CountChatMessage()
+		}
 		fmt.Printf("INF SOCKET CLIENT chatmessage received %v\n", data)
 	})
 
@@ -240,6 +352,17 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 		}
 
 		c.BroadcastTo("queuesync", res)
+
+		runtime := sPlayback.QueueRuntime()
+		c.BroadcastTo("info_queueruntime", &client.Response{
+			Id:   c.UUID(),
+			From: "system",
+			Extra: map[string]interface{}{
+				"seconds": runtime.Seconds,
+				"partial": runtime.Partial,
+				"items":   runtime.Items,
+			},
+		})
 	})
 
 	// this event is received when a client is requesting the current queue state for a specific Queue stack
@@ -315,6 +438,90 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 		c.BroadcastTo("streamsync", res)
 	})
 
+	// this event is received when a client acknowledges having received a "streamload" event
+	conn.On("streamloaded", func(data connection.MessageDataCodec) {
+		messageData, ok := data.(connection.MessageData)
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT socket connection event handler for event %q received data of wrong type. Expecting connection.MessageData", "streamloaded")
+			return
+		}
+
+		rawUrl, ok := messageData.Key("url")
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT client %q sent malformed streamload acknowledgement. Ignoring.", conn.UUID())
+			return
+		}
+
+		url, ok := rawUrl.(string)
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT client %q sent a non-string value for the field %q", conn.UUID(), "url")
+			return
+		}
+
+		c, err := h.clientHandler.GetClient(conn.UUID())
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to retrieve client from connection id. Ignoring streamloaded acknowledgement: %v", err)
+			return
+		}
+
+		sPlayback, err := h.getPlaybackFromClient(c)
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT %v", err)
+			return
+		}
+
+		if !sPlayback.AckStreamLoaded(conn.UUID(), url) {
+			log.Printf("INF SOCKET CLIENT client %q acknowledged stream %q, but no acknowledgement was pending", conn.UUID(), url)
+		}
+	})
+
+	// this event is received when a client presents the creator token
+	// issued to them when they created the room they are rejoining
+	conn.On("claimcreator", func(data connection.MessageDataCodec) {
+		if !h.promoteReturningCreator {
+			return
+		}
+
+		messageData, ok := data.(connection.MessageData)
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT socket connection event handler for event %q received data of wrong type. Expecting connection.MessageData", "claimcreator")
+			return
+		}
+
+		rawToken, ok := messageData.Key("token")
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT client %q sent malformed creator claim. Ignoring.", conn.UUID())
+			return
+		}
+
+		token, ok := rawToken.(string)
+		if !ok {
+			log.Printf("ERR SOCKET CLIENT client %q sent a non-string value for the field %q", conn.UUID(), "token")
+			return
+		}
+
+		c, err := h.clientHandler.GetClient(conn.UUID())
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to retrieve client from connection id. Ignoring creator claim: %v", err)
+			return
+		}
+
+		sPlayback, err := h.getPlaybackFromClient(c)
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT %v", err)
+			return
+		}
+
+		if !sPlayback.IsCreatorToken(token) {
+			log.Printf("WRN SOCKET CLIENT client %q presented an invalid creator token for room %q. Ignoring.", conn.UUID(), sPlayback.UUID())
+			return
+		}
+
+		if err := h.promoteCreator(c); err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to promote returning creator %q: %v", conn.UUID(), err)
+		}
+	})
+
 	// this event is received when a client is requesting current stream user information
 	conn.On("request_userlist", func(data connection.MessageDataCodec) {
 		log.Printf("INF SOCKET CLIENT client with id %q requested a userlist", conn.UUID())
@@ -333,7 +540,7 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 		}
 
 		userList := &client.SerializableClientList{}
-		for _, conn := range c.Connections() {
+		for _, conn := range connection.Participants(c.Connections()) {
 			user, err := h.clientHandler.GetClient(conn.UUID())
 			if err != nil {
 				continue
@@ -390,6 +597,26 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 			return
 		}
 
+		// a client reporting on the stream means it has started loading
+		sPlayback.ReportStreamProgress()
+
+		// in rooms that do not allow seeking ahead, the room's timer is the
+		// authority on playback position: reject reports from clients that
+		// are meaningfully ahead of it, and snap them back
+		if position, ok := reportedPosition(data); ok && sPlayback.SeekedAhead(position) {
+			log.Printf("INF SOCKET CLIENT client with id (%q) reported position %v ahead of room %q (%v). Snapping back...", c.UUID(), position, ns.Name(), sPlayback.GetTime())
+
+			res := &client.Response{
+				Id: c.UUID(),
+			}
+			if err := util.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra); err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to serialize playback status: %v", err)
+				return
+			}
+			c.BroadcastTo("streamsync", res)
+			return
+		}
+
 		jsonData, err := data.Serialize()
 		if err != nil {
 			log.Printf("ERR SOCKET CLIENT unable to convert received data map into json string: %v", err)
@@ -404,6 +631,29 @@ func (h *Handler) HandleClientConnection(conn connection.Connection) {
 	})
 }
 
+// reportedPosition receives the data of a "streamdata" event and returns the
+// client's playback position in seconds, given by its "playback" status in the
+// same format sent in "streamsync" events (e.g. {"playback": {"time": 42}}).
+// Returns a boolean (false) if the client did not report its position.
+func reportedPosition(data connection.MessageDataCodec) (float64, bool) {
+	messageData, ok := data.(connection.MessageData)
+	if !ok {
+		return 0, false
+	}
+
+	rawPlayback, ok := messageData.Key("playback")
+	if !ok {
+		return 0, false
+	}
+	playbackStatus, ok := rawPlayback.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	position, ok := playbackStatus["time"].(float64)
+	return position, ok
+}
+
 // ParseMessageMedia receives connection.MessageData and parses
 // image urls in the "message" key, removing urls from the
 // text message, and returning them as a slice of strings
@@ -467,9 +717,11 @@ func (h *Handler) RegisterClient(conn connection.Connection) {
 	log.Printf("INF SOCKET CLIENT registering client with id %q\n", conn.UUID())
 
 	c := h.clientHandler.CreateClient(conn)
-	c.BroadcastFrom("info_clientjoined", &client.Response{
-		Id: c.UUID(),
-	})
+	if !conn.IsObserver() {
+		c.BroadcastFrom("info_clientjoined", &client.Response{
+			Id: c.UUID(),
+		})
+	}
 
 	namespace, nsExists := c.Namespace()
 	if !nsExists {
@@ -487,7 +739,110 @@ func (h *Handler) RegisterClient(conn connection.Connection) {
 	sPlayback, exists := h.PlaybackHandler.PlaybackByNamespace(namespace)
 	if !exists {
 		log.Printf("INF SOCKET CLIENT Playback did not exist for room with namespace %v. Creating...", namespace)
-		sPlayback = h.PlaybackHandler.NewPlayback(namespace, h.CommandHandler.Authorizer(), h.clientHandler)
+
+		creatorIp := ""
+		if req := conn.Request(); req != nil {
+			creatorIp = requestIp(req)
+		}
+		if !h.roomCreations.Allowed(creatorIp, time.Now()) {
+			limit, window := h.roomCreations.Limit()
+			log.Printf("WRN SOCKET CLIENT refusing to create room %q: address %q has reached its limit of %v rooms per %v. Closing connection with id %q...", namespace.Name(), creatorIp, limit, window, conn.UUID())
+			c.BroadcastErrorTo(fmt.Errorf("error: unable to create room %q: you have created too many rooms recently. Try again later, or join an existing room", namespace.Name()))
+			conn.Close()
+			return
+		}
+
+		var err error
+		sPlayback, err = h.PlaybackHandler.NewPlayback(namespace, h.CommandHandler.Authorizer(), h.clientHandler)
+		if err != nil {
+			log.Printf("ERR SOCKET CLIENT unable to create Playback for room %q: %v. Closing connection with id %q...", namespace.Name(), err, conn.UUID())
+			c.BroadcastErrorTo(fmt.Errorf("error: unable to create room %q: %v", namespace.Name(), err))
+			conn.Close()
+			return
+		}
+		h.roomCreations.Record(creatorIp, time.Now())
+		sPlayback.RecordPresence(len(connection.Participants(namespace.Connections())))
+
+		// hand the room's creator a token they can use
+		// to reclaim the admin role if they later rejoin
+		if h.promoteReturningCreator && !conn.IsObserver() {
+			if token, err := sPlayback.IssueCreatorToken(); err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to issue creator token for room %q: %v", namespace.Name(), err)
+			} else {
+				c.BroadcastTo("info_creatortoken", &client.Response{
+					Id: c.UUID(),
+					Extra: map[string]interface{}{
+						"token": token,
+					},
+				})
+			}
+		}
+
+		// pick up where the room left off before a server restart, if possible
+		if snapshot, exists := h.PlaybackHandler.TakeSnapshot(namespace.Name()); exists {
+			skipped := sPlayback.RestoreSnapshot(snapshot, c, h.StreamHandler)
+			log.Printf("INF SOCKET CLIENT restored room %q from a snapshot saved at %v (%v streams skipped)", namespace.Name(), snapshot.SavedAt, skipped)
+
+			// the client restoring the room is the only one to load its stream
+			sendCurrentStream(c, sPlayback)
+		}
+
+		// let clients know when playback starts or ends without
+		// having to compare successive "streamsync" payloads
+		sPlayback.OnStateChange(func(from, to playback.PlaybackState) {
+			c.BroadcastAll("playbackstatechange", &client.Response{
+				Id:   c.UUID(),
+				From: "system",
+				Extra: map[string]interface{}{
+					"from": from,
+					"to":   to,
+				},
+			})
+		})
+
+		// skip streams no client manages to start playing, if the
+		// room has a load timeout (e.g. removed or geo-blocked videos)
+		sPlayback.OnLoadTimeout(func(s stream.Stream) {
+			currPlayback, exists := h.PlaybackHandler.PlaybackByNamespace(namespace)
+			if !exists {
+				return
+			}
+
+			// rooms mirroring another room load whatever it loads next
+			if _, mirroring := currPlayback.Mirroring(); mirroring {
+				return
+			}
+
+			name := s.GetName()
+			if len(name) == 0 {
+				name = s.GetStreamURL()
+			}
+
+			broadcastLoad := cmd.StreamLoadBroadcaster(c, "system", currPlayback)
+			_, advanceErr := currPlayback.AdvanceQueueFrom(s, func(next stream.Stream) {
+				c.BroadcastSystemMessageAll(fmt.Sprintf("Couldn't load %q, skipping...", name))
+				broadcastLoad(next)
+			})
+			if advanceErr == nil || advanceErr == playback.ErrStreamChanged {
+				return
+			}
+
+			log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT stream %q failed to start within the room's load timeout, and there is nothing left to skip to. Stopping...", s.GetStreamURL())
+			c.BroadcastSystemMessageAll(fmt.Sprintf("Couldn't load %q, skipping...", name))
+			currPlayback.Stop()
+
+			res := &client.Response{
+				Id:   c.UUID(),
+				From: "system",
+			}
+			err := util.SerializeIntoResponse(currPlayback.GetStatus(), &res.Extra)
+			if err != nil {
+				log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to serialize playback status: %v", err)
+				return
+			}
+			c.BroadcastAll("streamsync", res)
+		})
+
 		sPlayback.OnTick(func(currentTime int) {
 			currPlayback, exists := h.PlaybackHandler.PlaybackByNamespace(namespace)
 			if !exists {
@@ -500,36 +855,65 @@ func (h *Handler) RegisterClient(conn connection.Connection) {
 				if streamExists {
 					// if stream exists and playback timer >= playback stream duration, stop stream
 					// or queue the next item in the playback queue (if queue not empty)
-					if currStream.GetDuration() > 0 && float64(currPlayback.GetTime()) >= currStream.GetDuration() {
-						queue := currPlayback.GetQueue()
-						queueItem, err := queue.Next()
-						if err == nil {
-							log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT detected end of stream. Auto-queuing next stream...")
+					ended := currStream.GetDuration() > 0 && float64(currPlayback.GetTime()) >= currStream.GetDuration()
 
-							nextStream, ok := queueItem.(stream.Stream)
-							if !ok {
-								log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT expected next queue item to implement stream.Stream... Unable to advance the queue.")
-								return
-							}
+					// streams that overrun the room's max play time (if any) are skipped,
+					// even if their duration is unknown (such as live streams).
+					maxPlayTime, hasMaxPlayTime := currPlayback.MaxPlayTime()
+					overrun := !ended && hasMaxPlayTime && currPlayback.GetTime() >= maxPlayTime
 
-							currPlayback.SetStream(nextStream)
-							currPlayback.Reset()
+					if ended || overrun {
+						// rooms mirroring another room load whatever it loads next
+						if _, mirroring := currPlayback.Mirroring(); mirroring {
+							return
+						}
 
-							res := &client.Response{
-								Id:   c.UUID(),
-								From: "system",
+						// suspend auto-advancing until an admin returns, if the room requires one.
+						// The end of the stream is detected again on every tick until then.
+						authorizer := h.CommandHandler.Authorizer()
+						if currPlayback.AdvanceNeedsAdmin() && authorizer != nil && !playback.HasAdmin(authorizer, namespace) {
+							if currentTime%ROOM_DEFAULT_STREAMSYNC_LOGGING_RATE == 0 {
+								log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT detected end of stream with no admins present. Waiting for an admin before auto-queuing next stream...")
 							}
+							return
+						}
 
-							err = util.SerializeIntoResponse(currPlayback.GetStatus(), &res.Extra)
-							if err != nil {
-								log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to serialize nextStream codec: %v", err)
-								return
-							}
+						// announce the next stream before any other change to the room's stream
+						broadcastLoad := cmd.StreamLoadBroadcaster(c, "system", currPlayback)
 
-							c.BroadcastAll("streamload", res)
+						_, err := currPlayback.AdvanceQueueFrom(currStream, broadcastLoad)
+						if err == playback.ErrStreamChanged {
+							// stream was changed since the end of the stream was detected
+							return
+						}
+						// play the room's recent streams again, if it loops once its queue runs out
+						if err != nil && err != playback.ErrStreamChanged && currPlayback.OnEmpty() == playback.ON_EMPTY_LOOP {
+							looped, loopErr := currPlayback.LoopHistory(c, h.StreamHandler)
+							if loopErr != nil {
+								log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to loop the room's play history: %v", loopErr)
+							} else if looped > 0 {
+								log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT queue ran out. Looping %v streams from the room's play history...", looped)
+								c.BroadcastSystemMessageAll(fmt.Sprintf("The queue has run out. Playing the last %v streams again...", looped))
+								_, err = currPlayback.AdvanceQueueFrom(currStream, broadcastLoad)
+							}
+						}
+						if overrun {
+							log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT stream %q exceeded the room's max play time of %v seconds. Skipping...", currStream.GetStreamURL(), maxPlayTime)
+							c.BroadcastSystemMessageAll(fmt.Sprintf("Skipping %q after reaching the room's maximum play time of %v.", currStream.GetName(), time.Duration(maxPlayTime)*time.Second))
+						}
+						if err == nil {
+							log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT detected end of stream. Auto-queued next stream...")
 						} else {
 							log.Printf("INF CALLBACK-PLAYBACK SOCKET CLIENT detected end of stream and no queue items. Stopping stream...")
 							currPlayback.Stop()
+
+							// have clients show an idle screen until a stream is loaded
+							if currPlayback.OnEmpty() == playback.ON_EMPTY_HOME {
+								c.BroadcastAll("info_roomidle", &client.Response{
+									Id:   c.UUID(),
+									From: "system",
+								})
+							}
 						}
 
 						// emit updated playback state to client if stream has ended
@@ -570,34 +954,80 @@ func (h *Handler) RegisterClient(conn connection.Connection) {
 				return
 			}
 
-			c.BroadcastAll("streamsync", res)
+			if err := c.BroadcastAll("streamsync", res); err != nil {
+				log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to send streamsync event: %v", err)
+			}
 		})
 
 		return
 	}
 
 	sPlayback.SetLastUpdated(time.Now())
+	if !conn.IsObserver() {
+		sPlayback.MarkPopulated()
+		sPlayback.RecordPresence(len(connection.Participants(namespace.Connections())))
+	}
 
 	log.Printf("INF SOCKET CLIENT found Playback for room with name %q", namespace.Name())
 
-	pStream, exists := sPlayback.GetStream()
-	if exists {
-		log.Printf("INF SOCKET CLIENT found stream info (%s) associated with Playback for room with name %q... Sending \"streamload\" signal to client", pStream.GetStreamURL(), namespace)
-		res := &client.Response{
+	if msg, exists := sPlayback.WelcomeMessage(); exists {
+		c.BroadcastSystemMessageTo(msg)
+	}
+
+	// have the new client adopt the room's default volume, if one is set
+	if vol, exists := sPlayback.DefaultVolume(); exists {
+		c.BroadcastChatActionTo("setVolume", []interface{}{
+			vol,
+		})
+	}
+
+	// have the new client display the room's lyrics, if any are loaded
+	if lyricsPath, lines, exists := sPlayback.Lyrics(); exists {
+		c.BroadcastTo("info_lyrics", &client.Response{
 			Id: c.UUID(),
-		}
+			Extra: map[string]interface{}{
+				"path":  lyricsPath,
+				"lines": lines,
+				"on":    true,
+			},
+		})
+	}
 
-		err := util.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
-		if err != nil {
-			log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to serialize playback status: %v", err)
-			return
-		}
+	// joiners can still sync manually through a "request_streamsync" event
+	if !sPlayback.Autoload() {
+		log.Printf("INF SOCKET CLIENT room %q does not autoload its stream for new clients. Skipping \"streamload\" signal to client with id %q", namespace.Name(), c.UUID())
+		return
+	}
+
+	sendCurrentStream(c, sPlayback)
+}
 
-		c.BroadcastTo("streamload", res)
+// sendCurrentStream sends the given client a "streamload" event
+// for the room's current stream, if one is loaded
+func sendCurrentStream(c *client.Client, sPlayback *playback.Playback) {
+	pStream, exists := sPlayback.GetStream()
+	if !exists {
+		return
 	}
+
+	log.Printf("INF SOCKET CLIENT found stream info (%s) associated with Playback for room with name %q... Sending \"streamload\" signal to client", pStream.GetStreamURL(), sPlayback.UUID())
+	res := &client.Response{
+		Id: c.UUID(),
+	}
+
+	err := util.SerializeIntoResponse(sPlayback.GetStatus(), &res.Extra)
+	if err != nil {
+		log.Printf("ERR CALLBACK-PLAYBACK SOCKET CLIENT unable to serialize playback status: %v", err)
+		return
+	}
+
+	cmd.SendStreamLoad(c, sPlayback, res)
 }
 
 func (h *Handler) DeregisterClient(conn connection.Connection) error {
+	h.chatLimits.Forget(conn.UUID())
+	h.commandLimits.Forget(conn.UUID())
+
 	err := h.clientHandler.DestroyClient(conn)
 	if err != nil {
 		return fmt.Errorf("error: unable to de-register client: %v", err)
@@ -619,10 +1049,46 @@ func (h *Handler) getPlaybackFromClient(c *client.Client) (*playback.Playback, e
 	return sPlayback, nil
 }
 
+// ServeHTTP declines a websocket upgrade request if it would result in the
+// creation of a new room while the PlaybackHandler is at capacity.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.admitRequest(r); err != nil {
+		log.Printf("WRN SOCKET refusing websocket request for %q: %v\n", r.URL.String(), err)
+		http.Error(w, fmt.Sprintf("error: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
 	h.server.ServeHTTP(w, r)
 }
 
+// admitRequest returns an error if a socket request targets a room
+// that does not exist yet, and no more rooms can be created.
+func (h *Handler) admitRequest(r *http.Request) error {
+	if !h.PlaybackHandler.AtCapacity() {
+		return nil
+	}
+
+	nsName, err := util.NamespaceFromRequest(r)
+	if err != nil {
+		nsName = socketserver.DEFAULT_NAMESPACE
+	}
+
+	if ns, exists := h.nsHandler.NamespaceByName(nsName); exists {
+		if _, exists := h.PlaybackHandler.PlaybackByNamespace(ns); exists {
+			return nil
+		}
+	}
+
+	return playback.ErrMaxPlaybacksExceeded
+}
+
+// SetCompression receives a boolean determining whether the server attempts
+// to negotiate per-message compression with clients when upgrading their
+// connections. Compression is enabled by default.
+func (h *Handler) SetCompression(enabled bool) {
+	h.server.SetCompression(enabled)
+}
+
 func NewHandler(nsHandler connection.NamespaceHandler, connHandler connection.ConnectionHandler, commandHandler cmd.SocketCommandHandler, clientHandler client.SocketClientHandler, playbackHandler playback.PlaybackHandler, streamHandler stream.StreamHandler) *Handler {
 	handler := &Handler{
 		clientHandler:   clientHandler,
@@ -630,7 +1096,12 @@ func NewHandler(nsHandler connection.NamespaceHandler, connHandler connection.Co
 		PlaybackHandler: playbackHandler,
 		StreamHandler:   streamHandler,
 
-		server: socketserver.NewServer(connHandler, nsHandler),
+		nsHandler:           nsHandler,
+		server:              socketserver.NewServer(connHandler, nsHandler),
+		roomCreations:       newRoomCreationLimiter(),
+		chatLimits:          newMessageRateLimiter(DEFAULT_CHAT_MESSAGE_LIMIT),
+		commandLimits:       newMessageRateLimiter(DEFAULT_CHAT_MESSAGE_LIMIT * 2),
+		creatorReturnPolicy: playback.CreatorReturnKeep,
 	}
 
 	handler.addRequestHandlers()
@@ -0,0 +1,63 @@
+package socket
+
+import (
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+func TestReportedPosition(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           map[string]interface{}
+		expectPosition float64
+		expectOk       bool
+	}{
+		{
+			name: "playback time",
+			data: map[string]interface{}{
+				"url":      "http://example.com/video.mp4",
+				"playback": map[string]interface{}{"time": float64(42)},
+			},
+			expectPosition: 42,
+			expectOk:       true,
+		},
+		{
+			name: "no playback status",
+			data: map[string]interface{}{"url": "http://example.com/video.mp4"},
+		},
+		{
+			name: "playback status without a time",
+			data: map[string]interface{}{
+				"playback": map[string]interface{}{"isPlaying": true},
+			},
+		},
+		{
+			name: "malformed playback status",
+			data: map[string]interface{}{"playback": float64(42)},
+		},
+		{
+			name: "malformed time",
+			data: map[string]interface{}{
+				"playback": map[string]interface{}{"time": "42"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := connection.NewMessageData()
+			for k, v := range tc.data {
+				data.Set(k, v)
+			}
+
+			position, ok := reportedPosition(data)
+			if ok != tc.expectOk {
+				t.Fatalf("expected a reported position: %v, got %v", tc.expectOk, ok)
+			}
+			if position != tc.expectPosition {
+				t.Fatalf("expected position %v, got %v", tc.expectPosition, position)
+			}
+		})
+	}
+}
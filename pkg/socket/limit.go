@@ -0,0 +1,250 @@
+package socket
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DEFAULT_ROOM_CREATION_WINDOW is the period of time over which room
+// creations are counted per ip address, unless set otherwise through
+// Handler.SetRoomCreationLimit.
+const DEFAULT_ROOM_CREATION_WINDOW = 1 * time.Hour
+
+const (
+	// DEFAULT_CHAT_MESSAGE_LIMIT is the maximum number of chat messages
+	// a single client may send within DEFAULT_CHAT_MESSAGE_WINDOW, unless
+	// set otherwise through Handler.SetChatMessageLimit.
+	DEFAULT_CHAT_MESSAGE_LIMIT = 5
+	// DEFAULT_CHAT_MESSAGE_WINDOW is the period of time over
+	// which a client's chat messages and commands are counted.
+	DEFAULT_CHAT_MESSAGE_WINDOW = 2 * time.Second
+)
+
+// MaxTrackedRoomCreators is the maximum number of ip addresses
+// whose room creations are tracked at a time. The ip address
+// with the least recent room creation is evicted once exceeded.
+var MaxTrackedRoomCreators = 1000
+
+// SetRoomCreationLimit receives the maximum number of rooms a single ip
+// address may create within the given window of time. A limit <= 0
+// removes the limit.
+func (h *Handler) SetRoomCreationLimit(limit int, window time.Duration) {
+	h.roomCreations.mux.Lock()
+	defer h.roomCreations.mux.Unlock()
+
+	h.roomCreations.limit = limit
+	h.roomCreations.window = window
+}
+
+// SetChatMessageLimit receives the maximum number of chat messages a single
+// client may send within the given window of time. Messages beyond the limit
+// are dropped. Commands are limited separately, and more loosely, to twice
+// as many, so that moderation is not blocked by chatting. A limit <= 0
+// removes both limits.
+func (h *Handler) SetChatMessageLimit(limit int, window time.Duration) {
+	h.chatLimits.SetLimit(limit, window)
+	h.commandLimits.SetLimit(limit*2, window)
+}
+
+// roomCreationLimiter keeps track of recent room creations
+// per ip address, refusing new rooms beyond its limit.
+type roomCreationLimiter struct {
+	mux sync.Mutex
+	// limit is the maximum number of rooms a single ip address
+	// may create within the window. A value of 0 means no limit.
+	limit     int
+	window    time.Duration
+	creations map[string][]time.Time
+}
+
+// Allowed returns true if the given ip address is
+// permitted to create a new room at the given time.
+func (l *roomCreationLimiter) Allowed(ip string, now time.Time) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.limit <= 0 {
+		return true
+	}
+	return len(l.recent(ip, now)) < l.limit
+}
+
+// Limit returns the maximum number of rooms a single ip address may
+// create, and the period of time over which room creations are counted.
+func (l *roomCreationLimiter) Limit() (int, time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	return l.limit, l.window
+}
+
+// Record stores a room creation by the given ip address.
+func (l *roomCreationLimiter) Record(ip string, now time.Time) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.limit <= 0 {
+		return
+	}
+
+	recent := l.recent(ip, now)
+	if len(recent) == 0 {
+		l.evict(now)
+	}
+	l.creations[ip] = append(recent, now)
+}
+
+// recent prunes and returns room creations by the given ip address
+// that fall within the current window. Caller must hold the lock.
+func (l *roomCreationLimiter) recent(ip string, now time.Time) []time.Time {
+	times, exists := l.creations[ip]
+	if !exists {
+		return nil
+	}
+
+	recent := []time.Time{}
+	for _, t := range times {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) == 0 {
+		delete(l.creations, ip)
+		return nil
+	}
+
+	l.creations[ip] = recent
+	return recent
+}
+
+// evict makes room for a newly tracked ip address by dropping
+// expired entries, and the least recently active ip address
+// if the limiter is still full. Caller must hold the lock.
+func (l *roomCreationLimiter) evict(now time.Time) {
+	if len(l.creations) < MaxTrackedRoomCreators {
+		return
+	}
+
+	oldestIp := ""
+	var oldest time.Time
+	for ip, times := range l.creations {
+		last := times[len(times)-1]
+		if now.Sub(last) >= l.window {
+			delete(l.creations, ip)
+			continue
+		}
+		if len(oldestIp) == 0 || last.Before(oldest) {
+			oldestIp = ip
+			oldest = last
+		}
+	}
+
+	if len(l.creations) >= MaxTrackedRoomCreators && len(oldestIp) > 0 {
+		delete(l.creations, oldestIp)
+	}
+}
+
+func newRoomCreationLimiter() *roomCreationLimiter {
+	return &roomCreationLimiter{
+		window:    DEFAULT_ROOM_CREATION_WINDOW,
+		creations: make(map[string][]time.Time),
+	}
+}
+
+// tokenBucket holds the messages a client may still
+// send, refilled gradually over a window of time
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// warned is true if the client has been told that its
+	// messages are being dropped since it last ran out
+	warned bool
+}
+
+// messageRateLimiter keeps a token bucket per client
+// connection, dropping messages beyond its limit.
+type messageRateLimiter struct {
+	mux sync.Mutex
+	// limit is the maximum number of messages a single client
+	// may send within the window. A value of 0 means no limit.
+	limit   int
+	window  time.Duration
+	buckets map[string]*tokenBucket
+}
+
+// SetLimit receives the maximum number of messages a single
+// client may send within the given window of time.
+func (l *messageRateLimiter) SetLimit(limit int, window time.Duration) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.limit = limit
+	l.window = window
+}
+
+// Allow consumes a token from the given connection's bucket. Returns
+// true if the message is allowed, and a boolean (true) if the message
+// is dropped and the client has not yet been warned about it.
+func (l *messageRateLimiter) Allow(connId string, now time.Time) (bool, bool) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	limit := l.limit
+	if limit <= 0 || l.window <= 0 {
+		return true, false
+	}
+
+	bucket, exists := l.buckets[connId]
+	if !exists {
+		bucket = &tokenBucket{
+			tokens: float64(limit),
+			last:   now,
+		}
+		l.buckets[connId] = bucket
+	}
+
+	// refill the bucket at a rate of limit tokens per window
+	bucket.tokens += now.Sub(bucket.last).Seconds() * float64(limit) / l.window.Seconds()
+	if bucket.tokens > float64(limit) {
+		bucket.tokens = float64(limit)
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		warn := !bucket.warned
+		bucket.warned = true
+		return false, warn
+	}
+
+	bucket.tokens--
+	bucket.warned = false
+	return true, false
+}
+
+// Forget drops the bucket held for the given connection
+func (l *messageRateLimiter) Forget(connId string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	delete(l.buckets, connId)
+}
+
+func newMessageRateLimiter(limit int) *messageRateLimiter {
+	return &messageRateLimiter{
+		limit:   limit,
+		window:  DEFAULT_CHAT_MESSAGE_WINDOW,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// requestIp returns the ip address a request originated from
+func requestIp(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
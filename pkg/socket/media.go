@@ -0,0 +1,66 @@
+package socket
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+	"github.com/juanvallejo/streaming-server/pkg/stream"
+)
+
+// ACTIONABLE_MEDIA_TIMESTAMP is the kind of actionable media
+// describing a link to a point in time in a stream
+const ACTIONABLE_MEDIA_TIMESTAMP = "timestamp"
+
+// STRIP_MEDIA_KEY is the chat message field clients set to have
+// actionable links removed from the message text
+const STRIP_MEDIA_KEY = "stripMedia"
+
+var messageUrlPattern = regexp.MustCompile("https?://[^ ]+")
+
+// ActionableMedia describes a link in a chat message that clients
+// may offer to act on, such as queueing a stream from a timestamp.
+type ActionableMedia struct {
+	Kind    string `json:"kind"`
+	Url     string `json:"url"`
+	VideoId string `json:"videoId"`
+	// Start is the amount of seconds into the stream the link points to
+	Start int `json:"start"`
+}
+
+// ParseMessageTimestamps extracts youtube links to a timestamp from a chat
+// message. The links are left in the message text, unless the message sets
+// STRIP_MEDIA_KEY.
+func (h *Handler) ParseMessageTimestamps(data connection.MessageData) []*ActionableMedia {
+	media := []*ActionableMedia{}
+
+	message, ok := data.Key("message")
+	if !ok {
+		return media
+	}
+	rawText, ok := message.(string)
+	if !ok {
+		return media
+	}
+
+	newText := rawText
+	for _, link := range messageUrlPattern.FindAllString(rawText, -1) {
+		videoId, start, ok := stream.YouTubeTimestamp(link)
+		if !ok {
+			continue
+		}
+
+		media = append(media, &ActionableMedia{
+			Kind:    ACTIONABLE_MEDIA_TIMESTAMP,
+			Url:     link,
+			VideoId: videoId,
+			Start:   start,
+		})
+		newText = strings.Replace(newText, link, "", 1)
+	}
+
+	if strip, ok := data.Key(STRIP_MEDIA_KEY); ok && strip == true && len(media) > 0 {
+		data.Set("message", strings.TrimSpace(newText))
+	}
+	return media
+}
@@ -36,6 +36,9 @@ type Server struct {
 	// connHandler is a handler for incoming connection upgrade requests
 	connHandler connection.ConnectionHandler
 	nsHandler   connection.NamespaceHandler
+	// enableCompression determines whether the server attempts to negotiate
+	// per-message compression with clients when upgrading their connections
+	enableCompression bool
 }
 
 func (s *Server) On(eventName string, callback ServerEventCallback) {
@@ -58,6 +61,13 @@ func (s *Server) Emit(eventName string, conn connection.Connection) {
 	}
 }
 
+// SetCompression receives a boolean determining whether the server attempts
+// to negotiate per-message compression with clients when upgrading their
+// connections. Compression is enabled by default.
+func (s *Server) SetCompression(enabled bool) {
+	s.enableCompression = enabled
+}
+
 // ServeHTTP handles a connection upgrade request, and handles socket connection admission
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	origin := getClientOrigin(r)
@@ -79,7 +89,20 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		namespace = s.nsHandler.NewNamespace(nsName)
 	}
 
-	conn, err := websocket.Upgrade(w, r, w.Header(), MAX_READ_BUF_SIZE, MAX_WRITE_BUF_SIZE)
+	upgrader := &websocket.Upgrader{
+		ReadBufferSize:    MAX_READ_BUF_SIZE,
+		WriteBufferSize:   MAX_WRITE_BUF_SIZE,
+		EnableCompression: s.enableCompression,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			// errors are logged below, rather than returned to the client
+		},
+		CheckOrigin: func(r *http.Request) bool {
+			// request origins are handled through access-control headers
+			return true
+		},
+	}
+
+	conn, err := upgrader.Upgrade(w, r, w.Header())
 	if err != nil {
 		log.Printf("ERR SOCKET SERVER unable to upgrade connection for %q: %v\n", r.URL.String(), err)
 		return
@@ -94,9 +117,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func NewServer(handler connection.ConnectionHandler, nsHandler connection.NamespaceHandler) *Server {
 	return &Server{
-		callbacks:   make(map[string][]ServerEventCallback),
-		connHandler: handler,
-		nsHandler:   nsHandler,
+		callbacks:         make(map[string][]ServerEventCallback),
+		connHandler:       handler,
+		nsHandler:         nsHandler,
+		enableCompression: true,
 	}
 }
 
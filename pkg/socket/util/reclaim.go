@@ -0,0 +1,37 @@
+package util
+
+import (
+	"net"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+)
+
+// ReleaseClientUsername reserves a disconnecting client's username for the
+// grace period, so that it can be reclaimed by a client reconnecting from
+// the same host, but not taken by anyone else in the meantime.
+func ReleaseClientUsername(c *client.Client, clientHandler client.SocketClientHandler) {
+	usernameMux.Lock()
+	defer usernameMux.Unlock()
+
+	username, hasName := c.GetUsername()
+	if !hasName {
+		return
+	}
+
+	clientHandler.ReserveUsername(username, clientHost(c), time.Now())
+}
+
+// clientHost returns the host a client's connection originated from
+func clientHost(c *client.Client) string {
+	req := c.Connection().Request()
+	if req == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
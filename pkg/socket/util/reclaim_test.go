@@ -0,0 +1,135 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/client"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+// fakeConn is a connection that discards everything sent to it
+type fakeConn struct {
+	connection.Connection
+
+	id       string
+	req      *http.Request
+	closed   bool
+	observer bool
+}
+
+func (c *fakeConn) UUID() string                            { return c.id }
+func (c *fakeConn) IsClosed() bool                          { return c.closed }
+func (c *fakeConn) IsObserver() bool                        { return c.observer }
+func (c *fakeConn) Request() *http.Request                  { return c.req }
+func (c *fakeConn) Send([]byte)                             {}
+func (c *fakeConn) Namespace() (connection.Namespace, bool) { return nil, false }
+
+func newFakeConn(id, remoteAddr string) *fakeConn {
+	return &fakeConn{
+		id:  id,
+		req: &http.Request{RemoteAddr: remoteAddr},
+	}
+}
+
+func TestUpdateClientUsernameReclaim(t *testing.T) {
+	tests := []struct {
+		name string
+		// grace is the reclaim grace period
+		grace time.Duration
+		// deregistered determines whether the disconnected client's
+		// record was removed before the client reconnected
+		deregistered bool
+		// wait is the time between disconnecting and reconnecting
+		wait            time.Duration
+		reconnectAddr   string
+		expectErr       bool
+		expectStaleGone bool
+	}{
+		{
+			name:            "reconnect from same host before deregistration",
+			grace:           time.Minute,
+			reconnectAddr:   "10.0.0.1:5001",
+			expectStaleGone: true,
+		},
+		{
+			name:          "reconnect from same host within grace period",
+			grace:         time.Minute,
+			deregistered:  true,
+			reconnectAddr: "10.0.0.1:5001",
+		},
+		{
+			name:          "reconnect from another host within grace period",
+			grace:         time.Minute,
+			deregistered:  true,
+			reconnectAddr: "10.0.0.2:5001",
+			expectErr:     true,
+		},
+		{
+			name:          "another host before deregistration",
+			grace:         time.Minute,
+			reconnectAddr: "10.0.0.2:5001",
+			expectErr:     true,
+		},
+		{
+			name:          "another host after grace period",
+			grace:         10 * time.Millisecond,
+			deregistered:  true,
+			wait:          20 * time.Millisecond,
+			reconnectAddr: "10.0.0.2:5001",
+		},
+		{
+			name:          "another host with grace period disabled",
+			deregistered:  true,
+			reconnectAddr: "10.0.0.2:5001",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			clientHandler := client.NewHandler()
+			clientHandler.SetUsernameReclaimGracePeriod(tc.grace)
+
+			oldConn := newFakeConn("old", "10.0.0.1:5000")
+			oldClient := clientHandler.CreateClient(oldConn)
+			if _, err := UpdateClientUsername(oldClient, "alice", clientHandler); err != nil {
+				t.Fatalf("unexpected error assigning initial username: %v", err)
+			}
+
+			// disconnect
+			oldConn.closed = true
+			if tc.deregistered {
+				ReleaseClientUsername(oldClient, clientHandler)
+				if err := clientHandler.DestroyClient(oldConn); err != nil {
+					t.Fatalf("unexpected error deregistering client: %v", err)
+				}
+			}
+			time.Sleep(tc.wait)
+
+			newClient := clientHandler.CreateClient(newFakeConn("new", tc.reconnectAddr))
+			username, err := UpdateClientUsername(newClient, "alice", clientHandler)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected the username to be taken, but it was assigned as %q", username)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if username != "alice" {
+				t.Fatalf("expected username %q, got %q", "alice", username)
+			}
+
+			_, err = clientHandler.GetClient("old")
+			if tc.expectStaleGone && err == nil {
+				t.Fatalf("expected the stale client record to be removed")
+			}
+
+			if clientHandler.ReservedUsernames("", time.Now())["alice"] {
+				t.Fatalf("expected the reclaimed username to no longer be reserved")
+			}
+		})
+	}
+}
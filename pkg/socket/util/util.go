@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"path"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 
 	"time"
 
@@ -20,11 +22,24 @@ import (
 
 const ROOM_URL_SEGMENT = "/v/"
 
-// TODO: make this function concurrency-safe
-func UpdateClientUsername(c *client.Client, username string, clientHandler client.SocketClientHandler) error {
+// usernameMux serializes username updates so that checking
+// whether a username is taken and claiming it happen atomically
+var usernameMux sync.Mutex
+
+// UpdateClientUsername assigns a username to the given client.
+// The username of a disconnected client remains reserved for the
+// reclaim grace period, and may only be reclaimed by a client
+// connecting from the same host. Reclaiming a username removes
+// the disconnected client's record, if it still exists. Returns the username assigned to the client,
+// which differs from the requested one if it was taken and
+// the client handler suffixes taken usernames.
+func UpdateClientUsername(c *client.Client, username string, clientHandler client.SocketClientHandler) (string, error) {
+	usernameMux.Lock()
+	defer usernameMux.Unlock()
+
 	err := validation.ValidateClientUsername(username)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	prevName, hasPrevName := c.GetUsername()
@@ -32,17 +47,51 @@ func UpdateClientUsername(c *client.Client, username string, clientHandler clien
 	log.Printf("INF SOCKET CLIENT client with id %q requested a username update (%q -> %q)", c.UUID(), prevName, username)
 
 	if hasPrevName && prevName == username {
-		return fmt.Errorf("error: you already have that username")
+		return "", fmt.Errorf("error: you already have that username")
+	}
+
+	now := time.Now()
+	stale := make(map[string][]*client.Client)
+	for _, otherUser := range clientHandler.Clients() {
+		otherUserName, hasName := otherUser.GetUsername()
+		if !hasName || otherUser.UUID() == c.UUID() || !otherUser.Connection().IsClosed() {
+			continue
+		}
+		// stale client records, not yet cleaned up by the disconnection
+		// handler, hold their username through a reservation instead
+		clientHandler.ReserveUsername(otherUserName, clientHost(otherUser), now)
+		stale[otherUserName] = append(stale[otherUserName], otherUser)
 	}
 
+	taken := clientHandler.ReservedUsernames(clientHost(c), now)
 	for _, otherUser := range clientHandler.Clients() {
 		otherUserName, hasName := otherUser.GetUsername()
-		if !hasName {
+		if !hasName || otherUser.UUID() == c.UUID() || otherUser.Connection().IsClosed() {
 			continue
 		}
-		if username == otherUserName {
-			return fmt.Errorf("error: the username %q is taken", username)
+
+		taken[otherUserName] = true
+	}
+
+	requested := username
+	if taken[username] {
+		if !clientHandler.SuffixTakenUsernames() {
+			return "", fmt.Errorf("error: the username %q is taken", username)
+		}
+
+		username = ""
+		for n := 2; n <= len(taken)+2; n++ {
+			candidate := requested + strconv.Itoa(n)
+			if !taken[candidate] && validation.ValidateClientUsername(candidate) == nil {
+				username = candidate
+				break
+			}
+		}
+		if len(username) == 0 || (hasPrevName && prevName == username) {
+			return "", fmt.Errorf("error: the username %q is taken", requested)
 		}
+
+		log.Printf("INF SOCKET CLIENT username %q is taken. Assigning %q to client with id %q instead", requested, username, c.UUID())
 	}
 
 	if err := c.UpdateUsername(username); err != nil {
@@ -52,7 +101,18 @@ func UpdateClientUsername(c *client.Client, username string, clientHandler clien
 		}
 
 		log.Printf("ERR SOCKET CLIENT failed to update username (%q -> %q) for client with id %q", oldName, username, c.UUID())
-		return err
+		return "", err
+	}
+
+	if clientHandler.ReclaimUsername(username) {
+		log.Printf("INF SOCKET CLIENT client with id %q reclaimed the reserved username %q", c.UUID(), username)
+
+		for _, staleUser := range stale[username] {
+			log.Printf("INF SOCKET CLIENT removing stale client with id %q after its username %q was reclaimed", staleUser.UUID(), username)
+			if err := clientHandler.DestroyClient(staleUser.Connection()); err != nil {
+				log.Printf("ERR SOCKET CLIENT unable to remove stale client with id %q: %v", staleUser.UUID(), err)
+			}
+		}
 	}
 
 	log.Printf("INF SOCKET CLIENT sending \"updateusername\" event to client with id %q (%s)\n", c.UUID(), username)
@@ -75,7 +135,11 @@ func UpdateClientUsername(c *client.Client, username string, clientHandler clien
 		IsSystem: true,
 	})
 
-	return nil
+	if username != requested {
+		c.BroadcastSystemMessageTo(fmt.Sprintf("The username %q is taken. You have been given the username %q instead.", requested, username))
+	}
+
+	return username, nil
 }
 
 // GetRoomNameFromRequest receives a socket connection request and returns
@@ -142,7 +206,8 @@ func rolesFromCookie(r *http.Request, authorizer rbac.Authorizer, namespace conn
 // BindDefaultuserRoles computes the default roles to assign to a given connection request
 // based on previously stored auth data as well as a connection's namespace state.
 // The following rules will be followed (in the given order) when determining which roles to return:
-//  - If no other connections are bound to the given namespace, an "admin" role will be assigned
+//  - If the connection is an observer, only a "viewer" role will be assigned.
+//  - If no other participants are bound to the given namespace, an "admin" role will be assigned
 //    and forced onto the connection - regardless of previously stored data on an existing auth cookie.
 //  - If auth information was previously stored in an auth cookie, and the computed role based on
 //    the namespace state is not "admin", the stored roles will be forced onto the connection.
@@ -154,10 +219,19 @@ func DefaultRoles(r *http.Request, authorizer rbac.Authorizer, connUUID string,
 		return []rbac.Role{}, fmt.Errorf("attempt to assign default roles to user (%s) with no authorizer enabled", connUUID)
 	}
 
+	// observers are only allowed to view the room
+	if conn, exists := namespace.Connection(connUUID); exists && conn.IsObserver() {
+		role, found := authorizer.Role(rbac.VIEWER_ROLE)
+		if !found {
+			return []rbac.Role{}, fmt.Errorf("unable to bind role %q to observer connection with id (%s): unable to find role", rbac.VIEWER_ROLE, connUUID)
+		}
+		return []rbac.Role{role}, nil
+	}
+
 	// assign admin role to user if room already exists,
-	// but they are the only client assigned to it
+	// but they are the only participant assigned to it
 	roleName := rbac.ADMIN_ROLE
-	for _, c := range namespace.Connections() {
+	for _, c := range connection.Participants(namespace.Connections()) {
 		if c.UUID() == connUUID {
 			continue
 		}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/juanvallejo/streaming-server/pkg/socket/cmd/rbac"
+	"github.com/juanvallejo/streaming-server/pkg/socket/connection"
+)
+
+func TestDefaultRolesObservers(t *testing.T) {
+	authorizer := rbac.NewAuthorizer()
+	for _, name := range []string{rbac.VIEWER_ROLE, rbac.USER_ROLE, rbac.ADMIN_ROLE} {
+		authorizer.AddRole(rbac.NewRole(name, []rbac.Rule{}))
+	}
+
+	tests := []struct {
+		name string
+		// others are the connections already in the room
+		others     []*fakeConn
+		observer   bool
+		expectRole string
+	}{
+		{
+			name:       "first participant",
+			expectRole: rbac.ADMIN_ROLE,
+		},
+		{
+			name:       "participant joining observers",
+			others:     []*fakeConn{{id: "observer", observer: true}},
+			expectRole: rbac.ADMIN_ROLE,
+		},
+		{
+			name:       "participant joining participants",
+			others:     []*fakeConn{{id: "participant"}},
+			expectRole: rbac.USER_ROLE,
+		},
+		{
+			name:       "observer joining an empty room",
+			observer:   true,
+			expectRole: rbac.VIEWER_ROLE,
+		},
+		{
+			name:       "observer joining participants",
+			others:     []*fakeConn{{id: "participant"}},
+			observer:   true,
+			expectRole: rbac.VIEWER_ROLE,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ns := connection.NewNamespace("room")
+			for _, c := range tc.others {
+				ns.Add(c)
+			}
+			conn := &fakeConn{id: "joining", observer: tc.observer}
+			ns.Add(conn)
+
+			req := &http.Request{Header: http.Header{}}
+			roles, err := DefaultRoles(req, authorizer, conn.UUID(), ns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(roles) != 1 || roles[0].Name() != tc.expectRole {
+				t.Fatalf("expected the %q role, got %v", tc.expectRole, roles)
+			}
+		})
+	}
+}
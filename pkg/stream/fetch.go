@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	// MetadataFetchAttempts is the maximum amount of times a request
+	// for a stream's metadata is made before giving up on it
+	MetadataFetchAttempts = 3
+	// MetadataFetchBackoff is the amount of time to wait before retrying
+	// a failed metadata request. It is doubled after every attempt.
+	MetadataFetchBackoff = 200 * time.Millisecond
+	// MetadataFetchTimeout is the amount of time a single
+	// metadata request is allowed to take
+	MetadataFetchTimeout = 5 * time.Second
+)
+
+// fetchMetadata performs the request built by newRequest and returns its
+// response body. Requests that fail, time out, or receive a server error
+// are retried with an exponential backoff, up to MetadataFetchAttempts
+// times. Other responses are returned as-is for the caller to interpret.
+func fetchMetadata(newRequest func() (*http.Request, error)) ([]byte, error) {
+	backoff := MetadataFetchBackoff
+
+	var err error
+	for attempt := 1; attempt <= MetadataFetchAttempts; attempt++ {
+		var data []byte
+		var retry bool
+		data, retry, err = fetchMetadataOnce(newRequest)
+		if err == nil || !retry {
+			return data, err
+		}
+
+		if attempt < MetadataFetchAttempts {
+			log.Printf("WRN STREAM metadata request failed (attempt %v of %v): %v. Retrying in %v...", attempt, MetadataFetchAttempts, err, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return nil, err
+}
+
+// fetchMetadataOnce performs a single metadata request, returning
+// its response body, or an error and whether it is worth retrying.
+func fetchMetadataOnce(newRequest func() (*http.Request, error)) ([]byte, bool, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), MetadataFetchTimeout)
+	defer cancel()
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, true, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests {
+		return nil, true, fmt.Errorf("metadata request failed with status %q", res.Status)
+	}
+	return data, false, nil
+}
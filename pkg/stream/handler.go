@@ -1,13 +1,14 @@
 package stream
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
-	"os"
-	"strings"
+)
 
-	paths "github.com/juanvallejo/streaming-server/pkg/server/path"
+var (
+	ErrMaxStreamsExceeded = errors.New("the server has reached its maximum number of registered streams, try again later")
 )
 
 type StreamHandler interface {
@@ -24,10 +25,13 @@ type StreamHandler interface {
 	// NewStream creates and registers a new stream object
 	// with a unique identifier url.
 	// Returns a Stream object or an error if a stream has already
-	// been registered with the given url
+	// been registered with the given url, or if the handler is at capacity.
 	NewStream(string) (Stream, error)
 	// GetSize returns the number of stream objects currently registered
 	GetSize() int
+	// SetMaxStreams receives the maximum amount of streams the handler
+	// is allowed to register. A value <= 0 removes the limit.
+	SetMaxStreams(int)
 }
 
 // Handler provides a convenience set of methods for
@@ -35,6 +39,7 @@ type StreamHandler interface {
 type Handler struct {
 	isGarbageCollected bool
 	garbageCollector   *StreamReaper
+	maxStreams         int
 	streams            map[string]Stream
 }
 
@@ -66,6 +71,27 @@ func (h *Handler) GetSize() int {
 	return len(h.streams)
 }
 
+func (h *Handler) SetMaxStreams(max int) {
+	h.maxStreams = max
+}
+
+func (h *Handler) atCapacity() bool {
+	return h.maxStreams > 0 && len(h.streams) >= h.maxStreams
+}
+
+// reapUnreferenced reaps every stream that is not currently
+// aggregated by any parent ref, regardless of its staleness.
+func (h *Handler) reapUnreferenced() {
+	for _, s := range h.GetStreams() {
+		if len(s.Metadata().GetParentRefs()) > 0 {
+			continue
+		}
+		if h.ReapStream(s) {
+			log.Printf("INF StreamHandler stream limit reached; force-reaping unreferenced stream with url %q\n", s.GetStreamURL())
+		}
+	}
+}
+
 func (h *Handler) initGarbageCollector() {
 	// if handler is already being garbage collected, perform a no-op
 	if h.isGarbageCollected {
@@ -89,78 +115,31 @@ func (h *Handler) NewStream(streamUrl string) (Stream, error) {
 		return nil, fmt.Errorf("error: a stream with resource location %q has already been registered", streamUrl)
 	}
 
+	// if the stream limit has been reached, attempt to free up
+	// space before refusing to register the new stream
+	if h.atCapacity() {
+		h.reapUnreferenced()
+		if h.atCapacity() {
+			log.Printf("WRN StreamHandler refusing to register stream %q: %v streams registered (max %v)\n", streamUrl, len(h.streams), h.maxStreams)
+			return nil, ErrMaxStreamsExceeded
+		}
+	}
+
 	u, err := url.Parse(streamUrl)
 	if err != nil {
 		return nil, err
 	}
 
-	if u.Scheme == "http" || u.Scheme == "https" {
-		host := u.Host
-		segs := strings.Split(u.Host, "www.")
-		if len(segs) > 1 {
-			host = segs[1]
-		}
-
-		switch host {
-		case "youtube.com", "youtu.be", "m.youtube.com":
-			s := NewYouTubeStream(streamUrl)
-			h.streams[streamUrl] = s
-			return s, nil
-		case "api.soundcloud.com", "soundcloud.com":
-			s := NewSoundCloudStream(streamUrl)
-			h.streams[streamUrl] = s
-			return s, nil
-		case "twitch.tv":
-			s := NewTwitchStream(streamUrl)
-			h.streams[streamUrl] = s
-			return s, nil
-		case "clips-media-assets.twitch.tv":
-			params := u.Query()
-			if len(params.Get("clip")) == 0 {
-				return nil, fmt.Errorf("invalid Twitch clip url. Missing ?clip= parameter")
-			}
-
-			s := NewTwitchClipStream(streamUrl)
-			h.streams[streamUrl] = s
-			return s, nil
-		default:
-			// handle remote urls
-			supportedFormats := map[string]bool{
-				".mp4":  true,
-				".webm": true,
-				".mkv":  true,
-			}
-
-			format := paths.FileExtensionFromFilePath(u.Path)
-			if supported, ok := supportedFormats[strings.ToLower(format)]; ok && supported {
-				s := NewRemoteVideoStream(streamUrl)
-				h.streams[streamUrl] = s
-				return s, nil
-			}
-		}
-
+	provider, ok := ProviderForUrl(u)
+	if !ok {
 		return nil, fmt.Errorf("stream resource location interpreted as url, but stream source is not supported for: %q", streamUrl)
 	}
 
-	fpath := paths.StreamDataFilePathFromFilename(streamUrl)
-
-	// determine if a mimetype can be determined from the requested filepath,
-	// and that the mimetype (if any) is supported.
-	mimeType, err := paths.FileMimeFromFilePath(streamUrl)
-	if err != nil || !strings.HasPrefix(mimeType, "video") {
-		log.Printf("ERR SOCKET CLIENT error parsing file mimetype (%q): %v", mimeType, err)
-		return nil, fmt.Errorf("unable to load %q. Unsupported streaming file.", streamUrl)
-	}
-
-	_, err = os.Stat(fpath)
+	s, err := provider.newStream(u, streamUrl)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("unable to load %q: video file does not exist.", streamUrl)
-		}
-		return nil, fmt.Errorf("unable to load %q: %v", streamUrl, err)
+		return nil, err
 	}
 
-	s := NewLocalVideoStream(streamUrl)
 	h.streams[streamUrl] = s
 	return s, nil
 }
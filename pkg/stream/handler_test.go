@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestNewStreamYouTubeHosts(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		expectId  string
+		expectErr bool
+	}{
+		{
+			name:     "youtube.com",
+			url:      "https://youtube.com/watch?v=abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "www.youtube.com",
+			url:      "https://www.youtube.com/watch?v=abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "m.youtube.com",
+			url:      "https://m.youtube.com/watch?v=abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "youtu.be",
+			url:      "https://youtu.be/abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "music.youtube.com",
+			url:      "https://music.youtube.com/watch?v=abc123&feature=share",
+			expectId: "abc123",
+		},
+		{
+			name:     "youtube.com embed",
+			url:      "https://www.youtube.com/embed/abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "youtube-nocookie.com embed",
+			url:      "https://www.youtube-nocookie.com/embed/abc123",
+			expectId: "abc123",
+		},
+		{
+			name:     "youtube-nocookie.com embed without www",
+			url:      "https://youtube-nocookie.com/embed/abc123/",
+			expectId: "abc123",
+		},
+		{
+			name:      "unsupported host",
+			url:       "https://notyoutube.com/watch?v=abc123",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHandler().NewStream(tc.url)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got a stream of kind %q", s.GetKind())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.GetKind() != STREAM_TYPE_YOUTUBE {
+				t.Fatalf("expected a stream of kind %q, got %q", STREAM_TYPE_YOUTUBE, s.GetKind())
+			}
+
+			id, err := ytVideoIdFromUrl(s.GetStreamURL())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tc.expectId {
+				t.Fatalf("expected video id %q, got %q", tc.expectId, id)
+			}
+		})
+	}
+}
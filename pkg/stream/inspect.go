@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// InspectTimeout is the amount of time to wait for
+// an inspected stream's metadata to be fetched
+var InspectTimeout = 15 * time.Second
+
+// Inspection is a serializable summary of a resolved stream:
+// its resolved kind and fields, and any error encountered
+// while fetching its metadata.
+type Inspection struct {
+	Url    string `json:"url"`
+	Kind   string `json:"kind"`
+	Stream Stream `json:"stream"`
+	// FetchError describes why the stream's metadata
+	// could not be fetched, if it could not be
+	FetchError string `json:"fetchError,omitempty"`
+}
+
+func (i *Inspection) Serialize() ([]byte, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return b, nil
+}
+
+// Inspect receives a url, resolves it into a stream using a throwaway
+// handler, and waits up to the given timeout for its metadata to be
+// fetched. The resolved stream is never registered with a live handler.
+// Returns an error if the url cannot be resolved into a stream.
+func Inspect(url string, timeout time.Duration) (*Inspection, error) {
+	s, err := NewHandler().NewStream(url)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &Inspection{
+		Url:    url,
+		Kind:   s.GetKind(),
+		Stream: s,
+	}
+
+	type fetchResult struct {
+		data []byte
+		err  error
+	}
+
+	// fetched metadata is applied here, rather than in the callback,
+	// so that a fetch finishing after the timeout never mutates the
+	// stream while it is being serialized.
+	done := make(chan fetchResult, 1)
+	s.FetchMetadata(func(s Stream, data []byte, err error) {
+		done <- fetchResult{data, err}
+	})
+
+	select {
+	case res := <-done:
+		err := res.err
+		if err == nil {
+			err = s.SetInfo(res.data)
+		}
+		if err != nil {
+			inspection.FetchError = err.Error()
+		}
+	case <-time.After(timeout):
+		inspection.FetchError = fmt.Sprintf("timed out after %v waiting for stream metadata", timeout)
+	}
+	return inspection, nil
+}
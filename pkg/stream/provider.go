@@ -0,0 +1,193 @@
+package stream
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	paths "github.com/juanvallejo/streaming-server/pkg/server/path"
+)
+
+// Provider describes a supported source of streams and
+// is used to resolve a stream url into a specific stream type.
+type Provider struct {
+	// Name uniquely identifies the provider
+	Name string `json:"name"`
+	// Kind is the kind of stream created by the provider
+	Kind string `json:"kind"`
+	// Hosts are the url hosts handled by the provider, minus any "www." prefix
+	Hosts []string `json:"hosts,omitempty"`
+	// Formats are the file extensions handled by the provider
+	Formats []string `json:"formats,omitempty"`
+	// Examples are sample stream locations handled by the provider
+	Examples []string `json:"examples"`
+	// HasMetadata is true if stream metadata (title, thumbnail, etc.) can be fetched
+	HasMetadata bool `json:"hasMetadata"`
+	// HasDuration is true if a stream's duration can be determined
+	HasDuration bool `json:"hasDuration"`
+
+	newStream func(*url.URL, string) (Stream, error)
+}
+
+const (
+	PROVIDER_REMOTE = "remote"
+	PROVIDER_LOCAL  = "local"
+)
+
+// Providers is the list of supported stream providers
+var Providers = []*Provider{
+	{
+		Name:        STREAM_TYPE_YOUTUBE,
+		Kind:        STREAM_TYPE_YOUTUBE,
+		Hosts:       []string{"youtube.com", "youtu.be", "m.youtube.com", "music.youtube.com", "youtube-nocookie.com"},
+		Examples:    []string{"https://www.youtube.com/watch?v=<id>", "https://youtu.be/<id>", "https://www.youtube.com/embed/<id>"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			return NewYouTubeStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        STREAM_TYPE_SOUNDCLOUD,
+		Kind:        STREAM_TYPE_SOUNDCLOUD,
+		Hosts:       []string{"api.soundcloud.com", "soundcloud.com"},
+		Examples:    []string{"https://soundcloud.com/<artist>/<track>"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			return NewSoundCloudStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        STREAM_TYPE_TWITCH,
+		Kind:        STREAM_TYPE_TWITCH,
+		Hosts:       []string{"twitch.tv"},
+		Examples:    []string{"https://www.twitch.tv/videos/<id>"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			return NewTwitchStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        STREAM_TYPE_TWITCH_CLIP,
+		Kind:        STREAM_TYPE_TWITCH_CLIP,
+		Hosts:       []string{"clips-media-assets.twitch.tv"},
+		Examples:    []string{"https://clips-media-assets.twitch.tv/<file>.mp4?clip=<slug>"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			params := u.Query()
+			if len(params.Get("clip")) == 0 {
+				return nil, fmt.Errorf("invalid Twitch clip url. Missing ?clip= parameter")
+			}
+
+			return NewTwitchClipStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        STREAM_TYPE_DAILYMOTION,
+		Kind:        STREAM_TYPE_DAILYMOTION,
+		Hosts:       []string{"dailymotion.com", "dai.ly"},
+		Examples:    []string{"https://www.dailymotion.com/video/<id>", "https://dai.ly/<id>"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			if _, err := dailymotionVideoIdFromUrl(streamUrl); err != nil {
+				return nil, fmt.Errorf("invalid Dailymotion url. Expected a link to a video")
+			}
+
+			return NewDailymotionStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        PROVIDER_REMOTE,
+		Kind:        STREAM_TYPE_REMOTE,
+		Formats:     []string{".mp4", ".webm", ".mkv"},
+		Examples:    []string{"https://example.com/path/to/video.mp4"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream: func(u *url.URL, streamUrl string) (Stream, error) {
+			return NewRemoteVideoStream(streamUrl), nil
+		},
+	},
+	{
+		Name:        PROVIDER_LOCAL,
+		Kind:        STREAM_TYPE_LOCAL,
+		Examples:    []string{"video.mp4"},
+		HasMetadata: true,
+		HasDuration: true,
+		newStream:   newLocalStream,
+	},
+}
+
+// ProviderByName returns a provider by the given name,
+// or a boolean (false) if no such provider exists.
+func ProviderByName(name string) (*Provider, bool) {
+	for _, p := range Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ProviderForUrl receives a parsed stream url and returns the provider
+// that handles it, or a boolean (false) if the url is not supported.
+// Urls with no http(s) scheme are handled by the local provider.
+func ProviderForUrl(u *url.URL) (*Provider, bool) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ProviderByName(PROVIDER_LOCAL)
+	}
+
+	host := u.Host
+	segs := strings.Split(u.Host, "www.")
+	if len(segs) > 1 {
+		host = segs[1]
+	}
+
+	for _, p := range Providers {
+		for _, h := range p.Hosts {
+			if h == host {
+				return p, true
+			}
+		}
+	}
+
+	// handle remote urls
+	format := strings.ToLower(paths.FileExtensionFromFilePath(u.Path))
+	for _, p := range Providers {
+		for _, f := range p.Formats {
+			if f == format {
+				return p, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// newLocalStream creates a stream for a video file in the server's stream data root
+func newLocalStream(u *url.URL, streamUrl string) (Stream, error) {
+	fpath := paths.StreamDataFilePathFromFilename(streamUrl)
+
+	// determine if a mimetype can be determined from the requested filepath,
+	// and that the mimetype (if any) is supported.
+	mimeType, err := paths.FileMimeFromFilePath(streamUrl)
+	if err != nil || !strings.HasPrefix(mimeType, "video") {
+		log.Printf("ERR SOCKET CLIENT error parsing file mimetype (%q): %v", mimeType, err)
+		return nil, fmt.Errorf("unable to load %q. Unsupported streaming file.", streamUrl)
+	}
+
+	_, err = os.Stat(fpath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to load %q: video file does not exist.", streamUrl)
+		}
+		return nil, fmt.Errorf("unable to load %q: %v", streamUrl, err)
+	}
+
+	return NewLocalVideoStream(streamUrl), nil
+}
@@ -2,11 +2,13 @@ package stream
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +27,7 @@ const (
 	STREAM_TYPE_TWITCH      = "twitch"
 	STREAM_TYPE_TWITCH_CLIP = "twitch#clip"
 	STREAM_TYPE_SOUNDCLOUD  = "soundcloud"
+	STREAM_TYPE_DAILYMOTION = "dailymotion"
 )
 
 type StreamMetadataCallback func(Stream, []byte, error)
@@ -79,6 +82,8 @@ type StreamMeta interface {
 	RemoveParentRef(StreamRef) bool
 	// GetParentRefs returns a list of all currently stored parentRefs
 	GetParentRefs() []StreamRef
+	// ParentRefKeys returns a sorted list of the ids of all currently stored parentRefs
+	ParentRefKeys() []string
 	// SetLabelledRef receives a key, value pair, adding the new pair
 	// if the key does not yet exist in the list of labelledRefs, or
 	// replacing the value of the key if the key already exists.
@@ -93,6 +98,8 @@ type StreamMeta interface {
 	// GetLabelledRef returns the ref stored under the given key and a boolean true,
 	// or a boolean false if the given key does not exist.
 	GetLabelledRef(string) (StreamRef, bool)
+	// LabelledRefKeys returns a sorted list of all currently stored labelledRef keys
+	LabelledRefKeys() []string
 }
 
 // StreamMetaSchema implements StreamMeta
@@ -132,6 +139,15 @@ func (s *StreamMetaSchema) GetParentRefs() []StreamRef {
 	return refs
 }
 
+func (s *StreamMetaSchema) ParentRefKeys() []string {
+	keys := []string{}
+	for k := range s.ParentRefs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s *StreamMetaSchema) AddParentRef(ref StreamRef) bool {
 	if _, exists := s.ParentRefs[ref.UUID()]; exists {
 		return false
@@ -166,6 +182,15 @@ func (s *StreamMetaSchema) GetLabelledRef(key string) (StreamRef, bool) {
 	return nil, false
 }
 
+func (s *StreamMetaSchema) LabelledRefKeys() []string {
+	keys := []string{}
+	for k := range s.LabelledRefs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (s *StreamMetaSchema) RemoveLabelledRef(key string) bool {
 	if _, exists := s.LabelledRefs[key]; exists {
 		delete(s.LabelledRefs, key)
@@ -214,6 +239,17 @@ type Stream interface {
 	SetInfo([]byte) error
 }
 
+// DirectStream is a Stream that can be resolved into a resource
+// locator playable directly by a client's media player, rather
+// than through a provider's embedded player.
+type DirectStream interface {
+	Stream
+
+	// DirectURL returns a directly playable resource locator
+	// for the stream, or an error if one cannot be resolved.
+	DirectURL() (string, error)
+}
+
 // StreamSchema implements Stream
 // also implements an pkg/api/types.ApiCodec
 type StreamSchema struct {
@@ -331,15 +367,9 @@ func (s *YouTubeStream) FetchMetadata(callback StreamMetadataCallback) {
 	}
 
 	go func(videoId, apiKey string, callback StreamMetadataCallback) {
-		res, err := http.Get("https://www.googleapis.com/youtube/v3/videos?id=" + videoId + "&key=" + apiKey + "&part=contentDetails,snippet")
-		if err != nil {
-			callback(s, nil, err)
-			return
-		}
-
-		defer res.Body.Close()
-
-		data, err := ioutil.ReadAll(res.Body)
+		data, err := fetchMetadata(func() (*http.Request, error) {
+			return http.NewRequest("GET", "https://www.googleapis.com/youtube/v3/videos?id="+videoId+"&key="+apiKey+"&part=contentDetails,snippet", nil)
+		})
 		if err != nil {
 			callback(s, nil, err)
 			return
@@ -404,6 +434,18 @@ func NewYouTubeStream(videoUrl string) Stream {
 	}
 }
 
+// ErrYouTubeNoDirectUrl is returned when a youtube stream is requested
+// in "direct" mode. The server has no endpoint transforming youtube
+// videos into a directly playable resource, so youtube streams are
+// always played through their embedded player.
+var ErrYouTubeNoDirectUrl = errors.New("youtube streams cannot be played directly by this server, only through the embedded youtube player")
+
+// DirectURL implements DirectStream. YouTube videos
+// cannot currently be resolved into a direct url.
+func (s *YouTubeStream) DirectURL() (string, error) {
+	return "", ErrYouTubeNoDirectUrl
+}
+
 // LocalVideoStream implements Stream
 // and represents a video stream from
 // a local filepath.
@@ -455,6 +497,10 @@ func FetchVideoMetadata(fpath string) ([]byte, error) {
 	return m, nil
 }
 
+func (s *LocalVideoStream) DirectURL() (string, error) {
+	return s.Url, nil
+}
+
 func NewLocalVideoStream(filepath string) Stream {
 	return &LocalVideoStream{
 		StreamSchema: &StreamSchema{
@@ -484,6 +530,10 @@ type RemoteVideoStream struct {
 	*StreamSchema
 }
 
+func (s *RemoteVideoStream) DirectURL() (string, error) {
+	return s.Url, nil
+}
+
 func NewRemoteVideoStream(url string) Stream {
 	return &RemoteVideoStream{
 		StreamSchema: &StreamSchema{
@@ -525,25 +575,15 @@ func (s *TwitchStream) FetchMetadata(callback StreamMetadataCallback) {
 	}
 
 	go func(videoId, apiKey string, callback StreamMetadataCallback) {
-		client := &http.Client{}
-
-		req, err := http.NewRequest("GET", "https://api.twitch.tv/kraken/videos/"+videoId, nil)
-		if err != nil {
-			callback(s, nil, err)
-			return
-		}
-
-		req.Header.Set("Client-ID", apiKey)
-
-		res, err := client.Do(req)
-		if err != nil {
-			callback(s, nil, err)
-			return
-		}
-
-		defer res.Body.Close()
-
-		data, err := ioutil.ReadAll(res.Body)
+		data, err := fetchMetadata(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", "https://api.twitch.tv/kraken/videos/"+videoId, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Client-ID", apiKey)
+			return req, nil
+		})
 		if err != nil {
 			callback(s, nil, err)
 			return
@@ -623,26 +663,16 @@ func (s *TwitchClipStream) FetchMetadata(callback StreamMetadataCallback) {
 	}
 
 	go func(videoId, apiKey string, callback StreamMetadataCallback) {
-		client := &http.Client{}
-
-		req, err := http.NewRequest("GET", "https://api.twitch.tv/kraken/clips/"+videoId, nil)
-		if err != nil {
-			callback(s, nil, err)
-			return
-		}
-
-		req.Header.Set("Client-ID", apiKey)
-		req.Header.Set("Accept", "application/vnd.twitchtv.v5+json")
-
-		res, err := client.Do(req)
-		if err != nil {
-			callback(s, nil, err)
-			return
-		}
-
-		defer res.Body.Close()
-
-		data, err := ioutil.ReadAll(res.Body)
+		data, err := fetchMetadata(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", "https://api.twitch.tv/kraken/clips/"+videoId, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Client-ID", apiKey)
+			req.Header.Set("Accept", "application/vnd.twitchtv.v5+json")
+			return req, nil
+		})
 		if err != nil {
 			callback(s, nil, err)
 			return
@@ -691,7 +721,13 @@ type SoundCloudStream struct {
 	apiKey string
 }
 
+var (
+	ErrSoundCloudNoClientId = errors.New("soundcloud streams are unavailable: no soundcloud client id is configured")
+	ErrSoundCloudPlaylist   = errors.New("soundcloud playlists are not supported as a single stream, link a single track instead")
+)
+
 type SoundCloudResponseItem struct {
+	Kind     string             `json:"kind"`
 	Title    string             `json:"title"`
 	Duration int                `json:"duration"`
 	User     SoundCloudUserItem `json:"user"`
@@ -705,11 +741,16 @@ type SoundCloudVideoItem map[string]interface{}
 
 func (s *SoundCloudStream) FetchMetadata(callback StreamMetadataCallback) {
 	go func(videoId, apiKey string, callback StreamMetadataCallback) {
+		if len(apiKey) == 0 {
+			callback(s, nil, ErrSoundCloudNoClientId)
+			return
+		}
+
 		// resolve permalink
 		permalink := url.QueryEscape(videoId)
 
 		// resolve permalink into track id
-		resolveUrl := fmt.Sprintf("https://api.soundcloud.com/resolve.json?url=%s&client_id=%s", permalink, apiconfig.SC_API_KEY)
+		resolveUrl := fmt.Sprintf("https://api.soundcloud.com/resolve.json?url=%s&client_id=%s", permalink, apiKey)
 		res, err := http.Get(resolveUrl)
 		if err != nil {
 			callback(s, nil, err)
@@ -718,6 +759,11 @@ func (s *SoundCloudStream) FetchMetadata(callback StreamMetadataCallback) {
 
 		defer res.Body.Close()
 
+		if err := SoundCloudResolveError(res.StatusCode); err != nil {
+			callback(s, nil, err)
+			return
+		}
+
 		data, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			callback(s, nil, err)
@@ -730,6 +776,10 @@ func (s *SoundCloudStream) FetchMetadata(callback StreamMetadataCallback) {
 			callback(s, nil, err)
 			return
 		}
+		if scResponseItem.Kind == "playlist" {
+			callback(s, nil, ErrSoundCloudPlaylist)
+			return
+		}
 
 		// craft callback metadata response with default fields
 		scVideoItem := SoundCloudVideoItem{}
@@ -747,6 +797,23 @@ func (s *SoundCloudStream) FetchMetadata(callback StreamMetadataCallback) {
 	}(s.Url, s.apiKey, callback)
 }
 
+// SoundCloudResolveError receives the status code of a response to a
+// SoundCloud resolve request and returns a user-friendly error describing
+// why the permalink could not be resolved, or nil if it was resolved.
+func SoundCloudResolveError(statusCode int) error {
+	switch {
+	case statusCode == http.StatusOK:
+		return nil
+	case statusCode == http.StatusNotFound:
+		return fmt.Errorf("soundcloud track not found: it may have been removed, or the link may be incorrect")
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return fmt.Errorf("soundcloud track is private or not available for playback")
+	case statusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("soundcloud is rate-limiting requests, try again later")
+	}
+	return fmt.Errorf("unable to resolve soundcloud link: soundcloud responded with status %v", statusCode)
+}
+
 func NewSoundCloudStream(videoUrl string) Stream {
 	return &SoundCloudStream{
 		StreamSchema: &StreamSchema{
@@ -759,25 +826,194 @@ func NewSoundCloudStream(videoUrl string) Stream {
 	}
 }
 
+// DailymotionStream implements Stream
+// and represents a dailymotion video stream
+// data and state
+type DailymotionStream struct {
+	*StreamSchema
+}
+
+// DailymotionResponseItem contains dailymotion api
+// response data for a unique dailymotion video
+type DailymotionResponseItem struct {
+	Title     string  `json:"title"`
+	Duration  float64 `json:"duration"`
+	Thumbnail string  `json:"thumbnail_url"`
+}
+
+type DailymotionVideoItem map[string]interface{}
+
+func (s *DailymotionStream) FetchMetadata(callback StreamMetadataCallback) {
+	videoId, err := dailymotionVideoIdFromUrl(s.Url)
+	if err != nil {
+		callback(s, []byte{}, err)
+		return
+	}
+
+	go func(videoId string, callback StreamMetadataCallback) {
+		data, err := fetchMetadata(func() (*http.Request, error) {
+			return http.NewRequest("GET", "https://api.dailymotion.com/video/"+url.PathEscape(videoId)+"?fields=title,duration,thumbnail_url", nil)
+		})
+		if err != nil {
+			callback(s, nil, err)
+			return
+		}
+
+		responseItem := &DailymotionResponseItem{}
+		err = json.Unmarshal(data, responseItem)
+		if err != nil {
+			callback(s, nil, err)
+			return
+		}
+		if len(responseItem.Title) == 0 {
+			callback(s, nil, fmt.Errorf("no metadata found for dailymotion video id %q", videoId))
+			return
+		}
+
+		// craft callback metadata response with default fields
+		dmVideoItem := DailymotionVideoItem{}
+		dmVideoItem["name"] = responseItem.Title
+		dmVideoItem["duration"] = responseItem.Duration
+		dmVideoItem["thumb"] = responseItem.Thumbnail
+
+		jsonData, err := json.Marshal(dmVideoItem)
+		if err != nil {
+			callback(s, nil, err)
+			return
+		}
+
+		callback(s, jsonData, nil)
+	}(videoId, callback)
+}
+
+func NewDailymotionStream(videoUrl string) Stream {
+	return &DailymotionStream{
+		StreamSchema: &StreamSchema{
+			Url:  videoUrl,
+			Kind: STREAM_TYPE_DAILYMOTION,
+			Meta: NewStreamMeta(),
+		},
+	}
+}
+
+// ResourceIdentifiers receives a stream resource location and returns the
+// host it is served from (without a "www." prefix), along with its video id
+// for providers whose urls contain one. Local resource locations have no
+// host, and are their own video id.
+func ResourceIdentifiers(streamUrl string) (string, string) {
+	u, err := url.Parse(streamUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", streamUrl
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	var id string
+	switch host {
+	case "youtube.com", "youtu.be", "m.youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		id, err = ytVideoIdFromUrl(streamUrl)
+	case "twitch.tv":
+		id, err = twitchVideoIdFromUrl(streamUrl)
+	case "dailymotion.com", "dai.ly":
+		id, err = dailymotionVideoIdFromUrl(streamUrl)
+	}
+	if err != nil {
+		id = ""
+	}
+
+	return host, id
+}
+
+// ytVideoIdFromUrl receives a youtube video url and returns its video id.
+// Both "watch?v=<id>" urls (including music.youtube.com) and urls ending
+// in the video id (youtu.be/<id>, /embed/<id> on youtube.com and
+// youtube-nocookie.com) are supported.
 func ytVideoIdFromUrl(videoUrl string) (string, error) {
-	segs := strings.Split(videoUrl, "/")
-	if len(segs) < 2 {
+	u, err := url.Parse(videoUrl)
+	if err != nil {
 		return "", fmt.Errorf("invalid url")
 	}
 
-	lastSeg := segs[len(segs)-1]
+	if id := u.Query().Get("v"); len(id) > 0 {
+		return id, nil
+	}
+
+	segs := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
+	if len(segs) < 2 || len(segs[len(segs)-1]) == 0 {
+		return "", fmt.Errorf("invalid url")
+	}
+
+	return segs[len(segs)-1], nil
+}
+
+// YouTubeTimestamp receives a youtube video url and returns its video id,
+// and the amount of seconds into the video it links to, given by its "t"
+// (e.g. "90", "90s", "1m30s") or "start" parameter. Returns a boolean
+// (false) if the url is not a youtube url, or does not link to a timestamp.
+func YouTubeTimestamp(videoUrl string) (string, int, bool) {
+	u, err := url.Parse(videoUrl)
+	if err != nil {
+		return "", 0, false
+	}
+	if provider, ok := ProviderForUrl(u); !ok || provider.Name != STREAM_TYPE_YOUTUBE {
+		return "", 0, false
+	}
+
+	t := u.Query().Get("t")
+	if len(t) == 0 {
+		t = u.Query().Get("start")
+	}
+	if len(t) == 0 {
+		return "", 0, false
+	}
 
-	if matched, _ := regexp.MatchString("watch\\?v=", lastSeg); matched {
-		idSegs := strings.Split(lastSeg, "watch?v=")
-		ampSegs := strings.Split(idSegs[1], "&")
-		if len(ampSegs) > 1 {
-			return ampSegs[0], nil
+	start, err := strconv.Atoi(t)
+	if err != nil {
+		start, err = util.HumanTimeToSeconds(t)
+		if err != nil {
+			return "", 0, false
 		}
+	}
+	if start <= 0 {
+		return "", 0, false
+	}
 
-		return idSegs[1], nil
+	id, err := ytVideoIdFromUrl(videoUrl)
+	if err != nil {
+		return "", 0, false
+	}
+	return id, start, true
+}
+
+// dailymotionVideoIdFromUrl receives a dailymotion video url and returns
+// its video id. Both dailymotion.com/video/<id> urls (including embed
+// urls, and ids followed by a "_<title>" slug) and dai.ly/<id> short
+// links are supported.
+func dailymotionVideoIdFromUrl(videoUrl string) (string, error) {
+	u, err := url.Parse(videoUrl)
+	if err != nil {
+		return "", fmt.Errorf("invalid url")
+	}
+
+	var id string
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") == "dai.ly" {
+		id = segs[0]
+	} else {
+		for i, seg := range segs {
+			if seg == "video" && i+1 < len(segs) {
+				id = segs[i+1]
+				break
+			}
+		}
+	}
+
+	id = strings.Split(id, "_")[0]
+	if len(id) == 0 {
+		return "", fmt.Errorf("invalid url")
 	}
 
-	return lastSeg, nil
+	return id, nil
 }
 
 func twitchVideoIdFromUrl(videoUrl string) (string, error) {
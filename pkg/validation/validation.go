@@ -8,6 +8,9 @@ import (
 var ClientValidationPattern string = "^[a-zA-Z_0-9]+$"
 var ClientValidation *regexp.Regexp
 
+// MaxWelcomeMessageLength is the maximum length of a room's welcome message
+var MaxWelcomeMessageLength = 280
+
 // ValidateClientUsername receives a username and returns an error if it does not comply
 // with ClientValidationPattern
 func ValidateClientUsername(name string) error {
@@ -18,6 +21,19 @@ func ValidateClientUsername(name string) error {
 	return nil
 }
 
+// ValidateWelcomeMessage receives a room welcome message and returns an error
+// if it is empty or longer than MaxWelcomeMessageLength
+func ValidateWelcomeMessage(msg string) error {
+	if len(msg) == 0 {
+		return fmt.Errorf("error: the welcome message cannot be empty")
+	}
+	if len(msg) > MaxWelcomeMessageLength {
+		return fmt.Errorf("error: the welcome message cannot be longer than %v characters", MaxWelcomeMessageLength)
+	}
+
+	return nil
+}
+
 func init() {
 	var err error
 	ClientValidation, err = regexp.Compile(ClientValidationPattern)
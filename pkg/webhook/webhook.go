@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	EVENT_ROOM_CREATED        = "room.created"
+	EVENT_ROOM_FIRST_PLAYBACK = "room.firstplayback"
+	EVENT_ROOM_REAPED         = "room.reaped"
+
+	// SIGNATURE_HEADER holds the hex-encoded HMAC-SHA256
+	// of the request body, keyed by the configured secret.
+	SIGNATURE_HEADER = "X-Streaming-Signature"
+
+	MaxPendingEvents = 100
+	MaxAttempts      = 3
+	RequestTimeout   = 5 * time.Second
+)
+
+// Notifier delivers room lifecycle events to an external endpoint
+type Notifier interface {
+	// Notify queues an event for delivery without blocking.
+	// Events are dropped if the delivery queue is full.
+	Notify(*Event)
+}
+
+// Event is the JSON payload delivered for a room lifecycle event
+type Event struct {
+	Room      string       `json:"room"`
+	Event     string       `json:"event"`
+	Timestamp time.Time    `json:"timestamp"`
+	Stream    *StreamEvent `json:"stream,omitempty"`
+}
+
+// StreamEvent describes the stream associated with an Event, if any
+type StreamEvent struct {
+	Url  string `json:"url"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// HTTPNotifier implements Notifier and POSTs events to
+// a webhook url from a single background worker.
+type HTTPNotifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+	events chan *Event
+}
+
+func (n *HTTPNotifier) Notify(e *Event) {
+	select {
+	case n.events <- e:
+	default:
+		log.Printf("WRN WEBHOOK delivery queue full; dropping %q event for room %q\n", e.Event, e.Room)
+	}
+}
+
+func (n *HTTPNotifier) work() {
+	for e := range n.events {
+		body, err := json.Marshal(e)
+		if err != nil {
+			log.Printf("ERR WEBHOOK unable to serialize %q event for room %q: %v\n", e.Event, e.Room, err)
+			continue
+		}
+
+		for attempt := 1; attempt <= MaxAttempts; attempt++ {
+			err = n.post(body)
+			if err == nil {
+				break
+			}
+
+			log.Printf("WRN WEBHOOK attempt %v/%v to deliver %q event for room %q failed: %v\n", attempt, MaxAttempts, e.Event, e.Room, err)
+			if attempt < MaxAttempts {
+				time.Sleep(time.Duration(attempt) * time.Second)
+			}
+		}
+	}
+}
+
+func (n *HTTPNotifier) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(SIGNATURE_HEADER, "sha256="+Sign(n.secret, body))
+	}
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %q", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of the given body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewHTTPNotifier receives a webhook url and a (possibly empty) signing
+// secret, and returns a Notifier whose delivery worker has been started.
+func NewHTTPNotifier(url, secret string) Notifier {
+	n := &HTTPNotifier{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{
+			Timeout: RequestTimeout,
+		},
+		events: make(chan *Event, MaxPendingEvents),
+	}
+
+	go n.work()
+	return n
+}